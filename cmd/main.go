@@ -1,10 +1,23 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"steplife-universal-importer-gui/internal/cli"
 	"steplife-universal-importer-gui/internal/gui"
 )
 
 func main() {
+	// 带参数时使用命令行模式
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// GUI 模式
 	guiApp := gui.NewGUI()
 	guiApp.Run()
@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/server"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strconv"
+	"strings"
+)
+
+// Run
+//
+//	@Description: 	命令行模式入口
+//	@param args		命令行参数（不含程序名）
+//	@return error
+func Run(args []string) error {
+	fs := flag.NewFlagSet("steplife-importer", flag.ContinueOnError)
+	input := fs.String("input", "", "轨迹文件或包含轨迹文件的目录（必填）")
+	output := fs.String("output", "", "输出目录，默认为输入所在目录下的 output 文件夹")
+	configPath := fs.String("config", "", "配置文件路径，默认为环境变量 "+consts.ConfigPathEnv+" 或用户配置目录下的 "+consts.ConfigDirName+"/"+consts.ConfigFileName+"，不存在时使用默认配置")
+	profile := fs.String("profile", "", "使用配置文件中的命名配置，如 高铁、飞机、徒步、驾车")
+	formats := fs.String("formats", "", "输出格式，逗号分隔：csv,gpx,kml,geojson,ovjsn（CSV 始终输出）")
+	startTime := fs.String("start", "", "开始时间，如 2024-01-01 08:00:00，默认为当前时间")
+	endTime := fs.String("end", "", "结束时间（可选）")
+	timeInterval := fs.Int64("interval", 0, "时间间隔（秒，可选）")
+	timezone := fs.String("timezone", "", "时区，如 Asia/Shanghai，auto 表示按轨迹坐标推断，默认为系统本地时区")
+	altitudeMode := fs.String("altitude-mode", "", "海拔处理方式：source、constant、linear、flight、dem")
+	demDir := fs.String("dem-dir", "", "本地 SRTM .hgt / GeoTIFF 高程瓦片目录（-altitude-mode dem 时使用）")
+	distanceMode := fs.String("distance-mode", "", "距离字段：step（与上一个点的距离）、cumulative（累计距离）")
+	timeMode := fs.String("time-mode", "", "时间来源：auto（轨迹带原始时间时保留）、override（默认，始终按 -start/-end/-interval 分配）")
+	manifest := fs.String("manifest", "", "任务清单（.json、.yaml、.yml），按清单中的输入、配置、输出和合并方式转换（不需要 -input）")
+	archive := fs.String("archive", "", "已有的一生足迹 CSV，设置后将新轨迹合并到其中并输出单个 CSV")
+	batch := fs.Bool("batch", false, "目录模式下将所有文件按顺序合并为一个 CSV，时间重叠的文件整体后移")
+	batchOrder := fs.String("batch-order", "", "合并顺序：time（按轨迹开始时间）、list（按文件路径顺序）")
+	batchGap := fs.Int64("batch-gap", 0, "合并时相邻两个文件之间至少间隔的时间（秒）")
+	batchConnector := fs.String("batch-connector", "", "合并时文件之间的连接段：straight、greatcircle、flight、rail，默认不连接")
+	mergeStrategy := fs.String("merge-strategy", "", "时间重叠处理方式：keep（保留已有）、replace（替换）、shift（平移到空闲时间段）")
+	simplifyAlgorithm := fs.String("simplify", "", "插点前的轨迹简化算法：douglas-peucker、visvalingam")
+	simplifyTolerance := fs.Float64("simplify-tolerance", 0, "轨迹简化容差（米）")
+	outlierFilter := fs.String("filter", "", "按交通方式剔除漂移点：walk、bike、car、rail、flight")
+	smoothMethod := fs.String("smooth", "", "轨迹平滑方式：median、kalman")
+	stayPoint := fs.Bool("stay", false, "检测停留点并折叠停留期间的抖动点")
+	stayRadius := fs.Float64("stay-radius", 0, "停留半径（米）")
+	stayDuration := fs.Int64("stay-duration", 0, "最短停留时间（秒）")
+	exportStops := fs.Bool("export-stops", false, "在 GPX/KML/GeoJSON 中输出命名停留点")
+	segmentGapDuration := fs.Int64("segment-gap-duration", 0, "轨迹内相邻点时间间隔超过该值（秒）视为缺口")
+	segmentGapDistance := fs.Float64("segment-gap-distance", 0, "轨迹内相邻点距离超过该值（米）视为缺口")
+	segmentMode := fs.String("segment-mode", "", "缺口处理方式：interpolate（插点连接）、empty（留空）、split（拆分输出）")
+	segmentOverrides := fs.String("segment-overrides", "", "按缺口序号单独指定处理方式，如 2:interpolate,3:split")
+	gapFile := fs.String("gap-file", "", "已有的一生足迹 CSV，列出其中的轨迹缺口（不需要 -input）")
+	gapMinDuration := fs.Int64("gap-min-duration", 0, "缺口判定的时间阈值（秒）")
+	gapMinDistance := fs.Float64("gap-min-distance", 0, "缺口判定的距离阈值（米）")
+	fill := fs.String("fill", "", "需要补全的缺口序号，逗号分隔，可用 序号:方式 单独指定方式，all 表示全部，如 1,3:flight")
+	fillMode := fs.String("fill-mode", consts.FillModeGreatCircle, "默认补全方式：straight、greatcircle、flight、rail")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s v%s\n\n用法: main -input <文件或目录> [选项]\n      main -manifest <任务清单> [选项]\n\n选项:\n", consts.AppName, consts.Version)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\n支持的输入格式:\n")
+		for _, format := range parser.Formats() {
+			fmt.Fprintf(fs.Output(), "  %-14s %s\n", strings.Join(format.Extensions, ", "), format.Name)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	config, err := loadConfig(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	if *gapMinDuration > 0 {
+		config.GapMinDuration = *gapMinDuration
+	}
+	if *gapMinDistance > 0 {
+		config.GapMinDistance = *gapMinDistance
+	}
+	if *gapFile != "" {
+		return runGapFill(*gapFile, *output, *fill, *fillMode, config)
+	}
+
+	// 命令行参数覆盖配置文件
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "formats":
+			config.OutputFormats = *formats
+		case "start":
+			config.PathStartTime = *startTime
+		case "end":
+			config.PathEndTime = *endTime
+		case "interval":
+			config.TimeInterval = *timeInterval
+		case "timezone":
+			config.Timezone = *timezone
+		case "time-mode":
+			config.TimeMode = *timeMode
+		case "altitude-mode":
+			config.AltitudeMode = *altitudeMode
+		case "dem-dir":
+			config.DemDirectory = *demDir
+		case "distance-mode":
+			config.DistanceMode = *distanceMode
+		case "batch":
+			config.CombineOutput = boolToInt(*batch)
+		case "batch-order":
+			config.BatchOrder = *batchOrder
+		case "batch-gap":
+			config.BatchGap = *batchGap
+		case "batch-connector":
+			config.BatchConnector = *batchConnector
+		case "merge-strategy":
+			config.MergeStrategy = *mergeStrategy
+		case "filter":
+			config.OutlierFilter = *outlierFilter
+		case "smooth":
+			config.SmoothMethod = *smoothMethod
+		case "simplify":
+			config.SimplifyAlgorithm = *simplifyAlgorithm
+		case "simplify-tolerance":
+			config.SimplifyTolerance = *simplifyTolerance
+		case "stay":
+			config.EnableStayPointDetection = boolToInt(*stayPoint)
+		case "stay-radius":
+			config.StayPointRadius = *stayRadius
+		case "stay-duration":
+			config.StayPointDuration = *stayDuration
+		case "export-stops":
+			config.ExportStayPoints = boolToInt(*exportStops)
+		case "segment-gap-duration":
+			config.SegmentGapDuration = *segmentGapDuration
+		case "segment-gap-distance":
+			config.SegmentGapDistance = *segmentGapDistance
+		case "segment-mode":
+			config.SegmentGapMode = *segmentMode
+		case "segment-overrides":
+			config.SegmentGapOverrides = *segmentOverrides
+		}
+	})
+
+	if *manifest != "" {
+		return runManifest(*manifest, config)
+	}
+	if *input == "" {
+		fs.Usage()
+		return fmt.Errorf("缺少 -input 参数")
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("配置错误：\n%w", err)
+	}
+	if err := server.ResolveTimestamps(&config); err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(*input)
+	if err != nil {
+		return fmt.Errorf("访问路径失败：%w", err)
+	}
+
+	outputDir := *output
+	if outputDir == "" {
+		outputDir = *input
+		if !fileInfo.IsDir() {
+			outputDir = filepath.Dir(*input)
+		}
+		outputDir = filepath.Join(outputDir, "output")
+	}
+	if err = os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败：%w", err)
+	}
+
+	filePaths, err := server.CollectFiles(*input, outputDir)
+	if err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return fmt.Errorf("未找到支持的文件格式(%s)", parser.ExtensionList())
+	}
+
+	// Ctrl+C 时停止转换
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// 同名配置文件（trip.kml.ini、trip.kml.json）或文件名中的时间优先于全局配置
+	files := make([]server.BatchFile, len(filePaths))
+	for i, outputPath := range server.OutputPaths(filePaths, outputDir) {
+		if files[i], err = server.NewBatchFile(filePaths[i], outputPath, config, nil); err != nil {
+			return err
+		}
+	}
+
+	if *archive != "" {
+		baseName := strings.TrimSuffix(filepath.Base(*archive), filepath.Ext(*archive))
+		outputPath := filepath.Join(outputDir, baseName+"_merged.csv")
+		if _, err = server.MergeIntoArchive(ctx, *archive, files, outputPath, config); err != nil {
+			return err
+		}
+		logx.InfoF("合并结果已写入：%s", outputPath)
+		return nil
+	}
+
+	if fileInfo.IsDir() && config.CombineOutput == 1 {
+		outputPath := filepath.Join(outputDir, filepath.Base(filepath.Clean(*input))+"_steplife.csv")
+		result, err := server.CombineFiles(ctx, files, outputPath, config, server.FileHooks{})
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("转换已取消：%w", err)
+		} else if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d 个文件处理失败，已跳过，其余文件已合并输出：%s", len(result.Failed), outputPath)
+		}
+		return nil
+	}
+
+	job := server.NewJob(files, config)
+	var finished server.Event
+	job.Subscribe(logEvents(&finished))
+	if err = job.Run(ctx); errors.Is(err, context.Canceled) {
+		return fmt.Errorf("转换已取消：%w", err)
+	} else if err != nil {
+		return err
+	}
+
+	if finished.Failed > 0 {
+		return fmt.Errorf("%d 个文件处理失败", finished.Failed)
+	}
+	return nil
+}
+
+// runManifest 按任务清单转换，清单中的配置优先于配置文件和命令行参数
+func runManifest(manifestPath string, config model.Config) error {
+	manifest, err := server.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	plan, err := manifest.Plan(config)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C 时停止转换
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var finished server.Event
+	if err = plan.Run(ctx, logEvents(&finished)); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("转换已取消：%w", err)
+		}
+		return err
+	}
+	if finished.Failed > 0 {
+		return fmt.Errorf("%d 个文件处理失败", finished.Failed)
+	}
+	return nil
+}
+
+// logEvents 将任务事件写入日志，结束事件保存到 finished
+func logEvents(finished *server.Event) func(server.Event) {
+	warnings := 0
+	return func(event server.Event) {
+		switch event.Type {
+		case server.EventStarted:
+			logx.InfoF("开始处理%d个文件，并发数%d", event.Total, event.Workers)
+		case server.EventWarning:
+			warnings++ // 警告内容已由流水线写入日志
+		case server.EventFileDone:
+			switch {
+			case event.Err == nil:
+				logx.InfoF("文件处理完成：%s -> %s", event.FilePath, event.OutputPath)
+			case errors.Is(event.Err, context.Canceled):
+			default:
+				logx.ErrorF("处理文件失败 %s：%s", event.FilePath, event.Err)
+			}
+		case server.EventFinished:
+			*finished = event
+			logx.InfoF("处理结束：成功%d个，失败%d个，警告%d条", event.Succeeded, event.Failed, warnings)
+			if event.OutputPath != "" && event.Err == nil {
+				logx.InfoF("合并结果已写入：%s", event.OutputPath)
+			}
+		}
+	}
+}
+
+// runGapFill 列出已有足迹中的缺口，并按 -fill 指定的序号生成补全轨迹
+func runGapFill(gapFile, outputDir, fill, fillMode string, config model.Config) error {
+	rows, err := server.LoadStepLifeRows(gapFile)
+	if err != nil {
+		return err
+	}
+	gaps := server.FindGaps(rows, config.GapMinDuration, config.GapMinDistance)
+	for _, gap := range gaps {
+		fmt.Println(gap.Describe(config.Timezone))
+	}
+	if fill == "" || len(gaps) == 0 {
+		return nil
+	}
+
+	var fills []server.GapFill
+	for _, item := range strings.Split(fill, ",") {
+		item = strings.TrimSpace(item)
+		if item == "all" {
+			for _, gap := range gaps {
+				fills = append(fills, server.GapFill{Gap: gap, Mode: fillMode})
+			}
+			continue
+		}
+
+		mode := fillMode
+		if idx := strings.Index(item, ":"); idx >= 0 {
+			item, mode = item[:idx], item[idx+1:]
+		}
+		index, err := strconv.Atoi(item)
+		if err != nil || index < 1 || index > len(gaps) {
+			return fmt.Errorf("无效的缺口序号：%s", item)
+		}
+		fills = append(fills, server.GapFill{Gap: gaps[index-1], Mode: mode})
+	}
+
+	if outputDir == "" {
+		outputDir = filepath.Dir(gapFile)
+	}
+	if err = os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败：%w", err)
+	}
+	baseName := strings.TrimSuffix(filepath.Base(gapFile), filepath.Ext(gapFile))
+	outputPath := filepath.Join(outputDir, baseName+"_gapfill.csv")
+	count, err := server.FillGaps(fills, outputPath, config)
+	if err != nil {
+		return err
+	}
+	logx.InfoF("补全%d个缺口，共%d个点，已写入：%s", len(fills), count, outputPath)
+	return nil
+}
+
+// loadConfig 加载配置文件，configPath 为空时使用默认位置，不存在或加载失败时使用默认配置；设置了 profile 时使用对应的命名配置
+func loadConfig(configPath, profile string) (model.Config, error) {
+	var file *server.ConfigFile
+	var err error
+	if configPath == "" {
+		file, err = server.LoadDefaultConfigFile()
+	} else {
+		file, err = server.LoadConfigFile(configPath)
+	}
+	if err != nil {
+		logx.ErrorF("%v，使用默认配置", err)
+	}
+	if profile == "" {
+		return file.Config, nil
+	}
+	if err = file.Use(profile); err != nil {
+		return file.Config, err
+	}
+	logx.InfoF("使用命名配置「%s」", profile)
+	return file.Config, nil
+}
+
+// boolToInt 将布尔开关转换为配置文件中的 0/1
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
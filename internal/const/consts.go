@@ -14,7 +14,172 @@ const (
 	FileTypeVariFlight = "variflight"
 )
 
+// StreamFileSize 源文件达到该大小（字节）且配置支持时使用流式转换。
+// 完整流水线的峰值堆内存约为文件大小的 9 倍（GPX 20 MB → 171 MB，60 MB → 546 MB），流式转换恒定约 4 MB，
+// 但慢 15%~80%（go test -bench . ./internal/pipeline）；64 MB 时完整流水线约占 600 MB，更大的文件才值得用速度换内存
+const StreamFileSize = 64 << 20
+
 const (
 	MinInsertPointDistance     = 30
 	DefaultInsertPointDistance = 100
 )
+
+const (
+	// 一生足迹 CSV
+	OutputFormatCSV = "csv"
+	// 奥维互动地图 ovjsn
+	OutputFormatOvjsn = "ovjsn"
+	// GPX 1.1
+	OutputFormatGPX = "gpx"
+	// KML（gx:Track）
+	OutputFormatKML = "kml"
+	// GeoJSON
+	OutputFormatGeoJSON = "geojson"
+)
+
+const (
+	// 保留已有数据，丢弃与之重叠的新轨迹点
+	MergeStrategyKeep = "keep"
+	// 用新轨迹替换重叠时间段内的已有数据
+	MergeStrategyReplace = "replace"
+	// 将新轨迹整体平移到最近的空闲时间段
+	MergeStrategyShift = "shift"
+
+	// 已有数据中相邻两点间隔超过该值（秒）视为空闲时间段
+	DefaultMergeGapThreshold = 600
+)
+
+const (
+	// 直线补全（经纬度线性插值）
+	FillModeStraight = "straight"
+	// 大圆航线补全
+	FillModeGreatCircle = "greatcircle"
+	// 飞行剖面补全（大圆航线 + 爬升/巡航/下降海拔）
+	FillModeFlight = "flight"
+	// 按高铁速度补全
+	FillModeRail = "rail"
+
+	// 默认缺口判定阈值：时间间隔（秒）
+	DefaultGapMinDuration = 1800
+	// 默认缺口判定阈值：距离（米）
+	DefaultGapMinDistance = 5000
+	// 高铁补全速度（米/秒，约 300km/h）
+	RailFillSpeed = 83.3
+	// 飞行补全巡航高度（米）
+	FlightCruiseAltitude = 10000
+)
+
+const (
+	// 不简化轨迹
+	SimplifyNone = ""
+	// Douglas-Peucker 算法
+	SimplifyDouglasPeucker = "douglas-peucker"
+	// Visvalingam-Whyatt 算法
+	SimplifyVisvalingam = "visvalingam"
+
+	// 默认简化容差（米）
+	DefaultSimplifyTolerance = 5
+)
+
+const (
+	// 交通方式，用于异常点过滤的速度和加速度上限
+	TransportWalk   = "walk"
+	TransportBike   = "bike"
+	TransportCar    = "car"
+	TransportRail   = "rail"
+	TransportFlight = "flight"
+
+	// 中值滤波平滑
+	SmoothMedian = "median"
+	// 卡尔曼滤波平滑
+	SmoothKalman = "kalman"
+)
+
+const (
+	// 默认停留半径（米）
+	DefaultStayPointRadius = 50
+	// 默认最短停留时间（秒）
+	DefaultStayPointDuration = 300
+)
+
+// 轨迹缺口处理方式
+const (
+	SegmentModeInterpolate = "interpolate" // 跨缺口插点连接
+	SegmentModeEmpty       = "empty"       // 缺口处留空，不插点
+	SegmentModeSplit       = "split"       // 在缺口处拆分为独立的输出文件
+)
+
+// 时间来源
+const (
+	TimeModeAuto     = "auto"     // 轨迹带原始时间时保留，否则按配置分配
+	TimeModeOverride = "override" // 始终按配置分配，未设置时的默认值
+)
+
+// TimezoneAuto 按轨迹坐标自动推断时区
+const TimezoneAuto = "auto"
+
+// 海拔处理方式
+const (
+	AltitudeModeSource   = "source"   // 保留源文件海拔，没有时使用默认海拔
+	AltitudeModeConstant = "constant" // 所有点使用默认海拔
+	AltitudeModeLinear   = "linear"   // 按距离在起点和终点海拔之间线性变化
+	AltitudeModeFlight   = "flight"   // 飞行剖面：爬升至巡航高度后下降
+	AltitudeModeDEM      = "dem"      // 从本地 SRTM/GeoTIFF 高程数据查询地形高程
+)
+
+// 输出行距离字段的计算方式
+const (
+	DistanceModeStep       = "step"       // 与上一个点之间的距离
+	DistanceModeCumulative = "cumulative" // 从轨迹起点开始的累计距离
+)
+
+// 合并为一个 CSV 时多个文件的排列顺序
+const (
+	BatchOrderTime = "time" // 按各文件轨迹的开始时间
+	BatchOrderList = "list" // 按文件列表的顺序（GUI 中可调整）
+)
+
+const (
+	// 合并输出时相邻两个文件之间至少间隔的时间（秒）
+	DefaultBatchGap = 60
+	// 直线、大圆连接段估算耗时使用的速度（米/秒，约 60km/h）
+	ConnectorDriveSpeed = 16.7
+	// 飞行连接段估算耗时使用的速度（米/秒，约 900km/h）
+	ConnectorFlightSpeed = 250
+)
+
+// 文件单独的时间设置的来源
+const (
+	FileTimeSidecar  = "sidecar"  // 同名配置文件，如 trip.kml.ini、trip.kml.json
+	FileTimeFileName = "filename" // 文件名中的日期和时间段，如 2024-05-01_0800-1230_xxx.kml
+	FileTimeManual   = "manual"   // GUI 中按文件填写
+)
+
+// 任务清单
+const (
+	ManifestVersion = 1 // 当前支持的清单版本
+
+	ManifestMergeNone    = "none"    // 每个文件单独输出
+	ManifestMergeCombine = "combine" // 按顺序合并为一个 CSV
+	ManifestMergeArchive = "archive" // 合并到已有的一生足迹导出数据
+)
+
+// 命名配置
+const (
+	ProfileKey = "profile" // 配置文件默认分区中记录当前选择的命名配置
+
+	// 没有任何命名配置时提供的内置配置
+	ProfileRail   = "高铁"
+	ProfileFlight = "飞机"
+	ProfileHiking = "徒步"
+	ProfileDrive  = "驾车"
+)
+
+// 配置文件
+const (
+	ConfigFileName   = "config.ini"
+	ConfigDirName    = "steplife-importer" // 系统用户配置目录下存放配置文件的子目录
+	ConfigPathEnv    = "STEPLIFE_CONFIG"   // 指定配置文件路径的环境变量，优先于用户配置目录
+	ConfigVersionKey = "configVersion"     // 配置文件默认分区中记录版本的键，没有该键的旧配置视为版本 1
+	ConfigVersion    = 3                   // 当前的配置文件版本，旧版本读取时自动迁移
+)
@@ -1,16 +1,20 @@
 package gui
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	consts "steplife-universal-importer-gui/internal/const"
 	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/pipeline"
 	"steplife-universal-importer-gui/internal/server"
 	"steplife-universal-importer-gui/internal/utils/logx"
 	timeUtils "steplife-universal-importer-gui/internal/utils/time"
@@ -23,7 +27,6 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/pkg/errors"
-	"gopkg.in/ini.v1"
 )
 
 // hiddenFileFilter 自定义文件过滤器，隐藏以点开头的文件和文件夹
@@ -62,15 +65,24 @@ type GUI struct {
 	app             fyne.App
 	window          fyne.Window
 	config          model.Config
+	configFile      *server.ConfigFile // 配置文件，保存当前配置和命名配置
 	sourceDir       string
 	outputDir       string
-	createOutputDir bool // 是否创建output文件夹
-	isFileMode      bool // 是否为文件选择模式（true=文件，false=文件夹）
-	showLog         bool // 是否显示处理日志
-	isDarkTheme     bool // 当前是否为暗色主题
-	isInitialized   bool // 窗口是否已初始化
+	archivePath     string                     // 已有的一生足迹 CSV，设置后进入合并模式
+	batchOrder      []string                   // 合并为一个 CSV 时用户调整后的文件顺序，按列表顺序合并时使用
+	fileTimes       map[string]server.FileTime // 按文件填写的时间设置，优先于同名配置文件和文件名
+	createOutputDir bool                       // 是否创建output文件夹
+	isFileMode      bool                       // 是否为文件选择模式（true=文件，false=文件夹）
+	showLog         bool                       // 是否显示处理日志
+	isDarkTheme     bool                       // 当前是否为暗色主题
+	isInitialized   bool                       // 窗口是否已初始化
 	statusLabel     *widget.Label
+	timezoneLabel   *widget.Label // 自动推断时区时显示推断结果
 	progressBar     *widget.ProgressBar
+	processButton   *widget.Button
+	cancelButton    *widget.Button
+	cancelProcess   context.CancelFunc // 取消正在进行的处理
+	startupErrors   []error            // 窗口内容创建前出现的配置错误，界面就绪后以对话框提示
 	logText         *widget.Entry
 	logScroll       *container.Scroll
 	fontRegular     fyne.Resource
@@ -129,14 +141,7 @@ func NewGUI() *GUI {
 		createOutputDir: true,  // 默认创建output文件夹
 		isFileMode:      false, // 默认文件夹模式
 		showLog:         true,  // 默认显示日志
-		config: model.Config{
-			EnableInsertPointStrategy: 1,
-			InsertPointDistance:       100,
-			DefaultAltitude:           0.0,
-			SpeedMode:                 "auto",
-			ManualSpeed:               1.5,
-			EnableBatchProcessing:     1,
-		},
+		config:          model.NewDefaultConfig(),
 	}
 
 	mytheme := &myTheme{}                    // 设置自定义主题
@@ -162,11 +167,10 @@ func NewGUI() *GUI {
 func (g *GUI) Run() {
 	g.loadConfig()
 	g.createMainWindow()
-
-	// 设置GUI日志回调，将logx的日志输出到GUI
-	logx.SetGUILogger(func(message string) {
-		g.addLog(message)
-	})
+	for _, err := range g.startupErrors {
+		dialog.ShowError(err, g.window)
+	}
+	g.startupErrors = nil
 
 	g.window.ShowAndRun()
 }
@@ -199,47 +203,49 @@ func (g *GUI) toggleTheme() {
 	g.createMainWindow()
 }
 
-// loadConfig 加载配置文件
+// loadConfig 加载配置文件（默认位于用户配置目录），读取失败或配置无效时提示，开始处理前需修正
 func (g *GUI) loadConfig() {
-	cfg, err := ini.Load("config.ini")
+	file, err := server.LoadDefaultConfigFile()
+	g.configFile = file
 	if err != nil {
-		logx.Info("配置文件不存在，使用默认配置")
+		g.showConfigError(fmt.Errorf("%w，使用默认配置", err))
 		return
 	}
+	g.config = file.Config
+	g.addLog(fmt.Sprintf("配置文件: %s", file.Path))
+	for _, note := range file.Notes {
+		g.addLog("配置迁移: " + note)
+	}
 
-	err = cfg.MapTo(&g.config)
-	if err != nil {
-		logx.ErrorF("加载配置失败：%v", err)
+	if err = g.config.Validate(); err != nil {
+		g.showConfigError(fmt.Errorf("配置文件 %s 中的配置无效，请修正后再开始处理：\n%w", file.Path, err))
 		return
 	}
-
-	// 处理时间戳
-	if g.config.PathStartTime != "" {
-		g.config.PathStartTimestamp, _ = timeUtils.ToTimestampWithTimezone(g.config.PathStartTime, g.config.Timezone)
+	if err = server.ResolveTimestamps(&g.config); err != nil {
+		g.showConfigError(err)
 	}
-	if g.config.PathEndTime != "" {
-		g.config.PathEndTimestamp, _ = timeUtils.ToTimestampWithTimezone(g.config.PathEndTime, g.config.Timezone)
+}
+
+// showConfigError 在日志和对话框中提示配置错误，窗口创建前调用时对话框在界面就绪后显示
+func (g *GUI) showConfigError(err error) {
+	logx.Error(err.Error())
+	g.addLog(err.Error())
+	if !g.isInitialized {
+		g.startupErrors = append(g.startupErrors, err)
+		return
 	}
+	g.runOnUI(func() {
+		dialog.ShowError(err, g.window)
+	})
 }
 
-// saveConfig 保存配置到文件
+// saveConfig 校验并保存当前配置及命名配置到文件
 func (g *GUI) saveConfig() error {
-	cfg := ini.Empty()
-
-	section := cfg.Section("")
-
-	section.Key("enableInsertPointStrategy").SetValue(fmt.Sprintf("%d", g.config.EnableInsertPointStrategy))
-	section.Key("insertPointDistance").SetValue(fmt.Sprintf("%d", g.config.InsertPointDistance))
-	section.Key("pathStartTime").SetValue(g.config.PathStartTime)
-	section.Key("pathEndTime").SetValue(g.config.PathEndTime)
-	section.Key("timeInterval").SetValue(fmt.Sprintf("%d", g.config.TimeInterval))
-	section.Key("timezone").SetValue(g.config.Timezone)
-	section.Key("defaultAltitude").SetValue(fmt.Sprintf("%.2f", g.config.DefaultAltitude))
-	section.Key("speedMode").SetValue(g.config.SpeedMode)
-	section.Key("manualSpeed").SetValue(fmt.Sprintf("%.2f", g.config.ManualSpeed))
-	section.Key("enableBatchProcessing").SetValue(fmt.Sprintf("%d", g.config.EnableBatchProcessing))
-
-	return cfg.SaveTo("config.ini")
+	if err := g.config.Validate(); err != nil {
+		return err
+	}
+	g.configFile.Config = g.config
+	return g.configFile.Save()
 }
 
 // createMainWindow 创建主界面
@@ -261,6 +267,7 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	sourceDirEntry := widget.NewEntry()
 	sourceDirEntry.SetPlaceHolder("选择轨迹文件或包含轨迹文件的目录")
 	sourceDirEntry.Resize(fyne.NewSize(350, sourceDirEntry.MinSize().Height))
+	sourceDirEntry.SetText(g.sourceDir) // 切换主题、命名配置重新创建界面时保留已选择的路径
 	sourceDirButton := widget.NewButton("选择文件/目录", func() {
 		g.selectSource(sourceDirEntry)
 	})
@@ -272,6 +279,7 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	outputDirEntry := widget.NewEntry()
 	outputDirEntry.SetPlaceHolder("选择输出CSV文件的目录")
 	outputDirEntry.Resize(fyne.NewSize(300, outputDirEntry.MinSize().Height))
+	outputDirEntry.SetText(g.outputDir)
 
 	// 创建output文件夹开关
 	createOutputDirCheck := widget.NewCheck("创建 output 文件夹", func(checked bool) {
@@ -290,13 +298,23 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	// 参数设置区域
 	paramsCard := widget.NewCard("参数设置", "",
 		container.NewVBox(
+			g.createProfileSettings(),
+			widget.NewSeparator(),
 			g.createTimeSettings(),
 			widget.NewSeparator(),
 			g.createAltitudeSettings(),
 			widget.NewSeparator(),
 			g.createSpeedSettings(),
 			widget.NewSeparator(),
+			g.createFilterSettings(),
+			widget.NewSeparator(),
+			g.createStayPointSettings(),
+			widget.NewSeparator(),
+			g.createSimplifySettings(),
+			widget.NewSeparator(),
 			g.createInsertPointSettings(),
+			widget.NewSeparator(),
+			g.createSegmentSettings(),
 		),
 	)
 
@@ -359,6 +377,7 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	outputDirRow := container.NewVBox(
 		container.NewHBox(outputDirLabel, createOutputDirCheck),
 		outputDirContainer,
+		g.createOutputFormatSettings(),
 	)
 
 	// 状态和日志区域 - 使用Border布局让日志区域填充剩余空间
@@ -376,6 +395,9 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	fileSelectionArea := container.NewVBox(
 		sourceDirRow,
 		outputDirRow,
+		g.createBatchSettings(),
+		g.createMergeSettings(),
+		g.createGapFillSettings(),
 	)
 
 	// 可滚动的主内容区域（不包含按钮）
@@ -396,10 +418,20 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	scrollContainer.SetMinSize(fyne.NewSize(800, 600)) // 设置最小滚动区域大小
 
 	// 操作按钮（始终可见，位于底部）
-	processButton := widget.NewButtonWithIcon("开始处理", theme.MediaPlayIcon(), func() {
+	g.processButton = widget.NewButtonWithIcon("开始处理", theme.MediaPlayIcon(), func() {
 		g.startProcessing(sourceDirEntry.Text, outputDirEntry.Text)
 	})
-	processButton.Importance = widget.HighImportance
+	g.processButton.Importance = widget.HighImportance
+
+	// 取消按钮：停止尚未开始的文件，正在处理的文件在当前阶段结束后停止
+	g.cancelButton = widget.NewButtonWithIcon("取消", theme.CancelIcon(), func() {
+		if g.cancelProcess != nil {
+			g.addLog("正在取消处理...")
+			g.cancelProcess()
+			g.cancelButton.Disable()
+		}
+	})
+	g.cancelButton.Hide()
 
 	saveConfigButton := widget.NewButtonWithIcon("保存配置", theme.DocumentSaveIcon(), func() {
 		g.saveConfigDialog()
@@ -409,9 +441,16 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 		g.resetConfigDialog()
 	})
 
+	// 按任务清单（JSON / YAML）处理，输入、输出和合并方式均以清单为准
+	manifestButton := widget.NewButtonWithIcon("运行任务清单", theme.FileIcon(), func() {
+		g.selectManifest()
+	})
+
 	buttons := container.NewHBox(
 		layout.NewSpacer(),
-		processButton,
+		g.processButton,
+		g.cancelButton,
+		manifestButton,
 		saveConfigButton,
 		resetConfigButton,
 		layout.NewSpacer(),
@@ -463,7 +502,11 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 			sourceDirButton.SetText("选择目录")
 		}
 	}
-	modeSelect.SetSelected("文件夹模式") // 默认文件夹模式
+	if g.isFileMode {
+		modeSelect.SetSelected("单文件模式")
+	} else {
+		modeSelect.SetSelected("文件夹模式") // 默认文件夹模式
+	}
 
 	// 整体布局：滚动内容在上，按钮固定在底部
 	return container.NewBorder(
@@ -475,11 +518,150 @@ func (g *GUI) createMainLayout() fyne.CanvasObject {
 	)
 }
 
+// createProfileSettings 创建命名配置（如 高铁、飞机、徒步、驾车）的选择、另存为、删除、导入和导出组件
+func (g *GUI) createProfileSettings() fyne.CanvasObject {
+	profileSelect := widget.NewSelect(g.configFile.Names(), nil)
+	profileSelect.PlaceHolder = "未选择，使用当前设置"
+	profileSelect.SetSelected(g.configFile.Profile)
+	profileSelect.OnChanged = func(name string) {
+		if name != g.configFile.Profile {
+			g.useProfile(name)
+		}
+	}
+
+	saveAsButton := widget.NewButtonWithIcon("另存为", theme.DocumentSaveIcon(), func() {
+		g.showSaveProfileDialog()
+	})
+	deleteButton := widget.NewButtonWithIcon("删除", theme.DeleteIcon(), func() {
+		g.deleteProfileDialog(profileSelect.Selected)
+	})
+	importButton := widget.NewButtonWithIcon("导入", theme.FolderOpenIcon(), func() {
+		g.importProfiles()
+	})
+	exportButton := widget.NewButtonWithIcon("导出", theme.UploadIcon(), func() {
+		g.exportProfiles()
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("命名配置（插点距离、速度、海拔、时间等全部设置）:"),
+		container.NewBorder(nil, nil, nil,
+			container.NewHBox(saveAsButton, deleteButton, importButton, exportButton), profileSelect),
+	)
+}
+
+// useProfile 切换到命名配置，重新创建界面以显示其设置；点击"保存配置"后下次启动时仍使用该配置
+func (g *GUI) useProfile(name string) {
+	if err := g.configFile.Use(name); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.config = g.configFile.Config
+	g.createMainWindow()
+	g.addLog(fmt.Sprintf("已切换到配置「%s」", name))
+}
+
+// showSaveProfileDialog 将当前设置另存为命名配置，同名时覆盖，并保存到配置文件
+func (g *GUI) showSaveProfileDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("如 高铁、飞机、徒步、驾车")
+	nameEntry.SetText(g.configFile.Profile)
+
+	dialog.ShowForm("另存为命名配置", "保存", "取消", []*widget.FormItem{
+		widget.NewFormItem("名称", nameEntry),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if err := g.configFile.SetProfile(name, g.config); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.configFile.Profile = name
+		if err := g.saveConfig(); err != nil {
+			dialog.ShowError(errors.Wrap(err, "保存配置失败"), g.window)
+			return
+		}
+		g.createMainWindow()
+		g.addLog(fmt.Sprintf("当前设置已保存为配置「%s」", name))
+	}, g.window)
+}
+
+// deleteProfileDialog 删除命名配置并保存到配置文件，当前设置保持不变
+func (g *GUI) deleteProfileDialog(name string) {
+	if name == "" {
+		dialog.ShowError(errors.New("请先选择要删除的配置"), g.window)
+		return
+	}
+	dialog.ShowConfirm("删除配置", fmt.Sprintf("确定要删除配置「%s」吗？", name), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		g.configFile.DeleteProfile(name)
+		if err := g.saveConfig(); err != nil {
+			dialog.ShowError(errors.Wrap(err, "保存配置失败"), g.window)
+			return
+		}
+		g.createMainWindow()
+		g.addLog(fmt.Sprintf("已删除配置「%s」", name))
+	}, g.window)
+}
+
+// importProfiles 从 ini 文件导入命名配置，同名的配置被覆盖
+func (g *GUI) importProfiles() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		profiles, err := server.ImportProfiles(reader.URI().Path())
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		var names []string
+		for _, profile := range profiles {
+			if err = g.configFile.SetProfile(profile.Name, profile.Config); err != nil {
+				dialog.ShowError(err, g.window)
+				return
+			}
+			names = append(names, profile.Name)
+		}
+		if err = g.saveConfig(); err != nil {
+			dialog.ShowError(errors.Wrap(err, "保存配置失败"), g.window)
+			return
+		}
+		g.createMainWindow()
+		g.addLog(fmt.Sprintf("已导入 %d 个配置：%s", len(names), strings.Join(names, "、")))
+	}, g.window)
+	fileDialog.SetFilter(&hiddenFileFilter{extensions: []string{".ini"}})
+	fileDialog.Show()
+}
+
+// exportProfiles 将全部命名配置导出为 ini 文件，便于分享或在其它电脑上导入
+func (g *GUI) exportProfiles() {
+	fileDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err = server.ExportProfiles(writer, g.configFile.Profiles); err != nil {
+			dialog.ShowError(errors.Wrap(err, "导出配置失败"), g.window)
+			return
+		}
+		g.addLog(fmt.Sprintf("已导出 %d 个配置：%s", len(g.configFile.Profiles), writer.URI().Path()))
+	}, g.window)
+	fileDialog.SetFileName("profiles.ini")
+	fileDialog.Show()
+}
+
 // createTimeSettings 创建时间设置组件
 func (g *GUI) createTimeSettings() fyne.CanvasObject {
 	// 开始时间输入框和选择按钮
 	startTimeEntry := widget.NewEntry()
-	startTimeEntry.SetPlaceHolder("格式: 2024-01-01 08:00:00 (默认为系统时间)")
+	startTimeEntry.SetPlaceHolder("格式: 2024-01-01 08:00:00、2024年1月1日 8点 或时间戳 (默认为系统时间)")
 	startTimeEntry.SetText(g.config.PathStartTime)
 	startTimeEntry.Resize(fyne.NewSize(250, startTimeEntry.MinSize().Height))
 	startTimeEntry.OnChanged = func(text string) {
@@ -494,7 +676,7 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 
 	// 结束时间输入框和选择按钮
 	endTimeEntry := widget.NewEntry()
-	endTimeEntry.SetPlaceHolder("格式: 2024-01-01 18:00:00 (可选)")
+	endTimeEntry.SetPlaceHolder("格式: 2024-01-01 18:00:00、2024年1月1日 下午6点 或时间戳 (可选)")
 	endTimeEntry.SetText(g.config.PathEndTime)
 	endTimeEntry.Resize(fyne.NewSize(250, endTimeEntry.MinSize().Height))
 	endTimeEntry.OnChanged = func(text string) {
@@ -534,6 +716,7 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 	}
 	timezoneOptions := []timezoneOption{
 		{"系统本地时区", ""},
+		{"自动（按轨迹坐标推断）", consts.TimezoneAuto},
 		{"UTC (协调世界时)", "UTC"},
 		{"中国 (北京时间)", "Asia/Shanghai"},
 		{"日本 (东京)", "Asia/Tokyo"},
@@ -548,7 +731,7 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 		{"澳大利亚 (悉尼)", "Australia/Sydney"},
 		{"澳大利亚 (墨尔本)", "Australia/Melbourne"},
 	}
-	
+
 	// 创建显示名称列表和映射
 	timezoneDisplayNames := make([]string, len(timezoneOptions))
 	timezoneIDToDisplay := make(map[string]string)
@@ -556,13 +739,15 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 		timezoneDisplayNames[i] = opt.DisplayName
 		timezoneIDToDisplay[opt.TimezoneID] = opt.DisplayName
 	}
-	
+
 	// 创建时区显示名称到ID的映射
 	timezoneDisplayToID := make(map[string]string)
 	for _, opt := range timezoneOptions {
 		timezoneDisplayToID[opt.DisplayName] = opt.TimezoneID
 	}
-	
+
+	g.timezoneLabel = widget.NewLabel("")
+	g.timezoneLabel.Hide()
 	timezoneSelect := widget.NewSelect(timezoneDisplayNames, func(selected string) {
 		// 根据显示名称查找时区ID
 		if tzID, exists := timezoneDisplayToID[selected]; exists {
@@ -571,8 +756,9 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 			// 处理自定义时区
 			g.config.Timezone = strings.TrimPrefix(selected, "自定义: ")
 		}
+		g.updateInferredTimezone()
 	})
-	
+
 	// 设置当前选中的时区
 	if g.config.Timezone == "" {
 		timezoneSelect.SetSelected("系统本地时区")
@@ -590,24 +776,177 @@ func (g *GUI) createTimeSettings() fyne.CanvasObject {
 			timezoneSelect.SetSelected(customDisplay)
 		}
 	}
-	
-	timezoneContainer := container.NewBorder(nil, nil, nil, nil, timezoneSelect)
+
+	timezoneContainer := container.NewVBox(timezoneSelect, g.timezoneLabel)
+
+	// 时间来源
+	timeModeOptions := []struct {
+		DisplayName string
+		Mode        string
+	}{
+		{"始终按上述设置分配", consts.TimeModeOverride},
+		{"优先使用轨迹原始时间", consts.TimeModeAuto},
+	}
+	timeModeNames := make([]string, len(timeModeOptions))
+	for i, opt := range timeModeOptions {
+		timeModeNames[i] = opt.DisplayName
+	}
+	timeModeSelect := widget.NewSelect(timeModeNames, func(selected string) {
+		for _, opt := range timeModeOptions {
+			if opt.DisplayName == selected {
+				g.config.TimeMode = opt.Mode
+			}
+		}
+	})
+	timeModeSelect.SetSelected(timeModeNames[0])
+	for _, opt := range timeModeOptions {
+		if opt.Mode == g.config.TimeMode {
+			timeModeSelect.SetSelected(opt.DisplayName)
+		}
+	}
 
 	// 添加提示信息
-	tipLabel := widget.NewLabel("💡 提示：\n1. 如果设置了结束时间，系统会在开始和结束时间之间均匀分配时间\n2. 如果设置了时间间隔，系统会按照指定间隔分配时间（负数会反转时间顺序）\n3. 如果都没有设置，所有时间统一为开始时间\n4. 如果开始时间大于结束时间，轨迹将自动反转处理\n5. 时区设置会影响时间字符串的解析，选择对应的时区可确保时间戳正确")
+	tipLabel := widget.NewLabel("💡 提示：\n0. 轨迹的每个点都带有原始时间（如 GPX、TCX）时默认保留原始时间，以下设置仅对无时间的轨迹生效\n1. 如果设置了结束时间，系统会在开始和结束时间之间均匀分配时间\n2. 如果设置了时间间隔，系统会按照指定间隔分配时间（负数会反转时间顺序）\n3. 如果都没有设置，所有时间统一为开始时间\n4. 如果开始时间大于结束时间，轨迹将自动反转处理\n5. 时区设置会影响时间字符串的解析，选择对应的时区可确保时间戳正确；选择自动时按轨迹坐标离线推断时区，跨时区的多段轨迹按每段推断\n6. 每个文件可以单独设置时间：同名配置文件（如 trip.kml.ini、trip.kml.json）、文件名中的日期和时间段（如 2024-05-01_0800-1230_xxx.kml）或点击\"按文件设置\"填写，以上设置仅作为没有单独设置的文件的默认值")
 	tipLabel.Wrapping = fyne.TextWrapWord
 
+	fileTimeButton := widget.NewButtonWithIcon("按文件设置", theme.ListIcon(), func() {
+		g.showFileTimesDialog()
+	})
+
 	return container.NewVBox(
 		container.New(layout.NewFormLayout(),
 			widget.NewLabel("开始时间:"), startTimeContainer,
 			widget.NewLabel("结束时间:"), endTimeContainer,
 			widget.NewLabel("时间间隔:"), timeIntervalContainer,
 			widget.NewLabel("时区:"), timezoneContainer,
+			widget.NewLabel("时间来源:"), timeModeSelect,
+			widget.NewLabel("单独设置:"), container.NewHBox(fileTimeButton),
 		),
 		container.NewPadded(tipLabel),
 	)
 }
 
+// showFileTimesDialog 按文件列出时间设置，填写的开始、结束时间优先于同名配置文件和文件名中的时间，留空时使用检测到的设置或全局配置
+func (g *GUI) showFileTimesDialog() {
+	if g.sourceDir == "" {
+		dialog.ShowError(errors.New("请先选择源文件或源目录"), g.window)
+		return
+	}
+	filePaths := []string{g.sourceDir}
+	if !g.isFileMode {
+		var err error
+		filePaths, err = server.CollectFiles(g.sourceDir, g.outputDir)
+		if err != nil {
+			dialog.ShowError(errors.Wrap(err, "扫描文件失败"), g.window)
+			return
+		}
+	}
+	if len(filePaths) == 0 {
+		dialog.ShowError(fmt.Errorf("未找到支持的文件格式(%s)", parser.ExtensionList()), g.window)
+		return
+	}
+
+	table := container.New(layout.NewGridLayoutWithColumns(4),
+		widget.NewLabelWithStyle("文件", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("检测到的设置", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("开始时间", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("结束时间", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	startEntries := make([]*widget.Entry, len(filePaths))
+	endEntries := make([]*widget.Entry, len(filePaths))
+	for i, filePath := range filePaths {
+		name, err := filepath.Rel(filepath.Dir(g.sourceDir), filePath)
+		if err != nil {
+			name = filepath.Base(filePath)
+		}
+
+		detected := "全局设置"
+		if fileTime, err := server.DetectFileTime(filePath); err != nil {
+			detected = err.Error()
+		} else if !fileTime.IsZero() {
+			detected = fmt.Sprintf("%s：%s", fileTime.SourceName(), fileTime)
+		}
+		detectedLabel := widget.NewLabel(detected)
+		detectedLabel.Wrapping = fyne.TextWrapWord
+
+		startEntries[i] = widget.NewEntry()
+		startEntries[i].SetPlaceHolder("留空使用检测到的设置")
+		endEntries[i] = widget.NewEntry()
+		endEntries[i].SetPlaceHolder("可选")
+		if manual, ok := g.fileTimes[filePath]; ok {
+			startEntries[i].SetText(manual.PathStartTime)
+			endEntries[i].SetText(manual.PathEndTime)
+		}
+		table.Add(widget.NewLabel(name))
+		table.Add(detectedLabel)
+		table.Add(startEntries[i])
+		table.Add(endEntries[i])
+	}
+
+	tableScroll := container.NewVScroll(table)
+	tableScroll.SetMinSize(fyne.NewSize(860, 400))
+
+	dialog.NewCustomConfirm("按文件设置时间", "确定", "取消", tableScroll, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		fileTimes := make(map[string]server.FileTime)
+		for i, filePath := range filePaths {
+			fileTime := server.FileTime{
+				PathStartTime: strings.TrimSpace(startEntries[i].Text),
+				PathEndTime:   strings.TrimSpace(endEntries[i].Text),
+			}
+			if fileTime.IsZero() {
+				continue
+			}
+			if fileTime.PathStartTime == "" {
+				dialog.ShowError(fmt.Errorf("%s：设置结束时间时需要同时设置开始时间", filepath.Base(filePath)), g.window)
+				return
+			}
+			if _, err := server.ApplyFileTime(g.config, fileTime); err != nil {
+				dialog.ShowError(fmt.Errorf("%s：%w", filepath.Base(filePath), err), g.window)
+				return
+			}
+			fileTimes[filePath] = fileTime
+		}
+		g.fileTimes = fileTimes
+		g.addLog(fmt.Sprintf("已按文件设置 %d 个文件的时间", len(fileTimes)))
+	}, g.window).Show()
+}
+
+// updateInferredTimezone 自动推断时区时，在时区选择下方显示单个源文件推断出的时区，须在界面线程调用
+func (g *GUI) updateInferredTimezone() {
+	if g.timezoneLabel == nil {
+		return
+	}
+	if g.config.Timezone != consts.TimezoneAuto {
+		g.timezoneLabel.Hide()
+		return
+	}
+
+	g.timezoneLabel.Show()
+	info, err := os.Stat(g.sourceDir)
+	if g.sourceDir == "" || err != nil || info.IsDir() {
+		g.timezoneLabel.SetText("处理时按每个文件的轨迹坐标推断时区")
+		return
+	}
+	// 解析整个文件较慢，在后台推断，完成时源文件已更换或不再自动推断则丢弃结果
+	source := g.sourceDir
+	g.timezoneLabel.SetText("正在推断时区…")
+	go func() {
+		text := "无法推断时区，将使用系统本地时区"
+		if zone, err := pipeline.InferTimezone(source); err == nil && zone != "" {
+			text = fmt.Sprintf("推断时区：%s", zone)
+		}
+		g.runOnUI(func() {
+			if g.sourceDir == source && g.config.Timezone == consts.TimezoneAuto {
+				g.timezoneLabel.SetText(text)
+			}
+		})
+	}()
+}
+
 // createAltitudeSettings 创建海拔设置组件
 func (g *GUI) createAltitudeSettings() fyne.CanvasObject {
 	altitudeEntry := widget.NewEntry()
@@ -620,8 +959,62 @@ func (g *GUI) createAltitudeSettings() fyne.CanvasObject {
 		}
 	}
 
+	demEntry := widget.NewEntry()
+	demEntry.SetPlaceHolder("SRTM .hgt / GeoTIFF 瓦片目录")
+	demEntry.SetText(g.config.DemDirectory)
+	demEntry.OnChanged = func(text string) {
+		g.config.DemDirectory = strings.TrimSpace(text)
+	}
+	demButton := widget.NewButton("浏览", func() {
+		folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			demEntry.SetText(uri.Path())
+		}, g.window)
+		folderDialog.Show()
+	})
+	demContainer := container.NewBorder(nil, nil, nil, demButton, demEntry)
+
+	modeOptions := []struct {
+		DisplayName string
+		Mode        string
+	}{
+		{"保留原始海拔", consts.AltitudeModeSource},
+		{"统一使用默认海拔", consts.AltitudeModeConstant},
+		{"起终点之间线性变化", consts.AltitudeModeLinear},
+		{"飞行剖面", consts.AltitudeModeFlight},
+		{"本地地形高程（SRTM/GeoTIFF）", consts.AltitudeModeDEM},
+	}
+	modeNames := make([]string, len(modeOptions))
+	for i, opt := range modeOptions {
+		modeNames[i] = opt.DisplayName
+	}
+	modeSelect := widget.NewSelect(modeNames, func(selected string) {
+		for _, opt := range modeOptions {
+			if opt.DisplayName == selected {
+				g.config.AltitudeMode = opt.Mode
+			}
+		}
+		if g.config.AltitudeMode == consts.AltitudeModeDEM {
+			demEntry.Enable()
+			demButton.Enable()
+		} else {
+			demEntry.Disable()
+			demButton.Disable()
+		}
+	})
+	modeSelect.SetSelected(modeNames[0])
+	for _, opt := range modeOptions {
+		if opt.Mode == g.config.AltitudeMode {
+			modeSelect.SetSelected(opt.DisplayName)
+		}
+	}
+
 	return container.New(layout.NewFormLayout(),
+		widget.NewLabel("海拔处理:"), modeSelect,
 		widget.NewLabel("默认海拔(米):"), altitudeEntry,
+		widget.NewLabel("高程数据目录:"), demContainer,
 	)
 }
 
@@ -660,11 +1053,195 @@ func (g *GUI) createSpeedSettings() fyne.CanvasObject {
 		speedModeSelect.SetSelected("手动指定")
 	}
 
+	distanceModeSelect := widget.NewSelect([]string{"与上一个点的距离", "累计距离"}, func(selected string) {
+		if selected == "累计距离" {
+			g.config.DistanceMode = consts.DistanceModeCumulative
+		} else {
+			g.config.DistanceMode = consts.DistanceModeStep
+		}
+	})
+	if g.config.DistanceMode == consts.DistanceModeCumulative {
+		distanceModeSelect.SetSelected("累计距离")
+	} else {
+		distanceModeSelect.SetSelected("与上一个点的距离")
+	}
+
 	return container.NewVBox(
 		widget.NewLabel("速度设置:"),
 		speedModeSelect,
 		container.New(layout.NewFormLayout(),
 			widget.NewLabel("指定速度(m/s):"), speedEntry,
+			widget.NewLabel("距离字段:"), distanceModeSelect,
+		),
+	)
+}
+
+// createFilterSettings 创建异常点过滤设置组件
+func (g *GUI) createFilterSettings() fyne.CanvasObject {
+	type option struct {
+		DisplayName string
+		Value       string
+	}
+	transportOptions := []option{
+		{"不过滤", ""},
+		{"步行", consts.TransportWalk},
+		{"骑行", consts.TransportBike},
+		{"驾车", consts.TransportCar},
+		{"高铁", consts.TransportRail},
+		{"飞机", consts.TransportFlight},
+	}
+	smoothOptions := []option{
+		{"不平滑", ""},
+		{"中值滤波", consts.SmoothMedian},
+		{"卡尔曼滤波", consts.SmoothKalman},
+	}
+
+	newSelect := func(options []option, current string, onChanged func(string)) *widget.Select {
+		displayNames := make([]string, len(options))
+		for i, opt := range options {
+			displayNames[i] = opt.DisplayName
+		}
+		sel := widget.NewSelect(displayNames, func(selected string) {
+			for _, opt := range options {
+				if opt.DisplayName == selected {
+					onChanged(opt.Value)
+				}
+			}
+		})
+		sel.SetSelected(displayNames[0])
+		for _, opt := range options {
+			if opt.Value == current {
+				sel.SetSelected(opt.DisplayName)
+			}
+		}
+		return sel
+	}
+
+	transportSelect := newSelect(transportOptions, g.config.OutlierFilter, func(value string) {
+		g.config.OutlierFilter = value
+	})
+	smoothSelect := newSelect(smoothOptions, g.config.SmoothMethod, func(value string) {
+		g.config.SmoothMethod = value
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("异常点过滤（仅对带时间的轨迹生效，剔除超出交通方式速度/加速度上限的漂移点）:"),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("交通方式:"), transportSelect,
+			widget.NewLabel("轨迹平滑:"), smoothSelect,
+		),
+	)
+}
+
+// createStayPointSettings 创建停留点检测设置组件
+func (g *GUI) createStayPointSettings() fyne.CanvasObject {
+	radiusEntry := widget.NewEntry()
+	radiusEntry.SetPlaceHolder("停留半径(米)")
+	radiusEntry.SetText(fmt.Sprintf("%.0f", g.config.StayPointRadius))
+	radiusEntry.OnChanged = func(text string) {
+		if val, err := strconv.ParseFloat(text, 64); err == nil && val > 0 {
+			g.config.StayPointRadius = val
+		}
+	}
+
+	durationEntry := widget.NewEntry()
+	durationEntry.SetPlaceHolder("最短停留(秒)")
+	durationEntry.SetText(fmt.Sprintf("%d", g.config.StayPointDuration))
+	durationEntry.OnChanged = func(text string) {
+		if val, err := strconv.ParseInt(text, 10, 64); err == nil && val > 0 {
+			g.config.StayPointDuration = val
+		}
+	}
+
+	exportCheck := widget.NewCheck("导出停留点（GPX/KML/GeoJSON 中的命名地点）", func(checked bool) {
+		g.config.ExportStayPoints = 0
+		if checked {
+			g.config.ExportStayPoints = 1
+		}
+	})
+	exportCheck.SetChecked(g.config.ExportStayPoints == 1)
+
+	setEnabled := func(enabled bool) {
+		if enabled {
+			radiusEntry.Enable()
+			durationEntry.Enable()
+			exportCheck.Enable()
+		} else {
+			radiusEntry.Disable()
+			durationEntry.Disable()
+			exportCheck.Disable()
+		}
+	}
+	enableCheck := widget.NewCheck("检测停留点（将静止时的漂移点折叠为一个位置）", func(checked bool) {
+		g.config.EnableStayPointDetection = 0
+		if checked {
+			g.config.EnableStayPointDetection = 1
+		}
+		setEnabled(checked)
+	})
+	enableCheck.SetChecked(g.config.EnableStayPointDetection == 1)
+	setEnabled(enableCheck.Checked)
+
+	return container.NewVBox(
+		enableCheck,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("停留半径(米):"), radiusEntry,
+			widget.NewLabel("最短停留(秒):"), durationEntry,
+		),
+		exportCheck,
+	)
+}
+
+// createSimplifySettings 创建轨迹简化设置组件
+func (g *GUI) createSimplifySettings() fyne.CanvasObject {
+	toleranceEntry := widget.NewEntry()
+	toleranceEntry.SetPlaceHolder("简化容差(米)")
+	toleranceEntry.SetText(fmt.Sprintf("%.2f", g.config.SimplifyTolerance))
+	toleranceEntry.OnChanged = func(text string) {
+		if val, err := strconv.ParseFloat(text, 64); err == nil && val > 0 {
+			g.config.SimplifyTolerance = val
+		}
+	}
+
+	type algorithmOption struct {
+		DisplayName string
+		Algorithm   string
+	}
+	algorithmOptions := []algorithmOption{
+		{"不简化", consts.SimplifyNone},
+		{"Douglas-Peucker", consts.SimplifyDouglasPeucker},
+		{"Visvalingam", consts.SimplifyVisvalingam},
+	}
+	displayNames := make([]string, len(algorithmOptions))
+	for i, opt := range algorithmOptions {
+		displayNames[i] = opt.DisplayName
+	}
+
+	algorithmSelect := widget.NewSelect(displayNames, func(selected string) {
+		for _, opt := range algorithmOptions {
+			if opt.DisplayName == selected {
+				g.config.SimplifyAlgorithm = opt.Algorithm
+			}
+		}
+		// 不简化时禁用容差输入框
+		if g.config.SimplifyAlgorithm == consts.SimplifyNone {
+			toleranceEntry.Disable()
+		} else {
+			toleranceEntry.Enable()
+		}
+	})
+	algorithmSelect.SetSelected(displayNames[0])
+	for _, opt := range algorithmOptions {
+		if opt.Algorithm == g.config.SimplifyAlgorithm {
+			algorithmSelect.SetSelected(opt.DisplayName)
+		}
+	}
+
+	return container.NewVBox(
+		widget.NewLabel("轨迹简化（插点前执行，减少密集轨迹的点数）:"),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("简化算法:"), algorithmSelect,
+			widget.NewLabel("容差(米):"), toleranceEntry,
 		),
 	)
 }
@@ -707,32 +1284,556 @@ func (g *GUI) createInsertPointSettings() fyne.CanvasObject {
 	)
 }
 
-// selectSource 选择源文件或目录
-func (g *GUI) selectSource(entry *widget.Entry) {
-	if g.isFileMode {
-		// 单文件模式
-		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
-			if err != nil || reader == nil {
-				return
-			}
-			uri := reader.URI()
-			path := uri.Path()
-			g.addLog(fmt.Sprintf("选择文件 - URI: %s, Path: %s", uri.String(), path))
+// segmentModeOption 缺口处理方式选项
+type segmentModeOption struct {
+	DisplayName string
+	Mode        string
+}
 
-			// 验证路径是否存在
-			if _, err := os.Stat(path); os.IsNotExist(err) {
-				g.addLog(fmt.Sprintf("警告：文件路径不存在: %s", path))
-			} else {
-				g.addLog(fmt.Sprintf("文件路径验证通过: %s", path))
-			}
+var segmentModeOptions = []segmentModeOption{
+	{"留空（不插点）", consts.SegmentModeEmpty},
+	{"插点连接", consts.SegmentModeInterpolate},
+	{"拆分为独立文件", consts.SegmentModeSplit},
+}
+
+// createSegmentSettings 创建轨迹缺口处理设置组件
+func (g *GUI) createSegmentSettings() fyne.CanvasObject {
+	durationEntry := widget.NewEntry()
+	durationEntry.SetPlaceHolder("时间间隔(秒)，0 表示不判断")
+	durationEntry.SetText(fmt.Sprintf("%d", g.config.SegmentGapDuration))
+	durationEntry.OnChanged = func(text string) {
+		if val, err := strconv.ParseInt(text, 10, 64); err == nil && val >= 0 {
+			g.config.SegmentGapDuration = val
+		}
+	}
+
+	distanceEntry := widget.NewEntry()
+	distanceEntry.SetPlaceHolder("距离(米)，0 表示不判断")
+	distanceEntry.SetText(fmt.Sprintf("%.0f", g.config.SegmentGapDistance))
+	distanceEntry.OnChanged = func(text string) {
+		if val, err := strconv.ParseFloat(text, 64); err == nil && val >= 0 {
+			g.config.SegmentGapDistance = val
+		}
+	}
+
+	displayNames := make([]string, len(segmentModeOptions))
+	for i, opt := range segmentModeOptions {
+		displayNames[i] = opt.DisplayName
+	}
+	modeSelect := widget.NewSelect(displayNames, func(selected string) {
+		for _, opt := range segmentModeOptions {
+			if opt.DisplayName == selected {
+				g.config.SegmentGapMode = opt.Mode
+			}
+		}
+	})
+	modeSelect.SetSelected(displayNames[0])
+	for _, opt := range segmentModeOptions {
+		if opt.Mode == g.config.SegmentGapMode {
+			modeSelect.SetSelected(opt.DisplayName)
+		}
+	}
+
+	detectButton := widget.NewButtonWithIcon("逐个设置缺口", theme.SearchIcon(), func() {
+		g.showSegmentGaps()
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("轨迹缺口（原始轨迹段边界、长时间中断或距离跳变处）:"),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("时间间隔(秒):"), durationEntry,
+			widget.NewLabel("距离(米):"), distanceEntry,
+			widget.NewLabel("默认处理方式:"), modeSelect,
+		),
+		detectButton,
+	)
+}
+
+// showSegmentGaps 列出单个轨迹文件中的缺口，逐个选择处理方式
+func (g *GUI) showSegmentGaps() {
+	if !g.isFileMode || g.sourceDir == "" {
+		dialog.ShowError(errors.New("请先在单文件模式下选择一个轨迹文件"), g.window)
+		return
+	}
+
+	// 先清空单独设置，列出的即为默认处理方式
+	config := g.config
+	config.SegmentGapOverrides = ""
+	gaps, err := pipeline.DetectSegmentGaps(g.sourceDir, config)
+	if err != nil {
+		dialog.ShowError(errors.Wrap(err, "检测缺口失败"), g.window)
+		return
+	}
+	g.addLog(fmt.Sprintf("在 %s 中找到 %d 个缺口", filepath.Base(g.sourceDir), len(gaps)))
+	if len(gaps) == 0 {
+		dialog.ShowInformation("轨迹缺口", "没有找到缺口", g.window)
+		return
+	}
+
+	overrides, _ := g.config.GetSegmentGapModes()
+	displayNames := make([]string, len(segmentModeOptions))
+	for i, opt := range segmentModeOptions {
+		displayNames[i] = opt.DisplayName
+	}
+
+	modeSelects := make([]*widget.Select, len(gaps))
+	gapList := container.NewVBox()
+	for i, gap := range gaps {
+		mode := gap.Mode
+		if override, ok := overrides[i+1]; ok {
+			mode = override
+		}
+		modeSelects[i] = widget.NewSelect(displayNames, nil)
+		for _, opt := range segmentModeOptions {
+			if opt.Mode == mode {
+				modeSelects[i].SetSelected(opt.DisplayName)
+			}
+		}
+
+		label := widget.NewLabel(fmt.Sprintf("#%d %s", i+1, gap.Boundary))
+		label.Wrapping = fyne.TextWrapWord
+		gapList.Add(container.NewBorder(nil, nil, nil, modeSelects[i], label))
+		gapList.Add(widget.NewSeparator())
+	}
+
+	gapScroll := container.NewVScroll(gapList)
+	gapScroll.SetMinSize(fyne.NewSize(760, 400))
+
+	dialog.NewCustomConfirm("设置缺口处理方式", "确定", "取消", gapScroll, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		// 只记录与默认处理方式不同的缺口
+		var items []string
+		for i, gap := range gaps {
+			for _, opt := range segmentModeOptions {
+				if opt.DisplayName == modeSelects[i].Selected && opt.Mode != gap.Mode {
+					items = append(items, fmt.Sprintf("%d:%s", i+1, opt.Mode))
+				}
+			}
+		}
+		g.config.SegmentGapOverrides = strings.Join(items, ",")
+		g.addLog("缺口单独处理方式: " + g.config.SegmentGapOverrides)
+	}, g.window).Show()
+}
+
+// createOutputFormatSettings 创建输出格式设置组件
+func (g *GUI) createOutputFormatSettings() fyne.CanvasObject {
+	// 一生足迹 CSV 始终输出
+	csvCheck := widget.NewCheck("一生足迹 CSV", nil)
+	csvCheck.SetChecked(true)
+	csvCheck.Disable()
+
+	formatOptions := []struct {
+		DisplayName string
+		Format      string
+	}{
+		{"GPX", consts.OutputFormatGPX},
+		{"KML", consts.OutputFormatKML},
+		{"GeoJSON", consts.OutputFormatGeoJSON},
+		{"奥维 ovjsn", consts.OutputFormatOvjsn},
+	}
+
+	formatChecks := container.NewHBox(widget.NewLabel("输出格式:"), csvCheck)
+	for _, opt := range formatOptions {
+		format := opt.Format
+		check := widget.NewCheck(opt.DisplayName, func(checked bool) {
+			g.setOutputFormat(format, checked)
+		})
+		check.SetChecked(slices.Contains(g.config.GetOutputFormats(), format))
+		formatChecks.Add(check)
+	}
+
+	return formatChecks
+}
+
+// createMergeSettings 创建合并到已有足迹的设置组件
+func (g *GUI) createMergeSettings() fyne.CanvasObject {
+	archiveEntry := widget.NewEntry()
+	archiveEntry.SetPlaceHolder("可选：选择已有的一生足迹 CSV，新轨迹将合并到其中并输出单个 CSV")
+	archiveEntry.SetText(g.archivePath)
+	archiveEntry.OnChanged = func(text string) {
+		g.archivePath = strings.TrimSpace(text)
+	}
+
+	archiveButton := widget.NewButton("选择文件", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			archiveEntry.SetText(reader.URI().Path())
+		}, g.window)
+		fileDialog.SetFilter(&hiddenFileFilter{extensions: []string{".csv"}})
+		fileDialog.Show()
+	})
+
+	type strategyOption struct {
+		DisplayName string
+		Strategy    string
+	}
+	strategyOptions := []strategyOption{
+		{"保留已有数据", consts.MergeStrategyKeep},
+		{"替换已有数据", consts.MergeStrategyReplace},
+		{"平移到空闲时段", consts.MergeStrategyShift},
+	}
+	displayNames := make([]string, len(strategyOptions))
+	for i, opt := range strategyOptions {
+		displayNames[i] = opt.DisplayName
+	}
+
+	strategySelect := widget.NewSelect(displayNames, func(selected string) {
+		for _, opt := range strategyOptions {
+			if opt.DisplayName == selected {
+				g.config.MergeStrategy = opt.Strategy
+			}
+		}
+	})
+	strategySelect.SetSelected(displayNames[0])
+	for _, opt := range strategyOptions {
+		if opt.Strategy == g.config.MergeStrategy {
+			strategySelect.SetSelected(opt.DisplayName)
+		}
+	}
+
+	return container.NewVBox(
+		widget.NewLabel("合并到已有足迹:"),
+		container.NewBorder(nil, nil, nil, archiveButton, archiveEntry),
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel("时间重叠时:"), strategySelect,
+		),
+	)
+}
+
+// batchConnectorOptions 合并输出时文件之间连接段的选项
+var batchConnectorOptions = []struct {
+	DisplayName string
+	Mode        string
+}{
+	{"不连接", ""},
+	{"直线", consts.FillModeStraight},
+	{"大圆航线", consts.FillModeGreatCircle},
+	{"飞行", consts.FillModeFlight},
+	{"高铁", consts.FillModeRail},
+}
+
+// createBatchSettings 创建文件夹模式下合并为一个 CSV 的设置组件
+func (g *GUI) createBatchSettings() fyne.CanvasObject {
+	orderSelect := widget.NewSelect([]string{"按轨迹时间", "按列表顺序"}, func(selected string) {
+		if selected == "按列表顺序" {
+			g.config.BatchOrder = consts.BatchOrderList
+		} else {
+			g.config.BatchOrder = consts.BatchOrderTime
+		}
+	})
+	if g.config.BatchOrder == consts.BatchOrderList {
+		orderSelect.SetSelected("按列表顺序")
+	} else {
+		orderSelect.SetSelected("按轨迹时间")
+	}
+	orderButton := widget.NewButton("调整顺序", func() {
+		g.showBatchOrderDialog(orderSelect)
+	})
+
+	connectorNames := make([]string, len(batchConnectorOptions))
+	for i, opt := range batchConnectorOptions {
+		connectorNames[i] = opt.DisplayName
+	}
+	connectorSelect := widget.NewSelect(connectorNames, func(selected string) {
+		for _, opt := range batchConnectorOptions {
+			if opt.DisplayName == selected {
+				g.config.BatchConnector = opt.Mode
+			}
+		}
+	})
+	connectorSelect.SetSelected(connectorNames[0])
+	for _, opt := range batchConnectorOptions {
+		if opt.Mode == g.config.BatchConnector {
+			connectorSelect.SetSelected(opt.DisplayName)
+		}
+	}
+
+	gapEntry := widget.NewEntry()
+	gapEntry.SetText(fmt.Sprintf("%d", g.config.BatchGap))
+	gapEntry.OnChanged = func(text string) {
+		if value, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil && value >= 0 {
+			g.config.BatchGap = value
+		}
+	}
+
+	options := container.New(layout.NewFormLayout(),
+		widget.NewLabel("文件顺序:"), container.NewBorder(nil, nil, nil, orderButton, orderSelect),
+		widget.NewLabel("最短间隔(秒):"), gapEntry,
+		widget.NewLabel("文件之间连接:"), connectorSelect,
+	)
+
+	setEnabled := func(enabled bool) {
+		for _, w := range []fyne.Disableable{orderSelect, orderButton, gapEntry, connectorSelect} {
+			if enabled {
+				w.Enable()
+			} else {
+				w.Disable()
+			}
+		}
+	}
+	batchCheck := widget.NewCheck("文件夹模式下合并为一个 CSV（按顺序排列，时间重叠的文件整体后移）", func(checked bool) {
+		g.config.CombineOutput = 0
+		if checked {
+			g.config.CombineOutput = 1
+		}
+		setEnabled(checked)
+	})
+	batchCheck.SetChecked(g.config.CombineOutput == 1)
+	setEnabled(g.config.CombineOutput == 1)
+
+	return container.NewVBox(batchCheck, options)
+}
+
+// showBatchOrderDialog 列出源目录中的文件，调整合并为一个 CSV 时的顺序，确定后改为按列表顺序合并
+func (g *GUI) showBatchOrderDialog(orderSelect *widget.Select) {
+	if g.isFileMode || g.sourceDir == "" {
+		dialog.ShowError(errors.New("请先在文件夹模式下选择源目录"), g.window)
+		return
+	}
+	filePaths, err := server.CollectFiles(g.sourceDir, g.outputDir)
+	if err != nil {
+		dialog.ShowError(errors.Wrap(err, "扫描文件失败"), g.window)
+		return
+	}
+	if len(filePaths) == 0 {
+		dialog.ShowError(fmt.Errorf("未找到支持的文件格式(%s)", parser.ExtensionList()), g.window)
+		return
+	}
+	order := g.orderBatchFiles(filePaths)
+
+	list := container.NewVBox()
+	var refresh func()
+	refresh = func() {
+		list.Objects = nil
+		for i, filePath := range order {
+			i := i
+			upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				order[i-1], order[i] = order[i], order[i-1]
+				refresh()
+			})
+			if i == 0 {
+				upButton.Disable()
+			}
+			downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				order[i], order[i+1] = order[i+1], order[i]
+				refresh()
+			})
+			if i == len(order)-1 {
+				downButton.Disable()
+			}
+
+			name, err := filepath.Rel(g.sourceDir, filePath)
+			if err != nil {
+				name = filepath.Base(filePath)
+			}
+			label := widget.NewLabel(fmt.Sprintf("%d. %s", i+1, name))
+			list.Add(container.NewBorder(nil, nil, nil, container.NewHBox(upButton, downButton), label))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	orderScroll := container.NewVScroll(list)
+	orderScroll.SetMinSize(fyne.NewSize(600, 400))
+
+	dialog.NewCustomConfirm("调整合并顺序", "确定", "取消", orderScroll, func(ok bool) {
+		if !ok {
+			return
+		}
+		g.batchOrder = order
+		orderSelect.SetSelected("按列表顺序")
+		g.addLog(fmt.Sprintf("已调整 %d 个文件的合并顺序", len(order)))
+	}, g.window).Show()
+}
+
+// orderBatchFiles 按用户调整的顺序排列文件，未调整过的文件按路径排在后面
+func (g *GUI) orderBatchFiles(filePaths []string) []string {
+	ordered := append([]string{}, filePaths...)
+	position := func(filePath string) int {
+		if index := slices.Index(g.batchOrder, filePath); index >= 0 {
+			return index
+		}
+		return len(g.batchOrder)
+	}
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		if pa, pb := position(a), position(b); pa != pb {
+			return pa - pb
+		}
+		return strings.Compare(a, b)
+	})
+	return ordered
+}
+
+// createGapFillSettings 创建历史缺口补全组件
+func (g *GUI) createGapFillSettings() fyne.CanvasObject {
+	historyEntry := widget.NewEntry()
+	historyEntry.SetPlaceHolder("选择一生足迹导出的 CSV，查找其中的时间或距离跳变")
+
+	historyButton := widget.NewButton("选择文件", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			historyEntry.SetText(reader.URI().Path())
+		}, g.window)
+		fileDialog.SetFilter(&hiddenFileFilter{extensions: []string{".csv"}})
+		fileDialog.Show()
+	})
+
+	findButton := widget.NewButtonWithIcon("查找缺口", theme.SearchIcon(), func() {
+		g.findGaps(strings.TrimSpace(historyEntry.Text))
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("历史缺口补全:"),
+		container.NewBorder(nil, nil, nil, container.NewHBox(historyButton, findButton), historyEntry),
+	)
+}
+
+// findGaps 查找已有足迹中的缺口，并弹出选择补全方式的对话框
+func (g *GUI) findGaps(historyPath string) {
+	if historyPath == "" {
+		dialog.ShowError(errors.New("请选择一生足迹 CSV 文件"), g.window)
+		return
+	}
+
+	rows, err := server.LoadStepLifeRows(historyPath)
+	if err != nil {
+		dialog.ShowError(errors.Wrap(err, "读取足迹失败"), g.window)
+		return
+	}
+	gaps := server.FindGaps(rows, g.config.GapMinDuration, g.config.GapMinDistance)
+	g.addLog(fmt.Sprintf("在 %s 中找到 %d 个缺口", filepath.Base(historyPath), len(gaps)))
+	if len(gaps) == 0 {
+		dialog.ShowInformation("查找缺口", "没有找到超过阈值的缺口", g.window)
+		return
+	}
+
+	type modeOption struct {
+		DisplayName string
+		Mode        string
+	}
+	modeOptions := []modeOption{
+		{"大圆航线", consts.FillModeGreatCircle},
+		{"直线", consts.FillModeStraight},
+		{"飞行剖面", consts.FillModeFlight},
+		{"高铁速度", consts.FillModeRail},
+	}
+	modeNames := make([]string, len(modeOptions))
+	for i, opt := range modeOptions {
+		modeNames[i] = opt.DisplayName
+	}
+
+	checks := make([]*widget.Check, len(gaps))
+	modeSelects := make([]*widget.Select, len(gaps))
+	gapList := container.NewVBox()
+	for i, gap := range gaps {
+		checks[i] = widget.NewCheck("", nil)
+		modeSelects[i] = widget.NewSelect(modeNames, nil)
+		modeSelects[i].SetSelected(modeNames[0])
+
+		label := widget.NewLabel(gap.Describe(g.config.Timezone))
+		label.Wrapping = fyne.TextWrapWord
+		gapList.Add(container.NewBorder(nil, nil, checks[i], modeSelects[i], label))
+		gapList.Add(widget.NewSeparator())
+	}
+
+	gapScroll := container.NewVScroll(gapList)
+	gapScroll.SetMinSize(fyne.NewSize(760, 400))
+
+	dialog.NewCustomConfirm("选择需要补全的缺口", "生成补全轨迹", "取消", gapScroll, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		var fills []server.GapFill
+		for i, gap := range gaps {
+			if !checks[i].Checked {
+				continue
+			}
+			mode := consts.FillModeGreatCircle
+			for _, opt := range modeOptions {
+				if opt.DisplayName == modeSelects[i].Selected {
+					mode = opt.Mode
+				}
+			}
+			fills = append(fills, server.GapFill{Gap: gap, Mode: mode})
+		}
+		if len(fills) == 0 {
+			return
+		}
+
+		outputDir := g.outputDir
+		if outputDir == "" {
+			outputDir = filepath.Dir(historyPath)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			g.showError("创建输出目录失败: " + err.Error())
+			return
+		}
+		baseName := strings.TrimSuffix(filepath.Base(historyPath), filepath.Ext(historyPath))
+		outputPath := filepath.Join(outputDir, baseName+"_gapfill.csv")
+
+		count, err := server.FillGaps(fills, outputPath, g.config)
+		if err != nil {
+			g.showError("生成补全轨迹失败: " + err.Error())
+			return
+		}
+		g.addLog(fmt.Sprintf("补全 %d 个缺口，共 %d 个点，已写入: %s", len(fills), count, outputPath))
+		dialog.ShowInformation("补全完成",
+			fmt.Sprintf("已生成 %d 个补全点：\n%s\n\n该文件仅包含补全的点，可直接导入一生足迹。", count, outputPath),
+			g.window)
+	}, g.window).Show()
+}
+
+// setOutputFormat 启用或停用指定的输出格式
+func (g *GUI) setOutputFormat(format string, enabled bool) {
+	var formats []string
+	for _, f := range g.config.GetOutputFormats() {
+		if f != format {
+			formats = append(formats, f)
+		}
+	}
+	if enabled {
+		formats = append(formats, format)
+	}
+	g.config.OutputFormats = strings.Join(formats, ",")
+}
+
+// selectSource 选择源文件或目录
+func (g *GUI) selectSource(entry *widget.Entry) {
+	if g.isFileMode {
+		// 单文件模式
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			uri := reader.URI()
+			path := uri.Path()
+			g.addLog(fmt.Sprintf("选择文件 - URI: %s, Path: %s", uri.String(), path))
+
+			// 验证路径是否存在
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				g.addLog(fmt.Sprintf("警告：文件路径不存在: %s", path))
+			} else {
+				g.addLog(fmt.Sprintf("文件路径验证通过: %s", path))
+			}
 
 			entry.SetText(path)
 			g.sourceDir = path
+			g.updateInferredTimezone()
 			// 自动更新输出目录
 			g.updateOutputDir(path, nil)
 		}, g.window)
 		// 使用自定义过滤器，隐藏以点开头的文件
-		fileFilter := &hiddenFileFilter{extensions: []string{".gpx", ".kml", ".ovjsn"}}
+		fileFilter := &hiddenFileFilter{extensions: append(parser.Extensions(), parser.GenericExtensions...)}
 		fileDialog.SetFilter(fileFilter)
 		fileDialog.Show()
 	} else {
@@ -830,7 +1931,7 @@ func (g *GUI) saveConfigDialog() {
 
 // resetConfigDialog 重置配置对话框
 func (g *GUI) resetConfigDialog() {
-	dialog.ShowConfirm("重置配置", "确定要重置所有配置为默认值吗？此操作不会保存到文件。", func(confirmed bool) {
+	dialog.ShowConfirm("重置配置", "确定要将当前设置重置为默认值吗？命名配置不受影响，此操作不会保存到文件。", func(confirmed bool) {
 		if confirmed {
 			g.resetConfig()
 			// 重新创建主窗口内容以更新UI
@@ -841,22 +1942,10 @@ func (g *GUI) resetConfigDialog() {
 	}, g.window)
 }
 
-// resetConfig 重置配置为默认值
+// resetConfig 重置当前配置为默认值，并取消选择命名配置
 func (g *GUI) resetConfig() {
-	g.config = model.Config{
-		EnableInsertPointStrategy: 1,
-		InsertPointDistance:       100,
-		DefaultAltitude:           0.0,
-		SpeedMode:                 "auto",
-		ManualSpeed:               1.5,
-		EnableBatchProcessing:     1,
-		PathStartTime:             "",
-		PathEndTime:               "",
-		TimeInterval:              0,
-		Timezone:                  "",
-		PathStartTimestamp:        0,
-		PathEndTimestamp:          0,
-	}
+	g.config = model.NewDefaultConfig()
+	g.configFile.Profile = ""
 }
 
 // startProcessing 开始处理文件
@@ -893,25 +1982,14 @@ func (g *GUI) startProcessing(sourcePath, outputDir string) {
 	g.sourceDir = sourcePath
 	g.outputDir = outputDir
 
-	// 验证和处理时间配置
-	if g.config.PathStartTime != "" {
-		timestamp, err := timeUtils.ToTimestampWithTimezone(g.config.PathStartTime, g.config.Timezone)
-		if err != nil {
-			dialog.ShowError(errors.Wrap(err, "开始时间格式错误"), g.window)
-			return
-		}
-		g.config.PathStartTimestamp = timestamp
-	} else {
-		g.config.PathStartTimestamp = time.Now().Unix()
+	// 校验配置并处理时间
+	if err := g.config.Validate(); err != nil {
+		dialog.ShowError(fmt.Errorf("配置错误：\n%w", err), g.window)
+		return
 	}
-
-	if g.config.PathEndTime != "" {
-		timestamp, err := timeUtils.ToTimestampWithTimezone(g.config.PathEndTime, g.config.Timezone)
-		if err != nil {
-			dialog.ShowError(errors.Wrap(err, "结束时间格式错误"), g.window)
-			return
-		}
-		g.config.PathEndTimestamp = timestamp
+	if err := server.ResolveTimestamps(&g.config); err != nil {
+		dialog.ShowError(err, g.window)
+		return
 	}
 
 	// 检测并提示轨迹反转
@@ -923,23 +2001,34 @@ func (g *GUI) startProcessing(sourcePath, outputDir string) {
 	}
 
 	// 开始处理（不再自动保存配置）
-	go g.processFiles()
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancelProcess = cancel
+	g.processButton.Disable()
+	g.cancelButton.Enable()
+	g.cancelButton.Show()
+	go g.processFiles(ctx)
 }
 
-// processFiles 处理文件
-func (g *GUI) processFiles() {
+// processFiles 处理文件，ctx 取消时停止处理；在后台 goroutine 中执行，界面更新均经 runOnUI
+func (g *GUI) processFiles(ctx context.Context) {
+	defer func() {
+		g.cancelProcess()
+		g.runOnUI(func() {
+			g.cancelButton.Hide()
+			g.processButton.Enable()
+		})
+	}()
 	defer func() {
 		// 确保在任何情况下都恢复GUI状态
 		if r := recover(); r != nil {
 			g.addLog(fmt.Sprintf("处理过程中发生严重错误: %v", r))
 			g.showError(fmt.Sprintf("处理过程中发生严重错误: %v", r))
-			g.progressBar.Hide()
-			g.statusLabel.SetText("错误：处理失败")
+			g.setStatus("错误：处理失败")
 		}
 	}()
 
-	g.progressBar.Show()
-	g.statusLabel.SetText("正在扫描文件...")
+	g.setProgress(0)
+	g.setStatus("正在扫描文件...")
 	g.addLog("开始处理文件...")
 
 	// 确保输出目录存在
@@ -961,7 +2050,7 @@ func (g *GUI) processFiles() {
 	os.Remove(testFile) // 清理测试文件
 	g.addLog("输出目录权限验证通过")
 
-	g.progressBar.SetValue(0.1)
+	g.setProgress(0.1)
 
 	var filePaths []string
 
@@ -975,16 +2064,11 @@ func (g *GUI) processFiles() {
 	if fileInfo.IsDir() {
 		// 文件夹模式：扫描目录
 		g.addLog("扫描源目录: " + g.sourceDir)
-		filePathMap, err := g.scanSourceDirectory()
+		filePaths, err = server.CollectFiles(g.sourceDir, g.outputDir)
 		if err != nil {
 			g.showError("扫描文件失败: " + err.Error())
 			return
 		}
-
-		// 收集所有文件路径
-		for _, paths := range filePathMap {
-			filePaths = append(filePaths, paths...)
-		}
 	} else {
 		// 单文件模式：直接处理单个文件
 		g.addLog("检测到单文件模式，源路径: " + g.sourceDir)
@@ -998,8 +2082,8 @@ func (g *GUI) processFiles() {
 		ext := strings.ToLower(filepath.Ext(g.sourceDir))
 		g.addLog("文件扩展名: " + ext)
 
-		if ext != ".gpx" && ext != ".kml" && ext != ".ovjsn" {
-			g.showError("不支持的文件格式，仅支持 .gpx, .kml, .ovjsn 文件")
+		if _, err := parser.Identify(g.sourceDir, nil); err != nil {
+			g.showError(err.Error())
 			return
 		}
 		filePaths = []string{g.sourceDir}
@@ -1010,133 +2094,288 @@ func (g *GUI) processFiles() {
 	g.addLog(fmt.Sprintf("找到 %d 个文件待处理", totalFiles))
 
 	if totalFiles == 0 {
-		g.showError("未找到支持的文件格式(.kml, .gpx, .ovjsn)")
+		g.showError(fmt.Sprintf("未找到支持的文件格式(%s)", parser.ExtensionList()))
 		return
 	}
 
-	g.statusLabel.SetText(fmt.Sprintf("找到 %d 个文件，开始处理...", totalFiles))
-	g.addLog("开始处理文件...")
-	g.progressBar.SetValue(0.2)
+	combine := fileInfo.IsDir() && g.config.CombineOutput == 1
+	if combine {
+		filePaths = g.orderBatchFiles(filePaths)
+	}
 
-	processed := 0
-	g.addLog(fmt.Sprintf("开始处理 %d 个文件", totalFiles))
+	g.setStatus(fmt.Sprintf("找到 %d 个文件，开始处理...", totalFiles))
+	g.addLog("开始处理文件...")
+	g.setProgress(0.2)
 
-	for _, filePath := range filePaths {
+	skipped := 0
+	var files []server.BatchFile
+	outputPaths := server.OutputPaths(filePaths, g.outputDir)
+	for i, filePath := range filePaths {
 		fileName := filepath.Base(filePath)
-		g.statusLabel.SetText(fmt.Sprintf("处理文件: %s", fileName))
-		g.addLog(fmt.Sprintf("正在处理文件: %s (路径: %s)", fileName, filePath))
 
 		// 验证文件是否存在
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
 			g.addLog(fmt.Sprintf("文件不存在，跳过: %s", filePath))
-			processed++
+			skipped++
 			continue
 		}
 
-		// 根据文件扩展名确定文件类型
-		ext := strings.ToLower(filepath.Ext(filePath))
-		var fileType string
-		switch ext {
-		case ".gpx", ".kml", ".ovjsn":
-			fileType = consts.FileTypeCommon
-			g.addLog(fmt.Sprintf("文件类型: %s", fileType))
-		default:
-			g.addLog(fmt.Sprintf("跳过不支持的文件类型 %s: %s", ext, fileName))
-			processed++
+		// 根据文件内容和扩展名确定文件类型
+		format, err := parser.Identify(filePath, nil)
+		if err != nil {
+			g.addLog(fmt.Sprintf("跳过不支持的文件: %s", err.Error()))
+			skipped++
 			continue
 		}
 
-		// 生成输出路径
-		outputPath := g.generateOutputPath(filePath)
-		g.addLog(fmt.Sprintf("输出路径: %s", outputPath))
-
-		err := g.processSingleFile(fileType, filePath)
+		// 同名文件的输出路径保留扩展名或追加序号，按文件填写、同名配置文件或文件名中的时间优先于全局配置
+		outputPath := outputPaths[i]
+		file, err := server.NewBatchFile(filePath, outputPath, g.config, g.fileTimes)
 		if err != nil {
-			g.addLog(fmt.Sprintf("处理文件失败 %s: %s", fileName, err.Error()))
-			g.showError(fmt.Sprintf("处理文件失败 %s: %s", fileName, err.Error()))
+			g.addLog(fmt.Sprintf("跳过时间设置错误的文件: %s", err.Error()))
+			skipped++
 			continue
 		}
+		g.addLog(fmt.Sprintf("待处理文件: %s (类型: %s，输出: %s)", fileName, format.Name, outputPath))
+		files = append(files, file)
+	}
 
-		g.addLog(fmt.Sprintf("文件处理完成: %s", fileName))
-		processed++
-		g.progressBar.SetValue(0.2 + 0.8*float64(processed)/float64(totalFiles))
+	if g.archivePath != "" {
+		g.mergeIntoArchive(ctx, files)
+		return
+	}
+	if combine {
+		g.combineFiles(ctx, files)
+		return
 	}
 
-	g.progressBar.SetValue(1.0)
-	if processed > 0 {
-		g.statusLabel.SetText(fmt.Sprintf("处理完成！成功处理 %d 个文件", processed))
-		g.addLog(fmt.Sprintf("处理完成！成功处理 %d 个文件", processed))
-	} else {
-		g.statusLabel.SetText("处理完成，但没有成功处理任何文件")
-		g.addLog("处理完成，但没有成功处理任何文件")
+	// 订阅任务事件，回调在任务的事件 goroutine 中串行执行，Run 返回前全部回调完成
+	job := server.NewJob(files, g.config)
+	var failures []string
+	job.Subscribe(g.jobEventHandler(skipped, &failures))
+	// 取消及失败已在事件中处理，输出路径重复时不会开始转换
+	if err := job.Run(ctx); errors.Is(err, server.ErrDuplicateOutput) {
+		g.showError(err.Error())
+		return
 	}
+	g.finishJob(failures)
+}
 
-	// 完成后隐藏进度条
-	time.Sleep(2 * time.Second)
-	g.progressBar.Hide()
-	g.statusLabel.SetText("就绪")
+// jobEventHandler 将任务事件显示到进度条、状态栏和日志，失败的文件追加到 failures
+func (g *GUI) jobEventHandler(skipped int, failures *[]string) func(server.Event) {
+	processed, warnings := 0, 0
+	return func(event server.Event) {
+		fileName := filepath.Base(event.FilePath)
+		switch event.Type {
+		case server.EventStarted:
+			g.addLog(fmt.Sprintf("开始处理 %d 个文件，并发数 %d", event.Total, event.Workers))
+			g.setStatus(fmt.Sprintf("正在处理 %d 个文件...", event.Total))
+		case server.EventProgress:
+			if event.Message != "" {
+				g.addLog(fileLogPrefix(fileName) + event.Message)
+			}
+			g.setProgress(0.2 + 0.8*event.Overall)
+		case server.EventWarning:
+			warnings++
+			g.addLog("警告: " + fileLogPrefix(fileName) + event.Message)
+		case server.EventFileDone:
+			g.setProgress(0.2 + 0.8*event.Overall)
+			switch {
+			case event.Err == nil:
+				processed++
+				g.addLog(fmt.Sprintf("文件处理完成 (%d/%d): %s", event.Index+1, event.Total, fileName))
+				g.setStatus(fmt.Sprintf("已完成 %d/%d: %s", processed, event.Total, fileName))
+			case errors.Is(event.Err, context.Canceled):
+				g.addLog(fmt.Sprintf("已取消: %s", fileName))
+			default:
+				g.addLog(fmt.Sprintf("处理文件失败 %s: %s", fileName, event.Error))
+				*failures = append(*failures, fmt.Sprintf("%s: %s", fileName, event.Error))
+			}
+		case server.EventFinished:
+			if event.Err != nil && !event.Canceled {
+				// 任务清单合并输出失败
+				g.addLog("合并失败: " + event.Error)
+				*failures = append(*failures, "合并失败: "+event.Error)
+			}
+			if event.Canceled {
+				g.setStatus(fmt.Sprintf("已取消，完成 %d 个文件", event.Succeeded))
+				g.addLog(fmt.Sprintf("处理已取消，已完成 %d 个文件", event.Succeeded))
+			} else if event.Succeeded > 0 {
+				g.setProgress(1.0)
+				g.setStatus(fmt.Sprintf("处理完成！成功处理 %d 个文件", event.Succeeded))
+				g.addLog(fmt.Sprintf("处理完成！成功处理 %d 个文件，失败 %d 个，跳过 %d 个，警告 %d 条",
+					event.Succeeded, event.Failed, skipped, warnings))
+				if event.OutputPath != "" {
+					g.addLog("合并结果已写入: " + event.OutputPath)
+				}
+			} else {
+				g.setProgress(1.0)
+				g.setStatus("处理完成，但没有成功处理任何文件")
+				g.addLog("处理完成，但没有成功处理任何文件")
+			}
+		}
+	}
 }
 
-// scanSourceDirectory 扫描源目录
-func (g *GUI) scanSourceDirectory() (map[string][]string, error) {
-	filePathMap := make(map[string][]string)
+// fileLogPrefix 任务事件日志的文件名前缀，整体执行的任务（如合并输出）没有文件名
+func fileLogPrefix(fileName string) string {
+	if fileName == "" || fileName == "." {
+		return ""
+	}
+	return fileName + ": "
+}
 
-	err := filepath.Walk(g.sourceDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// finishJob 任务结束后汇总显示失败的文件，稍后隐藏进度条
+func (g *GUI) finishJob(failures []string) {
+	if len(failures) > 0 {
+		err := fmt.Errorf("%d 个文件处理失败:\n%s", len(failures), strings.Join(failures, "\n"))
+		g.runOnUI(func() {
+			dialog.ShowError(err, g.window)
+		})
+	}
 
-		if info.IsDir() {
-			return nil
-		}
+	// 完成后隐藏进度条
+	time.Sleep(2 * time.Second)
+	g.hideProgress()
+	g.setStatus("就绪")
+}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".kml", ".gpx", ".ovjsn":
-			fileType := consts.FileTypeCommon
-			filePathMap[fileType] = append(filePathMap[fileType], path)
+// selectManifest 选择任务清单并开始执行
+func (g *GUI) selectManifest() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
 		}
+		defer reader.Close()
+		g.startManifest(reader.URI().Path())
+	}, g.window)
+	fileDialog.SetFilter(&hiddenFileFilter{extensions: []string{".json", ".yaml", ".yml"}})
+	fileDialog.Show()
+}
 
-		return nil
-	})
+// startManifest 按任务清单开始处理：清单未指定 config 时以当前界面中的配置为基础配置，输入、输出和合并方式均以清单为准
+func (g *GUI) startManifest(manifestPath string) {
+	g.addLog("读取任务清单: " + manifestPath)
+	manifest, err := server.LoadManifest(manifestPath)
+	if err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	plan, err := manifest.Plan(g.config)
+	if err != nil {
+		dialog.ShowError(errors.Wrap(err, "任务清单配置错误"), g.window)
+		return
+	}
 
-	return filePathMap, err
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancelProcess = cancel
+	g.processButton.Disable()
+	g.cancelButton.Enable()
+	g.cancelButton.Show()
+	go g.runManifest(ctx, plan)
 }
 
-// processSingleFile 处理单个文件
-func (g *GUI) processSingleFile(fileType, filePath string) error {
-	// 为每个文件创建独立的配置副本
-	config := g.config
-
-	// 如果设置了结束时间，重新计算时间戳分配
-	if config.PathEndTime != "" {
-		// 这里需要先读取文件获取点数量，然后重新分配时间
-		// 暂时使用简化逻辑，后续完善
-	}
+// runManifest 执行展开后的任务清单；在后台 goroutine 中执行，界面更新均经 runOnUI
+func (g *GUI) runManifest(ctx context.Context, plan *server.ManifestPlan) {
+	defer func() {
+		g.cancelProcess()
+		g.runOnUI(func() {
+			g.cancelButton.Hide()
+			g.processButton.Enable()
+		})
+	}()
 
-	csvFilePath := g.generateOutputPath(filePath)
+	g.setProgress(0.2)
+	g.addLog(fmt.Sprintf("任务清单 %s：%d 个文件，合并方式 %s，输出目录 %s", plan.Name, len(plan.Files), plan.Merge, plan.OutputDir))
+	var failures []string
+	_ = plan.Run(ctx, g.jobEventHandler(0, &failures)) // 取消及失败已在事件中处理
+	g.finishJob(failures)
+}
 
-	err := server.ProcessSingleFile(fileType, filePath, csvFilePath, config)
-	if err != nil {
-		return err
+// mergeIntoArchive 将新轨迹合并到已有足迹并输出单个 CSV，ctx 取消时停止合并
+func (g *GUI) mergeIntoArchive(ctx context.Context, files []server.BatchFile) {
+	baseName := strings.TrimSuffix(filepath.Base(g.archivePath), filepath.Ext(g.archivePath))
+	outputPath := filepath.Join(g.outputDir, baseName+"_merged.csv")
+
+	g.setStatus(fmt.Sprintf("正在合并 %d 个文件到已有足迹...", len(files)))
+	g.addLog(fmt.Sprintf("合并模式：已有足迹 %s，输出 %s", g.archivePath, outputPath))
+	g.setProgress(0.2)
+
+	result, err := server.MergeIntoArchive(ctx, g.archivePath, files, outputPath, g.config)
+	switch {
+	case errors.Is(err, context.Canceled):
+		g.setStatus("已取消合并")
+		g.addLog("合并已取消，未写出结果")
+	case err != nil:
+		g.showError("合并失败: " + err.Error())
+		return
+	default:
+		g.setProgress(1.0)
+		g.setStatus(fmt.Sprintf("合并完成！输出 %d 行", result.TotalRows))
+		g.addLog(fmt.Sprintf("合并完成：新增 %d 行，丢弃重叠 %d 行，替换 %d 行，平移轨迹 %d 条，去重 %d 行",
+			result.AddedRows, result.DroppedRows, result.ReplacedRows, result.ShiftedTracks, result.DuplicateRows))
 	}
 
-	return nil
+	// 完成后隐藏进度条
+	time.Sleep(2 * time.Second)
+	g.hideProgress()
+	g.setStatus("就绪")
 }
 
-// generateOutputPath 生成输出文件路径
-func (g *GUI) generateOutputPath(sourcePath string) string {
-	baseName := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
-	outputPath := filepath.Join(g.outputDir, baseName+"_steplife.csv")
-	return outputPath
+// combineFiles 将文件夹中的文件按顺序合并输出为一个 CSV
+func (g *GUI) combineFiles(ctx context.Context, files []server.BatchFile) {
+	outputPath := filepath.Join(g.outputDir, filepath.Base(g.sourceDir)+"_steplife.csv")
+	g.setStatus(fmt.Sprintf("正在合并 %d 个文件...", len(files)))
+	g.addLog(fmt.Sprintf("合并为一个 CSV：%d 个文件，输出 %s", len(files), outputPath))
+	g.setProgress(0.2)
+
+	warnings := 0
+	result, err := server.CombineFiles(ctx, files, outputPath, g.config, server.FileHooks{
+		Progress: func(progress float64) {
+			g.setProgress(0.2 + 0.8*progress)
+		},
+		Warn: func(message string) {
+			warnings++
+			g.addLog("警告: " + message)
+		},
+		Info: g.addLog,
+	})
+	switch {
+	case errors.Is(err, context.Canceled):
+		g.setStatus("已取消合并")
+		g.addLog("合并已取消")
+	case err != nil:
+		g.showError("合并失败: " + err.Error())
+		return
+	default:
+		g.setProgress(1.0)
+		g.setStatus(fmt.Sprintf("合并完成！%d 个文件，输出 %d 行", result.Files, result.TotalRows))
+		g.addLog(fmt.Sprintf("合并完成：%d 个文件，跳过 %d 个，平移 %d 个，连接段 %d 行，共 %d 行，警告 %d 条",
+			result.Files, len(result.Failed), result.ShiftedFiles, result.ConnectorRows, result.TotalRows, warnings))
+		for i, filePath := range result.Order {
+			g.addLog(fmt.Sprintf("   %d. %s", i+1, filepath.Base(filePath)))
+		}
+		for _, failed := range result.Failed {
+			g.addLog(fmt.Sprintf("   跳过 %s: %v", filepath.Base(failed.FilePath), failed.Err))
+		}
+	}
+
+	// 完成后隐藏进度条
+	time.Sleep(2 * time.Second)
+	g.hideProgress()
+	g.setStatus("就绪")
 }
 
 // showDateTimePicker 显示日期时间选择器
 func (g *GUI) showDateTimePicker(entry *widget.Entry, title string) {
 	currentTime := time.Now()
 	if entry.Text != "" {
-		if parsedTime, err := time.Parse("2006-01-02 15:04:05", entry.Text); err == nil {
-			currentTime = parsedTime
+		loc, err := timeUtils.Location(g.config.Timezone)
+		if err != nil {
+			loc = time.Local
+		}
+		if parsedTime, _, err := timeUtils.Parse(entry.Text, loc); err == nil {
+			currentTime = parsedTime.In(loc)
 		}
 	}
 
@@ -1322,28 +2561,58 @@ func (g *GUI) createNumberEntry(value, min, max int) *widget.Entry {
 	return entry
 }
 
-// addLog 添加日志消息到GUI日志显示区域
-func (g *GUI) addLog(message string) {
-	if g.logText == nil {
-		return
-	}
+// runOnUI 在 Fyne 主线程上执行界面更新，按调用的顺序依次执行；可在任意 goroutine 调用，不会阻塞。
+// Fyne 2.6 起组件只能在主线程上修改，后台处理的 goroutine 更新进度、状态和日志时都需经此转到主线程
+func (g *GUI) runOnUI(fn func()) {
+	fyne.Do(fn)
+}
+
+// setStatus 更新状态文字
+func (g *GUI) setStatus(text string) {
+	g.runOnUI(func() {
+		g.statusLabel.SetText(text)
+	})
+}
+
+// setProgress 显示进度条并更新进度
+func (g *GUI) setProgress(value float64) {
+	g.runOnUI(func() {
+		g.progressBar.Show()
+		g.progressBar.SetValue(value)
+	})
+}
 
+// hideProgress 隐藏进度条
+func (g *GUI) hideProgress() {
+	g.runOnUI(func() {
+		g.progressBar.Hide()
+	})
+}
+
+// addLog 添加日志消息到GUI日志显示区域，在主线程上执行
+func (g *GUI) addLog(message string) {
 	timestamp := time.Now().Format("15:04:05")
 	logLine := fmt.Sprintf("[%s] %s\n", timestamp, message)
 
-	// 追加新内容到日志
-	currentText := g.logText.Text
-	newText := currentText + logLine
-	g.logText.SetText(newText)
+	g.runOnUI(func() {
+		if g.logText == nil {
+			return
+		}
+
+		// 追加新内容到日志
+		g.logText.SetText(g.logText.Text + logLine)
 
-	// 自动滚动到底部
-	g.logScroll.ScrollToBottom()
+		// 自动滚动到底部
+		g.logScroll.ScrollToBottom()
+	})
 }
 
 // showError 显示错误信息
 func (g *GUI) showError(message string) {
-	g.statusLabel.SetText("处理失败: " + message)
 	g.addLog("错误: " + message)
-	g.progressBar.Hide()
-	dialog.ShowError(errors.New(message), g.window)
+	g.runOnUI(func() {
+		g.statusLabel.SetText("处理失败: " + message)
+		g.progressBar.Hide()
+		dialog.ShowError(errors.New(message), g.window)
+	})
 }
@@ -1,16 +1,205 @@
 package model
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	consts "steplife-universal-importer-gui/internal/const"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"strconv"
+	"strings"
+)
+
 type Config struct {
 	EnableInsertPointStrategy int     `ini:"enableInsertPointStrategy"`
 	InsertPointDistance       int     `ini:"insertPointDistance"`
 	PathStartTime             string  `ini:"pathStartTime"`
 	PathEndTime               string  `ini:"pathEndTime"`
 	TimeInterval              int64   `ini:"timeInterval"` // 时间间隔（秒）
-	Timezone                  string  `ini:"timezone"`      // 时区，如 "Asia/Shanghai"，空值表示使用系统本地时区
-	PathStartTimestamp        int64
-	PathEndTimestamp          int64
+	Timezone                  string  `ini:"timezone"`      // 时区，如 "Asia/Shanghai"，空值表示使用系统本地时区，"auto" 表示按轨迹坐标推断
+	TimeMode                  string  `ini:"timeMode"`      // 时间来源："auto" 轨迹带原始时间时保留，"override"（默认）始终按配置分配
+	PathStartTimestamp        int64   `ini:"-"`
+	PathEndTimestamp          int64   `ini:"-"`
 	DefaultAltitude           float64 `ini:"defaultAltitude"`
+	AltitudeMode              string  `ini:"altitudeMode"` // 海拔处理方式："source"、"constant"、"linear"、"flight"、"dem"
+	DemDirectory              string  `ini:"demDirectory"` // 本地 SRTM .hgt / GeoTIFF 高程瓦片目录，海拔处理方式为 "dem" 时使用
 	SpeedMode                 string  `ini:"speedMode"` // "auto" or "manual"
 	ManualSpeed               float64 `ini:"manualSpeed"`
-	EnableBatchProcessing     int     `ini:"enableBatchProcessing"`
+	DistanceMode              string  `ini:"distanceMode"` // 距离字段："step" 与上一个点的距离，"cumulative" 累计距离
+	CombineOutput             int     `ini:"combineOutput"`         // 是否将多个文件按时间顺序合并输出为一个 CSV
+	BatchOrder                string  `ini:"batchOrder"`            // 合并时的文件顺序："time" 按轨迹开始时间，"list" 按文件列表顺序
+	BatchGap                  int64   `ini:"batchGap"`              // 合并时相邻两个文件之间至少间隔的时间（秒）
+	BatchConnector            string  `ini:"batchConnector"`        // 合并时文件之间的连接段：straight、greatcircle、flight、rail，空值表示不连接
+	OutputFormats             string  `ini:"outputFormats"` // 输出格式，逗号分隔，如 "csv,ovjsn"，空值表示仅输出 CSV
+	MergeStrategy             string  `ini:"mergeStrategy"`     // 合并到已有足迹时的重叠处理方式："keep"、"replace" 或 "shift"
+	MergeGapThreshold         int64   `ini:"mergeGapThreshold"` // 已有足迹中超过该间隔（秒）视为空闲时间段
+	GapMinDuration            int64   `ini:"gapMinDuration"`    // 缺口判定的时间阈值（秒）
+	GapMinDistance            float64 `ini:"gapMinDistance"`    // 缺口判定的距离阈值（米）
+	SimplifyAlgorithm         string  `ini:"simplifyAlgorithm"` // 插点前的轨迹简化算法："douglas-peucker"、"visvalingam"，空值表示不简化
+	SimplifyTolerance         float64 `ini:"simplifyTolerance"` // 轨迹简化容差（米）
+	OutlierFilter             string  `ini:"outlierFilter"`     // 异常点过滤的交通方式："walk"、"bike"、"car"、"rail"、"flight"，空值表示不过滤
+	SmoothMethod              string  `ini:"smoothMethod"`      // 轨迹平滑方式："median"、"kalman"，空值表示不平滑
+	EnableStayPointDetection  int     `ini:"enableStayPointDetection"` // 是否检测停留点并折叠停留期间的抖动点
+	StayPointRadius           float64 `ini:"stayPointRadius"`          // 停留半径（米）
+	StayPointDuration         int64   `ini:"stayPointDuration"`        // 最短停留时间（秒）
+	ExportStayPoints          int     `ini:"exportStayPoints"`         // 是否在导出文件中输出命名停留点
+	SegmentGapDuration        int64   `ini:"segmentGapDuration"`       // 轨迹内相邻点时间间隔超过该值（秒）视为缺口，0 表示不按时间判断
+	SegmentGapDistance        float64 `ini:"segmentGapDistance"`       // 轨迹内相邻点距离超过该值（米）视为缺口，0 表示不按距离判断
+	SegmentGapMode            string  `ini:"segmentGapMode"`           // 缺口处理方式："interpolate"、"empty"、"split"
+	SegmentGapOverrides       string  `ini:"segmentGapOverrides"`      // 按缺口序号单独指定处理方式，如 "2:interpolate,3:split"
+}
+
+// NewDefaultConfig 返回默认配置
+func NewDefaultConfig() Config {
+	return Config{
+		EnableInsertPointStrategy: 1,
+		InsertPointDistance:       consts.DefaultInsertPointDistance,
+		DefaultAltitude:           0.0,
+		AltitudeMode:              consts.AltitudeModeSource,
+		SpeedMode:                 "auto",
+		ManualSpeed:               1.5,
+		DistanceMode:              consts.DistanceModeStep,
+		CombineOutput:             0,
+		BatchOrder:                consts.BatchOrderTime,
+		BatchGap:                  consts.DefaultBatchGap,
+		MergeStrategy:             consts.MergeStrategyKeep,
+		MergeGapThreshold:         consts.DefaultMergeGapThreshold,
+		GapMinDuration:            consts.DefaultGapMinDuration,
+		GapMinDistance:            consts.DefaultGapMinDistance,
+		SimplifyTolerance:         consts.DefaultSimplifyTolerance,
+		StayPointRadius:           consts.DefaultStayPointRadius,
+		StayPointDuration:         consts.DefaultStayPointDuration,
+	}
+}
+
+// GetOutputFormats 返回去重后的输出格式列表，CSV 始终输出
+func (this Config) GetOutputFormats() []string {
+	formats := []string{consts.OutputFormatCSV}
+	for _, format := range strings.Split(this.OutputFormats, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" || slices.Contains(formats, format) {
+			continue
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// GetSegmentGapModes
+//
+//	@Description: 		解析按缺口序号单独指定的处理方式
+//	@return map[int]string	缺口序号（从 1 开始）到处理方式的映射
+//	@return error
+func (this Config) GetSegmentGapModes() (map[int]string, error) {
+	modes := make(map[int]string)
+	for _, item := range strings.Split(this.SegmentGapOverrides, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		indexStr, mode, found := strings.Cut(item, ":")
+		index, err := strconv.Atoi(strings.TrimSpace(indexStr))
+		mode = strings.TrimSpace(mode)
+		if !found || err != nil || index < 1 ||
+			!slices.Contains([]string{consts.SegmentModeInterpolate, consts.SegmentModeEmpty, consts.SegmentModeSplit}, mode) {
+			return nil, fmt.Errorf("缺口处理方式配置错误：%s", item)
+		}
+		modes[index] = mode
+	}
+	return modes, nil
+}
+
+// Validate
+//
+//	@Description: 	校验全部配置项，字符串类的选项为空时表示使用默认处理方式
+//	@return error	每个错误一行，以 config.ini 中的键名开头，便于在配置文件中找到；没有错误时为 nil
+func (this Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s：%s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	oneOf := func(key, value string, options ...string) {
+		check(value == "" || slices.Contains(options, value), key, "不支持的取值 %q，可选 %s", value, strings.Join(options, "、"))
+	}
+	flag := func(key string, value int) {
+		check(value == 0 || value == 1, key, "只能为 0 或 1（当前为 %d）", value)
+	}
+	nonNegative := func(key string, value float64) {
+		check(value >= 0 && !math.IsNaN(value), key, "不能为负数（当前为 %v）", value)
+	}
+
+	flag("enableInsertPointStrategy", this.EnableInsertPointStrategy)
+	check(this.InsertPointDistance >= consts.MinInsertPointDistance, "insertPointDistance",
+		"插点距离不能小于 %d 米（当前为 %d）", consts.MinInsertPointDistance, this.InsertPointDistance)
+
+	// 时区和时间
+	_, zoneErr := timeUtils.Location(this.Timezone)
+	check(zoneErr == nil, "timezone", "无效的时区 %q，应为 Asia/Shanghai 这样的 IANA 时区名、auto 或留空", this.Timezone)
+	var start, end int64
+	if this.PathStartTime != "" && zoneErr == nil {
+		var err error
+		start, err = timeUtils.ToTimestampWithTimezone(this.PathStartTime, this.Timezone)
+		check(err == nil, "pathStartTime", "开始时间 %q 格式错误，应为 2024-01-01 08:00:00", this.PathStartTime)
+	}
+	if this.PathEndTime != "" && zoneErr == nil {
+		var err error
+		end, err = timeUtils.ToTimestampWithTimezone(this.PathEndTime, this.Timezone)
+		check(err == nil, "pathEndTime", "结束时间 %q 格式错误，应为 2024-01-01 08:00:00", this.PathEndTime)
+	}
+	check(this.PathEndTime == "" || this.PathStartTime != "", "pathEndTime", "设置结束时间时需要同时设置开始时间（pathStartTime）")
+	check(start == 0 || end == 0 || start != end, "pathEndTime", "结束时间与开始时间相同，所有点的时间将相同")
+	oneOf("timeMode", this.TimeMode, consts.TimeModeAuto, consts.TimeModeOverride)
+
+	// 海拔、速度、距离
+	check(!math.IsNaN(this.DefaultAltitude) && this.DefaultAltitude >= -500 && this.DefaultAltitude <= 20000,
+		"defaultAltitude", "默认海拔应在 -500 ~ 20000 米之间（当前为 %v）", this.DefaultAltitude)
+	oneOf("altitudeMode", this.AltitudeMode, consts.AltitudeModeSource, consts.AltitudeModeConstant,
+		consts.AltitudeModeLinear, consts.AltitudeModeFlight, consts.AltitudeModeDEM)
+	if this.AltitudeMode == consts.AltitudeModeDEM {
+		info, err := os.Stat(this.DemDirectory)
+		check(this.DemDirectory != "" && err == nil && info.IsDir(), "demDirectory", "海拔处理方式为 dem 时需要设置存在的高程数据目录（当前为 %q）", this.DemDirectory)
+	}
+	oneOf("speedMode", this.SpeedMode, "auto", "manual")
+	check(this.SpeedMode != "manual" || this.ManualSpeed > 0, "manualSpeed", "手动指定速度时速度必须大于 0（当前为 %v）", this.ManualSpeed)
+	oneOf("distanceMode", this.DistanceMode, consts.DistanceModeStep, consts.DistanceModeCumulative)
+
+	// 批量合并、输出
+	flag("combineOutput", this.CombineOutput)
+	oneOf("batchOrder", this.BatchOrder, consts.BatchOrderTime, consts.BatchOrderList)
+	nonNegative("batchGap", float64(this.BatchGap))
+	oneOf("batchConnector", this.BatchConnector, consts.FillModeStraight, consts.FillModeGreatCircle, consts.FillModeFlight, consts.FillModeRail)
+	outputFormats := []string{consts.OutputFormatCSV, consts.OutputFormatOvjsn, consts.OutputFormatGPX, consts.OutputFormatKML, consts.OutputFormatGeoJSON}
+	for _, format := range this.GetOutputFormats() {
+		oneOf("outputFormats", format, outputFormats...)
+	}
+	oneOf("mergeStrategy", this.MergeStrategy, consts.MergeStrategyKeep, consts.MergeStrategyReplace, consts.MergeStrategyShift)
+	nonNegative("mergeGapThreshold", float64(this.MergeGapThreshold))
+	nonNegative("gapMinDuration", float64(this.GapMinDuration))
+	nonNegative("gapMinDistance", this.GapMinDistance)
+
+	// 简化、过滤、停留点
+	oneOf("simplifyAlgorithm", this.SimplifyAlgorithm, consts.SimplifyDouglasPeucker, consts.SimplifyVisvalingam)
+	check(this.SimplifyAlgorithm == "" || this.SimplifyTolerance > 0, "simplifyTolerance", "启用轨迹简化时容差必须大于 0（当前为 %v）", this.SimplifyTolerance)
+	nonNegative("simplifyTolerance", this.SimplifyTolerance)
+	oneOf("outlierFilter", this.OutlierFilter, consts.TransportWalk, consts.TransportBike, consts.TransportCar, consts.TransportRail, consts.TransportFlight)
+	oneOf("smoothMethod", this.SmoothMethod, consts.SmoothMedian, consts.SmoothKalman)
+	flag("enableStayPointDetection", this.EnableStayPointDetection)
+	if this.EnableStayPointDetection == 1 {
+		check(this.StayPointRadius > 0, "stayPointRadius", "检测停留点时停留半径必须大于 0（当前为 %v）", this.StayPointRadius)
+		check(this.StayPointDuration > 0, "stayPointDuration", "检测停留点时最短停留时间必须大于 0（当前为 %d）", this.StayPointDuration)
+	}
+	flag("exportStayPoints", this.ExportStayPoints)
+
+	// 轨迹缺口
+	nonNegative("segmentGapDuration", float64(this.SegmentGapDuration))
+	nonNegative("segmentGapDistance", this.SegmentGapDistance)
+	oneOf("segmentGapMode", this.SegmentGapMode, consts.SegmentModeInterpolate, consts.SegmentModeEmpty, consts.SegmentModeSplit)
+	if _, err := this.GetSegmentGapModes(); err != nil {
+		errs = append(errs, fmt.Errorf("segmentGapOverrides：%w", err))
+	}
+	return errors.Join(errs...)
 }
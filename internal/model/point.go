@@ -6,4 +6,8 @@ type Point struct {
 	Speed     float64
 	Latitude  float64
 	Longitude float64
+	// SegmentStart 该点开始一段新的轨迹（见 Track.Points），转换时不与前一个点之间插点
+	SegmentStart bool
+	// LocalTime 时间不带时区信息，解析时按 UTC 墙上时间保存，转换时按实际时区换算
+	LocalTime bool
 }
@@ -5,6 +5,12 @@ import "fmt"
 type StepLife struct {
 	CSVHeader [][]string
 	CSVData   [][]string
+	// Rows 与 CSVData 一一对应的轨迹行，供导出其它格式使用
+	Rows []Row
+	// Stops 检测到的停留点，导出其它格式时作为命名停留点输出
+	Stops []Stop
+	// SplitAt 需要拆分为独立输出文件的行序号
+	SplitAt []int
 }
 
 func NewStepLife() *StepLife {
@@ -20,7 +26,29 @@ func NewStepLife() *StepLife {
 }
 
 func (this *StepLife) AddCSVRow(row Row) {
-	this.CSVData = append(this.CSVData, []string{
+	this.Rows = append(this.Rows, row)
+	this.CSVData = append(this.CSVData, FormatCSVRow(row))
+}
+
+// SegmentRows 按轨迹段边界拆分轨迹行，供 GPX/KML 等格式按段输出
+func (this *StepLife) SegmentRows() [][]Row {
+	var segments [][]Row
+	start := 0
+	for i, row := range this.Rows {
+		if i > start && row.SegmentStart {
+			segments = append(segments, this.Rows[start:i])
+			start = i
+		}
+	}
+	if start < len(this.Rows) {
+		segments = append(segments, this.Rows[start:])
+	}
+	return segments
+}
+
+// FormatCSVRow 将轨迹行格式化为与 CSVHeader 对应的 CSV 记录
+func FormatCSVRow(row Row) []string {
+	return []string{
 		fmt.Sprintf("%d", row.DataTime),
 		fmt.Sprintf("%d", row.LocType),
 		fmt.Sprintf("%.8f", row.Longitude),
@@ -32,5 +60,5 @@ func (this *StepLife) AddCSVRow(row Row) {
 		fmt.Sprintf("%d", row.IsBackForeground),
 		fmt.Sprintf("%d", row.StepType),
 		fmt.Sprintf("%.2f", row.Altitude),
-	})
+	}
 }
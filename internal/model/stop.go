@@ -0,0 +1,11 @@
+package model
+
+// Stop 停留点，导出时作为命名的停留位置
+type Stop struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	StartTime int64 // 停留开始时间戳
+	EndTime   int64 // 停留结束时间戳
+}
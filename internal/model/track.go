@@ -0,0 +1,51 @@
+package model
+
+// Track 一个文件解析出的轨迹，由若干轨迹段组成
+type Track struct {
+	Name     string
+	Segments []Segment
+}
+
+// Segment 轨迹段，对应 GPX trkseg、KML Placemark、奥维轨迹对象或 TCX 圈，转换时段与段之间不插点、不计算速度
+type Segment struct {
+	Name     string
+	Metadata map[string]string // 格式相关的附加信息，如描述、运动类型
+	Points   []Point
+}
+
+// NewTrack 由单段点列构造轨迹
+func NewTrack(name string, points []Point) *Track {
+	return &Track{
+		Name:     name,
+		Segments: []Segment{{Name: name, Points: points}},
+	}
+}
+
+// AddSegment 追加轨迹段，空段会被忽略
+func (this *Track) AddSegment(segment Segment) {
+	if len(segment.Points) == 0 {
+		return
+	}
+	this.Segments = append(this.Segments, segment)
+}
+
+// PointCount 所有轨迹段的点数之和
+func (this *Track) PointCount() int {
+	count := 0
+	for _, segment := range this.Segments {
+		count += len(segment.Points)
+	}
+	return count
+}
+
+// Points 按顺序展开所有轨迹段的点，除第一段外每段的第一个点带 SegmentStart 标记
+func (this *Track) Points() []Point {
+	points := make([]Point, 0, this.PointCount())
+	for _, segment := range this.Segments {
+		for i, point := range segment.Points {
+			point.SegmentStart = i == 0 && len(points) > 0
+			points = append(points, point)
+		}
+	}
+	return points
+}
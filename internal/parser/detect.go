@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"steplife-universal-importer-gui/internal/model"
+	"strconv"
+	"strings"
+)
+
+// SniffSize 判断格式时读取的文件开头字节数，流式读取时需要预读相同的长度
+const SniffSize = 64 * 1024
+
+// GenericExtensions 聊天软件等转发后常见的通用扩展名，这类文件需要根据内容判断格式
+var GenericExtensions = []string{".xml", ".json", ".txt", ""}
+
+// sniffedExtensions 已注册但过于常见的扩展名，目录扫描时内容也须能识别为已注册的格式，
+// 如 .csv 只收集一生足迹 CSV（dataTime,locType 表头或同样列数的数值行）
+var sniffedExtensions = []string{".csv"}
+
+// unsupportedFormats 能识别但暂无解析器的格式
+var unsupportedFormats = map[string]string{
+	".geojson": "GeoJSON",
+	".fit":     "FIT",
+	".nmea":    "NMEA",
+}
+
+// nmeaPattern NMEA 0183 语句，如 $GPRMC,...、$GNGGA,...
+var nmeaPattern = regexp.MustCompile(`^\$[A-Z]{4,5},`)
+
+// geoJSONTypes GeoJSON 根对象的 type
+var geoJSONTypes = map[string]bool{
+	"FeatureCollection": true, "Feature": true, "GeometryCollection": true,
+	"Point": true, "MultiPoint": true, "LineString": true, "MultiLineString": true,
+	"Polygon": true, "MultiPolygon": true,
+}
+
+// Detect
+//
+//	@Description: 	根据文件开头的内容判断格式，依次识别 FIT、XML 根元素（GPX、KML、TCX）、JSON 顶层字段（奥维 ovjsn、GeoJSON）、
+//	                	NMEA 语句、一生足迹 CSV，最后匹配已注册格式声明的特征字节
+//	@param content	文件内容，只检查前 64KB
+//	@return string	格式对应的扩展名（含点），无法判断时返回空字符串
+func Detect(content []byte) string {
+	if len(content) > SniffSize {
+		content = content[:SniffSize]
+	}
+
+	// FIT 文件头：长度 12 或 14，第 8~11 字节为 ".FIT"
+	if len(content) >= 12 && (content[0] == 12 || content[0] == 14) && string(content[8:12]) == ".FIT" {
+		return ".fit"
+	}
+
+	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+	trimmed := bytes.TrimLeft(content, " \t\r\n")
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	switch trimmed[0] {
+	case '<':
+		switch xmlRoot(trimmed) {
+		case "gpx":
+			return ".gpx"
+		case "kml":
+			return ".kml"
+		case "TrainingCenterDatabase":
+			return ".tcx"
+		}
+	case '{':
+		keys, rootType := jsonRoot(trimmed)
+		switch {
+		case keys["ObjItems"]:
+			return ".ovjsn"
+		case geoJSONTypes[rootType], keys["features"] && rootType == "":
+			return ".geojson"
+		}
+	case '$':
+		if nmeaPattern.Match(trimmed) {
+			return ".nmea"
+		}
+	default:
+		if isStepLifeCSV(trimmed) {
+			return ".csv"
+		}
+	}
+
+	for _, format := range Formats() {
+		for _, magic := range format.Magic {
+			if len(magic) > 0 && bytes.Contains(content, magic) {
+				return format.Extensions[0]
+			}
+		}
+	}
+	return ""
+}
+
+// Identify
+//
+//	@Description: 	识别文件格式：内容能判断时以内容为准，扩展名错误时按内容选择解析器；内容无法判断时按扩展名
+//	@param filePath
+//	@param content	文件内容，为 nil 时读取文件开头
+//	@return Format
+//	@return error	格式无法识别或暂不支持
+func Identify(filePath string, content []byte) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if content == nil {
+		head, err := readHead(filePath)
+		if err != nil {
+			return Format{}, err
+		}
+		content = head
+	}
+
+	detected := Detect(content)
+	if name, ok := unsupportedFormats[detected]; ok {
+		return Format{}, fmt.Errorf("%s 的内容为 %s 格式，暂不支持，仅支持 %s 文件", filepath.Base(filePath), name, ExtensionList())
+	}
+	if format, ok := Lookup(detected); ok {
+		return format, nil
+	}
+	if format, ok := Lookup(ext); ok {
+		return format, nil
+	}
+	return Format{}, fmt.Errorf("无法识别 %s 的格式，仅支持 %s 文件", filepath.Base(filePath), ExtensionList())
+}
+
+// SupportedFile 文件扩展名已注册，或扩展名为通用扩展名、.csv 且内容可识别为已注册的格式；用于目录扫描
+func SupportedFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	sniffed := slices.Contains(sniffedExtensions, ext)
+	if !sniffed && Supported(filePath) {
+		return true
+	}
+	if !sniffed && !slices.Contains(GenericExtensions, ext) {
+		return false
+	}
+
+	head, err := readHead(filePath)
+	if err != nil {
+		return false
+	}
+	_, ok := Lookup(Detect(head))
+	return ok
+}
+
+// readHead 读取文件开头用于判断格式
+func readHead(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head, err := io.ReadAll(io.LimitReader(file, SniffSize))
+	if err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// xmlRoot 返回 XML 根元素的名称（不含命名空间前缀），内容被截断时只要根元素完整即可
+func xmlRoot(content []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// jsonRoot 返回 JSON 根对象的字段名和 type 字段的值，内容被截断时返回已读到的部分
+func jsonRoot(content []byte) (map[string]bool, string) {
+	keys := make(map[string]bool)
+	rootType := ""
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return keys, rootType
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		key, ok := token.(string)
+		if !ok {
+			break
+		}
+		keys[key] = true
+
+		// 只解析 type 的值，其它值按深度跳过，避免解码大数组
+		if key == "type" {
+			var value string
+			if decoder.Decode(&value) == nil {
+				rootType = value
+			}
+			continue
+		}
+		if !skipJSONValue(decoder) {
+			break
+		}
+	}
+	return keys, rootType
+}
+
+// skipJSONValue 跳过一个 JSON 值，失败时返回 false
+func skipJSONValue(decoder *json.Decoder) bool {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return true
+		}
+	}
+}
+
+// isStepLifeCSV 第一行为一生足迹表头，或为 11 列且首列为时间戳的数据行
+func isStepLifeCSV(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	if !scanner.Scan() {
+		return false
+	}
+	fields := strings.Split(strings.TrimSpace(scanner.Text()), ",")
+	if isStepLifeHeader(fields) {
+		return true
+	}
+	if len(fields) != len(model.NewStepLife().CSVHeader[0]) {
+		return false
+	}
+	for _, field := range fields[:4] {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
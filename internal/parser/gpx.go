@@ -2,6 +2,8 @@ package parser
 
 import (
 	"encoding/xml"
+	"fmt"
+	"io"
 	"steplife-universal-importer-gui/internal/model"
 	"steplife-universal-importer-gui/internal/utils/logx"
 	timeUtils "steplife-universal-importer-gui/internal/utils/time"
@@ -10,10 +12,14 @@ import (
 
 type GPX struct {
 	XMLName xml.Name `xml:"gpx"`
+	Name    string   `xml:"metadata>name"`
 	Tracks  []Track  `xml:"trk"`
 }
 
 type Track struct {
+	Name     string         `xml:"name"`
+	Desc     string         `xml:"desc"`
+	Type     string         `xml:"type"`
 	Segments []TrackSegment `xml:"trkseg"`
 }
 
@@ -27,18 +33,28 @@ type TrackPoint struct {
 	Ele   float64 `xml:"ele"`
 	Time  string  `xml:"time"`
 	Speed float64 `xml:"speed"`
+	// GPX 1.1 中速度通常位于 Garmin TrackPointExtension 扩展内
+	ExtSpeed float64 `xml:"extensions>TrackPointExtension>speed"`
 }
 
-type GpxAdaptor struct {
-	BaseAdaptor
-}
+type GpxAdaptor struct{}
 
 func NewGpxAdaptor() *GpxAdaptor {
 	return &GpxAdaptor{}
 }
 
-func (this *GpxAdaptor) Parse(content []byte) ([]model.Point, error) {
-	var points []model.Point
+func init() {
+	Register(Format{
+		Name:       "GPX",
+		Extensions: []string{".gpx"},
+		MIMETypes:  []string{"application/gpx+xml"},
+		Magic:      [][]byte{[]byte("<gpx")},
+		New:        func() FileAdaptor { return NewGpxAdaptor() },
+	})
+}
+
+// Parse 每个 trkseg 解析为一个轨迹段，段名取所属 trk 的名称
+func (this *GpxAdaptor) Parse(content []byte) (*model.Track, error) {
 	var gpx GPX
 	decoder := xml.NewDecoder(strings.NewReader(string(content)))
 	err := decoder.Decode(&gpx)
@@ -46,24 +62,94 @@ func (this *GpxAdaptor) Parse(content []byte) ([]model.Point, error) {
 		return nil, err
 	}
 
-	for _, track := range gpx.Tracks {
-		for _, segment := range track.Segments {
+	track := &model.Track{Name: gpx.Name}
+	for _, trk := range gpx.Tracks {
+		for i, segment := range trk.Segments {
+			seg := model.Segment{
+				Name:     trk.Name,
+				Metadata: map[string]string{"desc": trk.Desc, "type": trk.Type},
+			}
+			if len(trk.Segments) > 1 {
+				seg.Name = fmt.Sprintf("%s-%d", trk.Name, i+1)
+			}
+
 			for _, pt := range segment.Points {
-				timestamp, err := timeUtils.ToTimestamp(pt.Time)
+				timestamp, local, err := timeUtils.ParseTimestamp(pt.Time)
 				if err != nil {
 					logx.ErrorF("时间解析失败：%s", err)
 					return nil, err
 				}
-				points = append(points, model.Point{
+				speed := pt.Speed
+				if speed == 0 {
+					speed = pt.ExtSpeed
+				}
+				seg.Points = append(seg.Points, model.Point{
 					Latitude:  pt.Lat,
 					Longitude: pt.Lon,
 					Altitude:  pt.Ele,
-					Speed:     pt.Speed,
+					Speed:     speed,
 					DataTime:  timestamp,
+					LocalTime: local,
 				})
 			}
+			track.AddSegment(seg)
 		}
 	}
 
-	return points, nil
+	return track, nil
+}
+
+// Stream 逐个 trkpt 解析，每个 trkseg 的第一个点（首段除外）带 SegmentStart 标记
+func (this *GpxAdaptor) Stream(r io.Reader) PointIterator {
+	return &gpxIterator{decoder: xml.NewDecoder(r)}
+}
+
+type gpxIterator struct {
+	decoder    *xml.Decoder
+	newSegment bool
+	started    bool
+}
+
+func (this *gpxIterator) Next() (model.Point, error) {
+	for {
+		token, err := this.decoder.Token()
+		if err != nil {
+			return model.Point{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "trkseg":
+			this.newSegment = true
+		case "trkpt":
+			var pt TrackPoint
+			if err = this.decoder.DecodeElement(&pt, &start); err != nil {
+				return model.Point{}, err
+			}
+			timestamp, local, err := timeUtils.ParseTimestamp(pt.Time)
+			if err != nil {
+				logx.ErrorF("时间解析失败：%s", err)
+				return model.Point{}, err
+			}
+			speed := pt.Speed
+			if speed == 0 {
+				speed = pt.ExtSpeed
+			}
+			point := model.Point{
+				Latitude:     pt.Lat,
+				Longitude:    pt.Lon,
+				Altitude:     pt.Ele,
+				Speed:        speed,
+				DataTime:     timestamp,
+				SegmentStart: this.newSegment && this.started,
+				LocalTime:    local,
+			}
+			this.newSegment = false
+			this.started = true
+			return point, nil
+		}
+	}
 }
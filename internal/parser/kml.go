@@ -2,7 +2,10 @@ package parser
 
 import (
 	"encoding/xml"
+	"fmt"
 	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
 	"strconv"
 	"strings"
 )
@@ -15,13 +18,42 @@ func NewKMLAdaptor() *KML {
 	return &KML{}
 }
 
-func (this *KML) Parse(content []byte) ([]model.Point, error) {
+func init() {
+	Register(Format{
+		Name:       "KML",
+		Extensions: []string{".kml"},
+		MIMETypes:  []string{"application/vnd.google-earth.kml+xml"},
+		Magic:      [][]byte{[]byte("<kml")},
+		New:        func() FileAdaptor { return NewKMLAdaptor() },
+	})
+}
 
-	var points []model.Point
+// Parse 每个 Placemark 中的 LineString、LinearRing 或 gx:Track 解析为一个轨迹段，gx:Track 保留 when 中的时间
+func (this *KML) Parse(content []byte) (*model.Track, error) {
+	track := &model.Track{}
 
 	decoder := xml.NewDecoder(strings.NewReader(string(content)))
-	var inCoordinates bool
-	var coordinates string
+	var stack []string
+	var placemarkName, description string
+	var placemarkSegments int
+	var whens, coords []string
+
+	addSegment := func(points []model.Point) {
+		if len(points) == 0 {
+			return
+		}
+		placemarkSegments++
+		name := placemarkName
+		if placemarkSegments > 1 {
+			name = fmt.Sprintf("%s-%d", placemarkName, placemarkSegments)
+		}
+		track.AddSegment(model.Segment{
+			Name:     name,
+			Metadata: map[string]string{"description": description},
+			Points:   points,
+		})
+	}
+
 	for {
 		tok, err := decoder.Token()
 		if err != nil {
@@ -30,22 +62,56 @@ func (this *KML) Parse(content []byte) ([]model.Point, error) {
 
 		switch el := tok.(type) {
 		case xml.StartElement:
-			if el.Name.Local == "coordinates" {
-				inCoordinates = true
+			stack = append(stack, el.Name.Local)
+			switch el.Name.Local {
+			case "Placemark":
+				placemarkName, description, placemarkSegments = "", "", 0
+			case "Track":
+				whens, coords = nil, nil
+			case "when":
+				// 空的 when 也占一个位置，保证与 gx:coord 一一对应
+				if len(stack) >= 2 && stack[len(stack)-2] == "Track" {
+					whens = append(whens, "")
+				}
+			}
+		case xml.EndElement:
+			if el.Name.Local == "Track" {
+				addSegment(this.parseGxTrack(whens, coords))
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
 			}
 		case xml.CharData:
-			if inCoordinates {
-				coordinates = strings.TrimSpace(string(el))
-				inCoordinates = false
+			if len(stack) < 2 {
+				continue
+			}
+			text := strings.TrimSpace(string(el))
+			current, parent := stack[len(stack)-1], stack[len(stack)-2]
+			switch {
+			case current == "name" && parent == "Document" && track.Name == "":
+				track.Name = text
+			case current == "name" && parent == "Placemark":
+				placemarkName = text
+			case current == "description" && parent == "Placemark":
+				description = text
+			case current == "coordinates" && (parent == "LineString" || parent == "LinearRing"):
+				addSegment(this.parseCoordinates(text))
+			case current == "coordinates" && parent == "Point":
+				logx.InfoF("跳过地标点（%s）", placemarkName)
+			case current == "when" && parent == "Track" && len(whens) > 0:
+				whens[len(whens)-1] += text
+			case current == "coord" && parent == "Track":
+				coords = append(coords, text)
 			}
 		}
 	}
-	coordinatesArr := strings.Split(coordinates, " ")
-	for _, point := range coordinatesArr {
-		point = strings.TrimSpace(point)
-		if point == "" {
-			continue
-		}
+	return track, nil
+}
+
+// parseCoordinates 解析 "经度,纬度[,海拔]" 以空白分隔的坐标串
+func (this *KML) parseCoordinates(coordinates string) []model.Point {
+	var points []model.Point
+	for _, point := range strings.Fields(coordinates) {
 		pointData := strings.Split(point, ",")
 		if len(pointData) < 2 {
 			continue
@@ -62,5 +128,33 @@ func (this *KML) Parse(content []byte) ([]model.Point, error) {
 			Altitude:  altitude,
 		})
 	}
-	return points, nil
+	return points
+}
+
+// parseGxTrack 解析 gx:Track，when 与 gx:coord（"经度 纬度 海拔"）按顺序一一对应
+func (this *KML) parseGxTrack(whens, coords []string) []model.Point {
+	var points []model.Point
+	for i, coord := range coords {
+		fields := strings.Fields(coord)
+		if len(fields) < 2 {
+			continue
+		}
+		point := model.Point{}
+		point.Longitude, _ = strconv.ParseFloat(fields[0], 64)
+		point.Latitude, _ = strconv.ParseFloat(fields[1], 64)
+		if len(fields) >= 3 {
+			point.Altitude, _ = strconv.ParseFloat(fields[2], 64)
+		}
+		if i < len(whens) && whens[i] != "" {
+			timestamp, local, err := timeUtils.ParseTimestamp(whens[i])
+			if err != nil {
+				logx.ErrorF("时间解析失败：%s", err)
+			} else {
+				point.DataTime = timestamp
+				point.LocalTime = local
+			}
+		}
+		points = append(points, point)
+	}
+	return points
 }
@@ -7,16 +7,25 @@ import (
 	"steplife-universal-importer-gui/internal/utils/logx"
 )
 
-type Ovjsn struct {
-	BaseAdaptor
-}
+type Ovjsn struct{}
 
 func NewOvjsnAdaptor() *Ovjsn {
 	return &Ovjsn{}
 }
 
-func (this *Ovjsn) Parse(content []byte) ([]model.Point, error) {
-	var points []model.Point
+func init() {
+	Register(Format{
+		Name:       "奥维 ovjsn",
+		Extensions: []string{".ovjsn"},
+		MIMETypes:  []string{"application/json"},
+		Magic:      [][]byte{[]byte(`"ObjItems"`)},
+		New:        func() FileAdaptor { return NewOvjsnAdaptor() },
+	})
+}
+
+// Parse 每个奥维轨迹对象解析为一个轨迹段，文件夹（ObjChildren）递归展开
+func (this *Ovjsn) Parse(content []byte) (*model.Track, error) {
+	track := &model.Track{}
 
 	// 检查是否有 BOM
 	if len(content) >= 3 && content[0] == 0xEF && content[1] == 0xBB && content[2] == 0xBF {
@@ -26,14 +35,15 @@ func (this *Ovjsn) Parse(content []byte) ([]model.Point, error) {
 	result := gjson.ParseBytes(content)
 	objItems := result.Get("ObjItems").Array()
 	for _, objItem := range objItems {
-		objChildrenPoints := this.parseObjChildren(objItem)
-		points = append(points, objChildrenPoints...)
+		for _, segment := range this.parseObjChildren(objItem) {
+			track.AddSegment(segment)
+		}
 	}
-	return points, nil
+	return track, nil
 }
 
-func (this *Ovjsn) parseObjChildren(objItems gjson.Result) []model.Point {
-	var points []model.Point
+func (this *Ovjsn) parseObjChildren(objItems gjson.Result) []model.Segment {
+	var segments []model.Segment
 	for _, itme := range objItems.Array() {
 		name := itme.Get("Object.Name").String()
 
@@ -41,17 +51,20 @@ func (this *Ovjsn) parseObjChildren(objItems gjson.Result) []model.Point {
 		objChildren := objectDetail.Get("ObjChildren")
 		if objChildren.Exists() {
 			logx.InfoF("开始解析ovjsn子文件夹（%s）", name)
-			objChildrenPoints := this.parseObjChildren(objChildren)
+			segments = append(segments, this.parseObjChildren(objChildren)...)
 			logx.InfoF("ovjsn子文件夹（%s）解析完成", name)
-			points = append(points, objChildrenPoints...)
 		} else {
 			objDetailPoints := this.parseObjDetail(objectDetail)
 
 			logx.InfoF("ovjsn子文件（%s）解析完成，坐标点数：%d", name, len(objDetailPoints))
-			points = append(points, objDetailPoints...)
+			segments = append(segments, model.Segment{
+				Name:     name,
+				Metadata: map[string]string{"comment": itme.Get("Object.Comment").String()},
+				Points:   objDetailPoints,
+			})
 		}
 	}
-	return points
+	return segments
 }
 
 func (this *Ovjsn) parseObjDetail(objDetail gjson.Result) []model.Point {
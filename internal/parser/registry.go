@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Format 轨迹文件格式的描述，解析器在 init 中通过 Register 注册后，GUI 文件筛选、目录扫描、命令行帮助和错误提示都会自动识别
+type Format struct {
+	// Name 显示名称，如 "GPX"
+	Name string
+	// Extensions 扩展名（含点），如 ".gpx"
+	Extensions []string
+	// MIMETypes 常见的 MIME 类型
+	MIMETypes []string
+	// Magic 文件开头（跳过 BOM 和空白）若干字节内出现的特征字节，任一匹配即认为可能是该格式
+	Magic [][]byte
+	// New 创建解析器
+	New func() FileAdaptor
+}
+
+var (
+	registryLock sync.RWMutex
+	formats      []Format
+)
+
+// Register
+//
+//	@Description: 	注册轨迹文件格式，解析器在 init 中调用以支持新的格式；名称或扩展名重复时 panic。
+//	              	注册表位于 internal 下，只供本仓库内的解析器使用
+//	@param format	Extensions 会被复制后统一为小写、带点的形式，不修改调用方的切片
+func Register(format Format) {
+	if format.Name == "" || len(format.Extensions) == 0 || format.New == nil {
+		panic("注册轨迹格式时必须提供名称、扩展名和解析器")
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	extensions := make([]string, len(format.Extensions))
+	for i, ext := range format.Extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions[i] = ext
+	}
+	format.Extensions = extensions
+	for _, registered := range formats {
+		if strings.EqualFold(registered.Name, format.Name) {
+			panic(fmt.Sprintf("轨迹格式重复注册：%s", format.Name))
+		}
+		for _, ext := range format.Extensions {
+			if registered.hasExtension(ext) {
+				panic(fmt.Sprintf("扩展名 %s 已由 %s 注册", ext, registered.Name))
+			}
+		}
+	}
+	formats = append(formats, format)
+}
+
+// Formats 按注册顺序返回已注册的格式
+func Formats() []Format {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return append([]Format(nil), formats...)
+}
+
+// Lookup 按扩展名（含点，不区分大小写）查找格式
+func Lookup(ext string) (Format, bool) {
+	ext = strings.ToLower(ext)
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for _, format := range formats {
+		if format.hasExtension(ext) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// Extensions 所有已注册的扩展名
+func Extensions() []string {
+	var extensions []string
+	for _, format := range Formats() {
+		extensions = append(extensions, format.Extensions...)
+	}
+	return extensions
+}
+
+// ExtensionList 已注册扩展名的列表文本，用于帮助和错误提示，如 ".gpx, .kml"
+func ExtensionList() string {
+	return strings.Join(Extensions(), ", ")
+}
+
+// Supported 按扩展名判断文件是否有对应的解析器
+func Supported(filePath string) bool {
+	_, ok := Lookup(filepath.Ext(filePath))
+	return ok
+}
+
+func (f Format) hasExtension(ext string) bool {
+	for _, e := range f.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisterCopiesExtensions(t *testing.T) {
+	extensions := []string{"MYTRK", ".MyTrk2"}
+	Register(Format{
+		Name:       "测试格式",
+		Extensions: extensions,
+		New:        func() FileAdaptor { return NewGpxAdaptor() },
+	})
+
+	if want := []string{"MYTRK", ".MyTrk2"}; !slices.Equal(extensions, want) {
+		t.Errorf("caller's extensions = %v, want %v", extensions, want)
+	}
+	for _, ext := range []string{".mytrk", ".MYTRK2"} {
+		if format, ok := Lookup(ext); !ok || format.Name != "测试格式" {
+			t.Errorf("Lookup(%q) = %v, %v", ext, format.Name, ok)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"steplife-universal-importer-gui/internal/model"
+	"strconv"
+	"strings"
+)
+
+// RowAdaptor 能直接解析出一生足迹轨迹行的适配器，解析结果保留 model.Row 的全部字段
+type RowAdaptor interface {
+	ParseRows(content []byte) ([]model.Row, error)
+}
+
+type StepLifeAdaptor struct{}
+
+func NewStepLifeAdaptor() *StepLifeAdaptor {
+	return &StepLifeAdaptor{}
+}
+
+func init() {
+	Register(Format{
+		Name:       "一生足迹 CSV",
+		Extensions: []string{".csv"},
+		MIMETypes:  []string{"text/csv"},
+		Magic:      [][]byte{[]byte("dataTime,locType")},
+		New:        func() FileAdaptor { return NewStepLifeAdaptor() },
+	})
+}
+
+// Parse 解析为单段轨迹，只保留坐标、时间、海拔和速度；定位类型、精度等一生足迹专有字段需通过 ParseRows 读取，
+// 转换流水线对一生足迹 CSV 使用 ParseRows 原样保留全部字段
+func (this *StepLifeAdaptor) Parse(content []byte) (*model.Track, error) {
+	rows, err := this.ParseRows(content)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]model.Point, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, row.Point)
+	}
+	return model.NewTrack("", points), nil
+}
+
+// ParseRows 按 model.NewStepLife 的表头解析 CSV，列顺序以表头为准，无表头时按默认顺序
+func (this *StepLifeAdaptor) ParseRows(content []byte) ([]model.Row, error) {
+	// 检查是否有 BOM
+	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header := model.NewStepLife().CSVHeader[0]
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	var rows []model.Row
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && isStepLifeHeader(record) {
+			columns = make(map[string]int, len(record))
+			for i, name := range record {
+				columns[strings.TrimSpace(name)] = i
+			}
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row, err := parseStepLifeRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行解析失败：%w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// isStepLifeHeader 判断首行是否为一生足迹 CSV 表头
+func isStepLifeHeader(record []string) bool {
+	for _, name := range record {
+		if strings.TrimSpace(name) == "dataTime" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseStepLifeRecord(record []string, columns map[string]int) (model.Row, error) {
+	row := *model.NewRow()
+	var err error
+
+	field := func(name string) (string, bool) {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return "", false
+		}
+		value := strings.TrimSpace(record[idx])
+		return value, value != ""
+	}
+	parseFloat := func(name string, target *float64) {
+		if value, ok := field(name); ok && err == nil {
+			*target, err = strconv.ParseFloat(value, 64)
+		}
+	}
+	// 部分导出工具会将整数列写成 "14.0"，按浮点数解析后取整
+	parseInt := func(name string, target *int) {
+		if value, ok := field(name); ok && err == nil {
+			var f float64
+			f, err = strconv.ParseFloat(value, 64)
+			*target = int(math.Round(f))
+		}
+	}
+
+	if value, ok := field("dataTime"); ok {
+		var f float64
+		f, err = strconv.ParseFloat(value, 64)
+		row.DataTime = int64(f)
+	}
+	parseInt("locType", &row.LocType)
+	parseFloat("longitude", &row.Longitude)
+	parseFloat("latitude", &row.Latitude)
+	parseInt("heading", &row.Heading)
+	parseInt("accuracy", &row.Accuracy)
+	parseFloat("speed", &row.Speed)
+	parseInt("distance", &row.Distance)
+	parseInt("isBackForeground", &row.IsBackForeground)
+	parseInt("stepType", &row.StepType)
+	parseFloat("altitude", &row.Altitude)
+
+	return row, err
+}
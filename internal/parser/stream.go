@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+)
+
+// PointIterator 流式解析得到的点迭代器
+type PointIterator interface {
+	//
+	// Next
+	//  @Description: 		返回下一个点，轨迹段的第一个点（首段除外）带 SegmentStart 标记，
+	//                		时间不带时区信息的点带 LocalTime 标记，需要按配置的时区换算
+	//  @return model.Point
+	//  @return error		读完时返回 io.EOF
+	//
+	Next() (model.Point, error)
+}
+
+// StreamAdaptor 支持流式解析的适配器，逐点读取，内存占用与文件大小无关
+type StreamAdaptor interface {
+	Stream(r io.Reader) PointIterator
+}
+
+// trackIterator 遍历已完整解析的轨迹，用于不支持流式解析的格式
+type trackIterator struct {
+	track   *model.Track
+	segment int
+	index   int
+	started bool
+}
+
+// NewTrackIterator 由已解析的轨迹构造点迭代器
+func NewTrackIterator(track *model.Track) PointIterator {
+	return &trackIterator{track: track}
+}
+
+func (this *trackIterator) Next() (model.Point, error) {
+	for this.segment < len(this.track.Segments) {
+		seg := this.track.Segments[this.segment]
+		if this.index >= len(seg.Points) {
+			this.segment++
+			this.index = 0
+			continue
+		}
+
+		point := seg.Points[this.index]
+		point.SegmentStart = this.index == 0 && this.started
+		this.index++
+		this.started = true
+		return point, nil
+	}
+	return model.Point{}, io.EOF
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"strings"
+)
+
+type TCX struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Activities []TcxActivity `xml:"Activities>Activity"`
+}
+
+type TcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []TcxLap `xml:"Lap"`
+}
+
+type TcxLap struct {
+	StartTime string          `xml:"StartTime,attr"`
+	Points    []TcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type TcxTrackpoint struct {
+	Time string `xml:"Time"`
+	// 没有 Position 的点（如室内或信号丢失时）只记录心率等数据
+	Position *TcxPosition `xml:"Position"`
+	Altitude float64      `xml:"AltitudeMeters"`
+	// 速度位于 Garmin ActivityExtension 的 TPX 扩展内
+	Speed float64 `xml:"Extensions>TPX>Speed"`
+}
+
+type TcxPosition struct {
+	Latitude  float64 `xml:"LatitudeDegrees"`
+	Longitude float64 `xml:"LongitudeDegrees"`
+}
+
+type TcxAdaptor struct{}
+
+func NewTcxAdaptor() *TcxAdaptor {
+	return &TcxAdaptor{}
+}
+
+func init() {
+	Register(Format{
+		Name:       "TCX",
+		Extensions: []string{".tcx"},
+		MIMETypes:  []string{"application/vnd.garmin.tcx+xml"},
+		Magic:      [][]byte{[]byte("<TrainingCenterDatabase")},
+		New:        func() FileAdaptor { return NewTcxAdaptor() },
+	})
+}
+
+// Parse 每个 Lap 解析为一个轨迹段，跳过没有坐标的点
+func (this *TcxAdaptor) Parse(content []byte) (*model.Track, error) {
+	var tcx TCX
+	decoder := xml.NewDecoder(strings.NewReader(string(content)))
+	if err := decoder.Decode(&tcx); err != nil {
+		return nil, err
+	}
+
+	track := &model.Track{}
+	for _, activity := range tcx.Activities {
+		if track.Name == "" {
+			track.Name = activity.ID
+		}
+		for i, lap := range activity.Laps {
+			seg := model.Segment{
+				Name:     fmt.Sprintf("%s 第%d圈", activity.Sport, i+1),
+				Metadata: map[string]string{"sport": activity.Sport, "startTime": lap.StartTime},
+			}
+			for _, tp := range lap.Points {
+				if tp.Position == nil {
+					continue
+				}
+				timestamp, local, err := timeUtils.ParseTimestamp(tp.Time)
+				if err != nil {
+					logx.ErrorF("时间解析失败：%s", err)
+					return nil, err
+				}
+				seg.Points = append(seg.Points, model.Point{
+					Latitude:  tp.Position.Latitude,
+					Longitude: tp.Position.Longitude,
+					Altitude:  tp.Altitude,
+					Speed:     tp.Speed,
+					DataTime:  timestamp,
+					LocalTime: local,
+				})
+			}
+			track.AddSegment(seg)
+		}
+	}
+	return track, nil
+}
+
+// Stream 逐个 Trackpoint 解析，每个 Lap 的第一个点（首段除外）带 SegmentStart 标记，跳过没有坐标的点
+func (this *TcxAdaptor) Stream(r io.Reader) PointIterator {
+	return &tcxIterator{decoder: xml.NewDecoder(r)}
+}
+
+type tcxIterator struct {
+	decoder    *xml.Decoder
+	newSegment bool
+	started    bool
+}
+
+func (this *tcxIterator) Next() (model.Point, error) {
+	for {
+		token, err := this.decoder.Token()
+		if err != nil {
+			return model.Point{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Lap":
+			this.newSegment = true
+		case "Trackpoint":
+			var tp TcxTrackpoint
+			if err = this.decoder.DecodeElement(&tp, &start); err != nil {
+				return model.Point{}, err
+			}
+			if tp.Position == nil {
+				continue
+			}
+			timestamp, local, err := timeUtils.ParseTimestamp(tp.Time)
+			if err != nil {
+				logx.ErrorF("时间解析失败：%s", err)
+				return model.Point{}, err
+			}
+			point := model.Point{
+				Latitude:     tp.Position.Latitude,
+				Longitude:    tp.Position.Longitude,
+				Altitude:     tp.Altitude,
+				Speed:        tp.Speed,
+				DataTime:     timestamp,
+				SegmentStart: this.newSegment && this.started,
+				LocalTime:    local,
+			}
+			this.newSegment = false
+			this.started = true
+			return point, nil
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/dem"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+)
+
+// altitudeStage 按配置的海拔处理方式计算每个点的海拔
+func altitudeStage(ctx *Context) error {
+	points := ctx.Points
+	if len(points) == 0 {
+		return nil
+	}
+	config := ctx.Config
+
+	switch config.AltitudeMode {
+	case "", consts.AltitudeModeSource:
+		fillDefaultAltitude(points, config.DefaultAltitude)
+	case consts.AltitudeModeConstant:
+		for i := range points {
+			points[i].Altitude = config.DefaultAltitude
+		}
+	case consts.AltitudeModeLinear, consts.AltitudeModeFlight:
+		// 每个轨迹段按该段的起终点和长度单独计算，段与段之间的缺口不计入距离
+		for _, segment := range splitSegments(points) {
+			fractions, total := pathFractions(segment)
+			start := endpointAltitude(segment[0], config.DefaultAltitude)
+			end := endpointAltitude(segment[len(segment)-1], config.DefaultAltitude)
+			for i := range segment {
+				segment[i].Altitude = start + fractions[i]*(end-start)
+				if config.AltitudeMode == consts.AltitudeModeFlight {
+					segment[i].Altitude = pointcalc.FlightAltitude(segment[i].Altitude, fractions[i], total)
+				}
+			}
+		}
+	case consts.AltitudeModeDEM:
+		if config.DemDirectory == "" {
+			return fmt.Errorf("海拔处理方式为地形高程时需要设置高程数据目录")
+		}
+		store, err := dem.Open(config.DemDirectory)
+		if err != nil {
+			return err
+		}
+		missing := 0
+		for i := range points {
+			if elevation, ok := store.Elevation(points[i].Latitude, points[i].Longitude); ok {
+				points[i].Altitude = elevation
+				continue
+			}
+			missing++
+		}
+		if missing > 0 {
+			ctx.Warnf("%d个点不在高程数据覆盖范围内，保留原始海拔", missing)
+		}
+		fillDefaultAltitude(points, config.DefaultAltitude)
+	default:
+		return fmt.Errorf("不支持的海拔处理方式：%s", config.AltitudeMode)
+	}
+	return nil
+}
+
+// fillDefaultAltitude 源文件没有海拔的点使用默认海拔
+func fillDefaultAltitude(points []model.Point, defaultAltitude float64) {
+	for i := range points {
+		if points[i].Altitude == 0 {
+			points[i].Altitude = defaultAltitude
+		}
+	}
+}
+
+// endpointAltitude 起点或终点的海拔，没有源海拔时使用默认海拔
+func endpointAltitude(point model.Point, defaultAltitude float64) float64 {
+	if point.Altitude == 0 {
+		return defaultAltitude
+	}
+	return point.Altitude
+}
+
+// splitSegments 按 SegmentStart 标记将点切分为轨迹段，各段与 points 共用底层数组
+func splitSegments(points []model.Point) [][]model.Point {
+	var segments [][]model.Point
+	start := 0
+	for i := 1; i <= len(points); i++ {
+		if i == len(points) || points[i].SegmentStart {
+			segments = append(segments, points[start:i])
+			start = i
+		}
+	}
+	return segments
+}
+
+// pathFractions 计算每个点沿轨迹的距离占总长度的比例，返回比例和总长度（米）
+func pathFractions(points []model.Point) ([]float64, float64) {
+	fractions := make([]float64, len(points))
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += pointcalc.Distance(points[i-1], points[i])
+		fractions[i] = total
+	}
+	for i := range fractions {
+		if total > 0 {
+			fractions[i] /= total
+		} else {
+			fractions[i] = float64(i) / float64(max(len(points)-1, 1))
+		}
+	}
+	return fractions, total
+}
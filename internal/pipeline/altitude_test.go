@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"math"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+func TestLinearAltitudePerSegment(t *testing.T) {
+	// 两段轨迹相距很远，各段按自己的起终点海拔线性变化
+	config := model.NewDefaultConfig()
+	config.AltitudeMode = consts.AltitudeModeLinear
+	ctx := &Context{Config: config, Points: []model.Point{
+		{Latitude: 30, Longitude: 120, Altitude: 100, SegmentStart: true},
+		{Latitude: 30, Longitude: 120.01},
+		{Latitude: 30, Longitude: 120.02, Altitude: 300},
+		{Latitude: 40, Longitude: 110, Altitude: 1000, SegmentStart: true},
+		{Latitude: 40, Longitude: 110.01},
+		{Latitude: 40, Longitude: 110.02, Altitude: 2000},
+	}}
+	if err := altitudeStage(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{100, 200, 300, 1000, 1500, 2000}
+	for i, point := range ctx.Points {
+		if math.Abs(point.Altitude-want[i]) > 0.01 {
+			t.Errorf("point %d Altitude = %v, want %v", i, point.Altitude, want[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"math"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+)
+
+// NewAttributesStage 属性阶段，也可单独用于已有坐标和时间的点（如补全的缺口），结果在 ctx.StepLife 中
+func NewAttributesStage() Stage {
+	return NewStage(StageAttributes, attributesStage)
+}
+
+// attributesStage 计算速度、方向和距离等属性并生成一生足迹数据
+func attributesStage(ctx *Context) error {
+	points := ctx.Points
+	steps := stepDistances(points)
+
+	sl := model.NewStepLife()
+	heading, cumulative := 0, 0.0
+	for i, point := range points {
+		row := model.NewRow()
+		row.Point = point
+		if point.SegmentStart {
+			heading = 0
+		}
+		heading = calculateHeading(points, steps, i, heading)
+		cumulative += steps[i]
+
+		row.Heading = heading
+		row.Distance = calculateDistance(ctx.Config, steps[i], cumulative)
+		row.Speed = calculateSpeed(ctx.Config, points, steps, i, ctx.SourceTime)
+		sl.AddCSVRow(*row)
+	}
+	sl.Stops = ctx.Stops
+	applySplits(sl, ctx.SegmentModes, ctx.Reversed)
+	ctx.StepLife = sl
+	return nil
+}
+
+// stepDistances 每个点与同一轨迹段内上一个点之间的距离（米），轨迹段的第一个点为 0
+func stepDistances(points []model.Point) []float64 {
+	steps := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		if !points[i].SegmentStart {
+			steps[i] = pointcalc.Distance(points[i-1], points[i])
+		}
+	}
+	return steps
+}
+
+// calculateHeading 计算行进方向：指向同一轨迹段内的下一个点，段内最后一个点沿用到达时的方向，两点重合时沿用 previous
+func calculateHeading(points []model.Point, steps []float64, currentIndex int, previous int) int {
+	var from, to int
+	switch {
+	case currentIndex+1 < len(points) && !points[currentIndex+1].SegmentStart:
+		from, to = currentIndex, currentIndex+1
+	case currentIndex > 0 && !points[currentIndex].SegmentStart:
+		from, to = currentIndex-1, currentIndex
+	default:
+		return previous
+	}
+	if steps[to] == 0 {
+		return previous
+	}
+	return int(math.Round(pointcalc.Bearing(points[from], points[to]))) % 360
+}
+
+// calculateDistance 按配置输出与上一个点的距离或累计距离（米）
+func calculateDistance(config model.Config, step, cumulative float64) int {
+	if config.DistanceMode == consts.DistanceModeCumulative {
+		return int(math.Round(cumulative))
+	}
+	return int(math.Round(step))
+}
+
+// calculateSpeed 计算速度：手动模式使用指定速度；保留原始时间时优先使用源文件中的速度，否则按最终时间戳计算
+func calculateSpeed(config model.Config, points []model.Point, steps []float64, currentIndex int, sourceTime bool) float64 {
+	if config.SpeedMode == "manual" {
+		return config.ManualSpeed
+	}
+	if sourceTime && points[currentIndex].Speed > 0 {
+		return points[currentIndex].Speed
+	}
+
+	// 不跨轨迹段计算，轨迹段的第一个点使用到下一个点的速度
+	index := currentIndex
+	if index == 0 || points[index].SegmentStart {
+		if index+1 >= len(points) || points[index+1].SegmentStart {
+			return 0.0
+		}
+		index++
+	}
+
+	// 统一时间模式下两点时间相同，无法计算速度
+	duration := math.Abs(float64(points[index].DataTime - points[index-1].DataTime))
+	if duration == 0 {
+		return 0.0
+	}
+	return steps[index] / duration
+}
+
+// applySplits 根据缺口处理方式记录需要拆分输出的行，reversed 表示分配时间时轨迹顺序被反转
+func applySplits(sl *model.StepLife, modes []string, reversed bool) {
+	if reversed {
+		modes = append([]string{}, modes...)
+		for i, j := 0, len(modes)-1; i < j; i, j = i+1, j-1 {
+			modes[i], modes[j] = modes[j], modes[i]
+		}
+	}
+
+	k := 0
+	for i, row := range sl.Rows {
+		if i == 0 || !row.SegmentStart {
+			continue
+		}
+		if k < len(modes) && modes[k] == consts.SegmentModeSplit {
+			sl.SplitAt = append(sl.SplitAt, i)
+		}
+		k++
+	}
+}
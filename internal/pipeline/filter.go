@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"fmt"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/filter"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"steplife-universal-importer-gui/internal/utils/segment"
+	"steplife-universal-importer-gui/internal/utils/staypoint"
+	"steplife-universal-importer-gui/internal/utils/timezone"
+	"time"
+)
+
+// SegmentGap 轨迹内的缺口及其处理方式
+type SegmentGap struct {
+	segment.Boundary
+	Mode string
+}
+
+// segmentModeNames 缺口处理方式的显示名称
+var segmentModeNames = map[string]string{
+	consts.SegmentModeInterpolate: "插点连接",
+	consts.SegmentModeEmpty:       "留空",
+	consts.SegmentModeSplit:       "拆分输出",
+}
+
+// DetectSegmentGaps
+//
+//	@Description: 		解析轨迹文件并列出其中的缺口，以及按当前配置的处理方式
+//	@param filePath
+//	@param config
+//	@return []SegmentGap
+//	@return error
+func DetectSegmentGaps(filePath string, config model.Config) ([]SegmentGap, error) {
+	ctx := &Context{Config: config, FilePath: filePath}
+	if err := parseStage(ctx); err != nil {
+		return nil, err
+	}
+	// 一生足迹 CSV 原样保留，不做缺口处理
+	if ctx.Passthrough {
+		return nil, nil
+	}
+	gaps, _, err := detectSegmentGaps(config, ctx.Track)
+	return gaps, err
+}
+
+// filterStage 按缺口拆分轨迹，逐段剔除异常点、平滑并折叠停留点
+func filterStage(ctx *Context) error {
+	gaps, parts, err := detectSegmentGaps(ctx.Config, ctx.Track)
+	if err != nil {
+		return err
+	}
+
+	ctx.GapModes = make([]string, len(gaps))
+	for i, gap := range gaps {
+		ctx.GapModes[i] = gap.Mode
+		ctx.Infof("轨迹缺口#%d（%s）：%s", i+1, gap.Boundary, segmentModeNames[gap.Mode])
+	}
+
+	for i := range parts {
+		points, err := filterPoints(ctx, parts[i].Points)
+		if err != nil {
+			return err
+		}
+		points, stops := collapseStayPoints(ctx, points, len(ctx.Stops))
+		ctx.Stops = append(ctx.Stops, stops...)
+		parts[i].Points = points
+	}
+	ctx.Track = &model.Track{Name: ctx.Track.Name, Segments: parts}
+	return nil
+}
+
+// detectSegmentGaps 检测轨迹段之间以及段内的缺口，返回每个缺口的处理方式和按缺口拆分后的各部分
+func detectSegmentGaps(config model.Config, track *model.Track) ([]SegmentGap, []model.Segment, error) {
+	overrides, err := config.GetSegmentGapModes()
+	if err != nil {
+		return nil, nil, err
+	}
+	defaultMode := config.SegmentGapMode
+	if defaultMode == "" {
+		defaultMode = consts.SegmentModeEmpty
+	}
+	if _, ok := segmentModeNames[defaultMode]; !ok {
+		return nil, nil, fmt.Errorf("不支持的缺口处理方式：%s", defaultMode)
+	}
+
+	var boundaries []segment.Boundary
+	var parts []model.Segment
+	offset := 0
+	for _, seg := range track.Segments {
+		if len(seg.Points) == 0 {
+			continue
+		}
+		if len(parts) > 0 {
+			last := parts[len(parts)-1].Points
+			reason := "轨迹段边界"
+			if seg.Name != "" {
+				reason = fmt.Sprintf("轨迹段边界「%s」", seg.Name)
+			}
+			boundaries = append(boundaries, segment.Between(last[len(last)-1], seg.Points[0], offset, reason))
+		}
+
+		inner := segment.Detect(seg.Points, config.SegmentGapDuration, config.SegmentGapDistance)
+		for _, points := range segment.Split(seg.Points, inner) {
+			parts = append(parts, model.Segment{Name: seg.Name, Metadata: seg.Metadata, Points: points})
+		}
+		for _, boundary := range inner {
+			boundary.Index += offset
+			boundaries = append(boundaries, boundary)
+		}
+		offset += len(seg.Points)
+	}
+
+	gaps := make([]SegmentGap, len(boundaries))
+	for i, boundary := range boundaries {
+		mode, ok := overrides[i+1]
+		if !ok {
+			mode = defaultMode
+		}
+		gaps[i] = SegmentGap{Boundary: boundary, Mode: mode}
+	}
+	return gaps, parts, nil
+}
+
+// filterPoints 按配置剔除漂移点并平滑轨迹，每个被剔除的点都会记录到日志文件
+func filterPoints(ctx *Context, points []model.Point) ([]model.Point, error) {
+	config := ctx.Config
+	if config.OutlierFilter != "" {
+		kept, dropped, err := filter.RemoveOutliers(points, config.OutlierFilter)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dropped {
+			loc := timezone.LocationAt(config.Timezone, d.Point.Latitude, d.Point.Longitude)
+			logx.InfoF("剔除异常点#%d（%s，%.6f, %.6f）：%s", d.Index,
+				time.Unix(d.Point.DataTime, 0).In(loc).Format("2006-01-02 15:04:05"), d.Point.Latitude, d.Point.Longitude, d.Reason)
+		}
+		ctx.Infof("异常点过滤（%s）：%d个点 → %d个点", config.OutlierFilter, len(points), len(kept))
+		points = kept
+	}
+
+	if config.SmoothMethod != "" && !filter.Timed(points) {
+		// 平滑按时间间隔加权，缺少时间的轨迹无法判断点之间的间隔
+		ctx.Infof("轨迹缺少时间，跳过平滑")
+		return points, nil
+	}
+	switch config.SmoothMethod {
+	case consts.SmoothMedian:
+		points = filter.MedianSmooth(points, 5)
+		ctx.Infof("轨迹已中值滤波平滑")
+	case consts.SmoothKalman:
+		points = filter.KalmanSmooth(points, 10, filter.TypicalSpeed(config.OutlierFilter))
+		ctx.Infof("轨迹已卡尔曼滤波平滑")
+	}
+	return points, nil
+}
+
+// collapseStayPoints 按配置检测停留点，停留期间的抖动点折叠为质心处静止的起止两点，offset 为之前已检测到的停留点数
+func collapseStayPoints(ctx *Context, points []model.Point, offset int) ([]model.Point, []model.Stop) {
+	config := ctx.Config
+	if config.EnableStayPointDetection != 1 {
+		return points, nil
+	}
+
+	collapsed, stops := staypoint.Collapse(points, config.StayPointRadius, config.StayPointDuration)
+	for i := range stops {
+		stop := &stops[i]
+		loc := timezone.LocationAt(config.Timezone, stop.Latitude, stop.Longitude)
+		stop.Name = staypoint.Name(offset+i+1, stop.StartTime, stop.EndTime, loc)
+		ctx.Infof("检测到%s：%.6f, %.6f，停留%d分钟", stop.Name, stop.Latitude, stop.Longitude,
+			(stop.EndTime-stop.StartTime)/60)
+	}
+	ctx.Infof("停留点检测（半径%.0f米，最短%d秒）：%d个停留点，%d个点 → %d个点",
+		config.StayPointRadius, config.StayPointDuration, len(stops), len(points), len(collapsed))
+	return collapsed, stops
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+)
+
+// interpolateStage 按缺口处理方式合并轨迹段，并在每段内按距离插点，段与段之间不插点；
+// 保留原始时间时先补齐没有时间的点
+func interpolateStage(ctx *Context) error {
+	track, segmentModes := joinSegments(ctx.Track.Name, ctx.Track.Segments, ctx.GapModes)
+	ctx.Track = track
+	ctx.SegmentModes = segmentModes
+
+	originals := make([]model.Point, 0, track.PointCount())
+	for _, segment := range track.Segments {
+		for i, point := range segment.Points {
+			point.SegmentStart = i == 0 && len(originals) > 0
+			originals = append(originals, point)
+		}
+	}
+	// 保留原始时间时先补齐个别没有时间的点，插出的点才能按前后两点的时间插值
+	if ctx.Config.TimeMode == consts.TimeModeAuto {
+		fillSourceTimes(ctx, originals)
+	}
+
+	points := make([]model.Point, 0, len(originals))
+	for i, point := range originals {
+		if i == 0 || point.SegmentStart || ctx.Config.EnableInsertPointStrategy != 1 {
+			points = append(points, point)
+			continue
+		}
+		points = append(points, pointcalc.Calculate(originals[i-1], point, ctx.Config.InsertPointDistance)...)
+	}
+	ctx.Infof("处理经纬度完成，原始坐标%d个，插点后坐标%d个", track.PointCount(), len(points))
+	ctx.Points = points
+	return nil
+}
+
+// joinSegments 合并各部分，插点连接的缺口两侧并为同一轨迹段，返回轨迹以及其余缺口（段与段之间）的处理方式；
+// 未指定处理方式的缺口按留空处理
+func joinSegments(name string, parts []model.Segment, modes []string) (*model.Track, []string) {
+	track := &model.Track{Name: name}
+	var segmentModes []string
+	for i, part := range parts {
+		if len(part.Points) == 0 {
+			continue
+		}
+		mode := consts.SegmentModeEmpty
+		if i > 0 && i-1 < len(modes) {
+			mode = modes[i-1]
+		}
+		if len(track.Segments) > 0 && mode == consts.SegmentModeInterpolate {
+			last := &track.Segments[len(track.Segments)-1]
+			// 截断容量，避免追加时覆盖其它段共享的底层数组
+			last.Points = append(last.Points[:len(last.Points):len(last.Points)], part.Points...)
+			continue
+		}
+		if len(track.Segments) > 0 {
+			segmentModes = append(segmentModes, mode)
+		}
+		track.AddSegment(part)
+	}
+	return track, segmentModes
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"slices"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strings"
+)
+
+// parseStage 按文件内容（无法判断时按扩展名）选择解析器解析文件，一生足迹 CSV 直接保留原始行
+func parseStage(ctx *Context) error {
+	if ctx.Content == nil {
+		content, err := utils.ReadFile(ctx.FilePath)
+		if err != nil {
+			logx.ErrorF("读取文件失败：%s", ctx.FilePath)
+			return err
+		}
+		ctx.Content = content
+	}
+
+	format, err := parser.Identify(ctx.FilePath, ctx.Content)
+	if err != nil {
+		return err
+	}
+	warnFormatMismatch(ctx, format)
+	adaptor := format.New()
+
+	// 一生足迹 CSV 已是转换结果，直接保留原始行
+	if rowAdaptor, ok := adaptor.(parser.RowAdaptor); ok {
+		rows, err := rowAdaptor.ParseRows(ctx.Content)
+		if err != nil {
+			logx.ErrorF("解析文件失败：%s", ctx.FilePath)
+			return err
+		}
+
+		sl := model.NewStepLife()
+		for _, row := range rows {
+			sl.AddCSVRow(row)
+		}
+		ctx.Infof("读取一生足迹数据完成，共%d行", len(rows))
+		warnIgnoredSettings(ctx)
+		ctx.StepLife = sl
+		ctx.Passthrough = true
+		return nil
+	}
+
+	track, err := adaptor.Parse(ctx.Content)
+	if err != nil {
+		logx.ErrorF("解析文件失败：%s", ctx.FilePath)
+		return err
+	}
+	ctx.Infof("解析完成，共%d个轨迹段，%d个点", len(track.Segments), track.PointCount())
+	ctx.Track = track
+	return localizeTrack(ctx)
+}
+
+// warnIgnoredSettings 一生足迹 CSV 原样保留全部字段，不经过过滤、简化、插点、时间、海拔和属性阶段，
+// 对其不生效的设置给出警告
+func warnIgnoredSettings(ctx *Context) {
+	config := ctx.Config
+	var ignored []string
+	if config.OutlierFilter != "" || config.SmoothMethod != "" {
+		ignored = append(ignored, "异常点过滤与平滑")
+	}
+	if config.SimplifyAlgorithm != "" {
+		ignored = append(ignored, "轨迹简化")
+	}
+	if config.EnableStayPointDetection == 1 {
+		ignored = append(ignored, "停留点检测")
+	}
+	if config.SegmentGapDuration > 0 || config.SegmentGapDistance > 0 {
+		ignored = append(ignored, "轨迹缺口处理")
+	}
+	if config.Timezone != "" {
+		ignored = append(ignored, "时区")
+	}
+	if config.AltitudeMode != "" && config.AltitudeMode != consts.AltitudeModeSource {
+		ignored = append(ignored, "海拔处理方式")
+	}
+	if config.SpeedMode == "manual" || config.DistanceMode == consts.DistanceModeCumulative {
+		ignored = append(ignored, "速度与距离计算")
+	}
+	if len(ignored) > 0 {
+		ctx.Warnf("%s 为一生足迹 CSV，按原样保留，以下设置不生效：%s", filepath.Base(ctx.FilePath), strings.Join(ignored, "、"))
+	}
+}
+
+// warnFormatMismatch 扩展名与按内容识别出的格式不一致时给出警告
+func warnFormatMismatch(ctx *Context, format parser.Format) {
+	if !slices.Contains(format.Extensions, strings.ToLower(filepath.Ext(ctx.FilePath))) {
+		ctx.Warnf("%s 按内容识别为 %s 格式", filepath.Base(ctx.FilePath), format.Name)
+	}
+}
@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"context"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestStepLifePassthroughWarnsIgnoredSettings(t *testing.T) {
+	content := []byte("dataTime,locType,longitude,latitude,heading,accuracy,speed,distance,isBackForeground,stepType,altitude\n" +
+		"1714521600,0,121.47,31.23,90,5,1.5,0,1,2,12\n" +
+		"1714521660,0,121.48,31.23,90,5,1.5,95,1,2,13\n")
+
+	cases := []struct {
+		Name    string
+		Modify  func(config *model.Config)
+		Ignored string
+	}{
+		{"默认设置不警告", func(config *model.Config) {}, ""},
+		{"轨迹简化", func(config *model.Config) { config.SimplifyAlgorithm = consts.SimplifyDouglasPeucker }, "轨迹简化"},
+		{"海拔处理方式", func(config *model.Config) { config.AltitudeMode = consts.AltitudeModeConstant }, "海拔处理方式"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			config := model.NewDefaultConfig()
+			c.Modify(&config)
+			var warnings []string
+			ctx := &Context{Config: config, FilePath: "history.csv", Content: content,
+				Warn: func(message string) { warnings = append(warnings, message) }}
+			if err := Default().RunContext(context.Background(), ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			// 原样保留全部字段
+			if rows := ctx.StepLife.Rows; len(rows) != 2 || rows[1].Accuracy != 5 || rows[1].StepType != 2 || rows[1].Distance != 95 {
+				t.Errorf("rows = %+v", rows)
+			}
+			warned := strings.Join(warnings, "\n")
+			if c.Ignored == "" && warned != "" {
+				t.Errorf("warnings = %q, want none", warned)
+			}
+			if c.Ignored != "" && !strings.Contains(warned, c.Ignored) {
+				t.Errorf("warnings = %q, want %q", warned, c.Ignored)
+			}
+		})
+	}
+}
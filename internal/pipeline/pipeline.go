@@ -0,0 +1,213 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/logx"
+)
+
+// 默认流水线的阶段名称，可通过 Replace、InsertAfter 等按名称替换或插入阶段
+const (
+	StageParse       = "parse"       // 解析文件为轨迹
+	StageFilter      = "filter"      // 按缺口拆分，剔除异常点、平滑、折叠停留点
+	StageSimplify    = "simplify"    // 轨迹简化
+	StageInterpolate = "interpolate" // 按缺口处理方式合并轨迹段并插点
+	StageTime        = "time"        // 分配时间戳
+	StageAltitude    = "altitude"    // 按海拔处理方式计算海拔
+	StageAttributes  = "attributes"  // 计算速度、方向、距离等属性，生成一生足迹数据
+	StageWrite       = "write"       // 写出 CSV 及其它格式
+)
+
+// Context 流水线各阶段之间传递的数据
+type Context struct {
+	Config   model.Config
+	FilePath string // 源文件路径，解析阶段读取
+	Content  []byte // 源文件内容，为空时由解析阶段读取 FilePath
+
+	Track    *model.Track  // 解析及各阶段处理后的轨迹
+	GapModes []string      // Track 中相邻轨迹段之间缺口的处理方式
+	Stops    []model.Stop  // 检测到的停留点
+	Points   []model.Point // 插点后按顺序展开的点，新轨迹段的第一个点带 SegmentStart 标记
+	// SegmentModes 插点后段与段之间缺口的处理方式，与 Points 中的 SegmentStart 标记一一对应
+	SegmentModes []string
+	Reversed     bool   // 分配时间时是否反转了轨迹顺序
+	SourceTime   bool   // 是否保留了源文件中的时间
+	Timezone     string // 按整条轨迹第一个点的坐标推断出的时区，仅在配置为自动推断时设置
+
+	StepLife *model.StepLife // 转换结果
+	// Passthrough 解析阶段已直接得到一生足迹数据（如一生足迹 CSV），跳过写出之前的其它阶段
+	Passthrough bool
+
+	// Progress 每完成一个阶段回调一次，done 为已完成的阶段数，可为空
+	Progress func(done, total int)
+	// Warn 处理过程中的警告（如按内容识别了格式、轨迹被反转），可为空
+	Warn func(message string)
+	// Info 各阶段的处理日志（如解析的点数、剔除的异常点），可为空
+	Info func(message string)
+}
+
+// Warnf 记录警告日志，设置了 Warn 时同时通知调用方
+func (this *Context) Warnf(template string, args ...interface{}) {
+	message := fmt.Sprintf(template, args...)
+	logx.Info(message)
+	if this.Warn != nil {
+		this.Warn(message)
+	}
+}
+
+// Infof 记录处理日志，设置了 Info 时同时通知调用方
+func (this *Context) Infof(template string, args ...interface{}) {
+	message := fmt.Sprintf(template, args...)
+	logx.Info(message)
+	if this.Info != nil {
+		this.Info(message)
+	}
+}
+
+// Stage 流水线中的一个处理阶段
+type Stage interface {
+	Name() string
+	Process(ctx *Context) error
+}
+
+type stageFunc struct {
+	name string
+	fn   func(ctx *Context) error
+}
+
+func (this stageFunc) Name() string {
+	return this.name
+}
+
+func (this stageFunc) Process(ctx *Context) error {
+	return this.fn(ctx)
+}
+
+// NewStage 由函数构造处理阶段
+func NewStage(name string, fn func(ctx *Context) error) Stage {
+	return stageFunc{name: name, fn: fn}
+}
+
+type Pipeline struct {
+	stages []Stage
+}
+
+// New 由指定阶段构造流水线
+func New(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Default
+//
+//	@Description: 		默认流水线：解析 → 过滤 → 简化 → 插点 → 时间 → 海拔 → 属性，不含写出阶段
+//	@return *Pipeline
+func Default() *Pipeline {
+	return New(
+		NewStage(StageParse, parseStage),
+		NewStage(StageFilter, filterStage),
+		NewStage(StageSimplify, simplifyStage),
+		NewStage(StageInterpolate, interpolateStage),
+		NewStage(StageTime, timeStage),
+		NewStage(StageAltitude, altitudeStage),
+		NewAttributesStage(),
+	)
+}
+
+// Stages 当前的阶段列表
+func (this *Pipeline) Stages() []Stage {
+	return this.stages
+}
+
+// Append 在末尾追加阶段
+func (this *Pipeline) Append(stages ...Stage) *Pipeline {
+	this.stages = append(this.stages, stages...)
+	return this
+}
+
+// Replace 替换同名阶段，不存在时不做修改
+func (this *Pipeline) Replace(name string, stage Stage) *Pipeline {
+	for i, s := range this.stages {
+		if s.Name() == name {
+			this.stages[i] = stage
+		}
+	}
+	return this
+}
+
+// InsertAfter 在指定阶段之后插入阶段，不存在时追加到末尾
+func (this *Pipeline) InsertAfter(name string, stage Stage) *Pipeline {
+	for i, s := range this.stages {
+		if s.Name() == name {
+			this.stages = append(this.stages[:i+1], append([]Stage{stage}, this.stages[i+1:]...)...)
+			return this
+		}
+	}
+	return this.Append(stage)
+}
+
+// Remove 移除指定阶段
+func (this *Pipeline) Remove(name string) *Pipeline {
+	stages := this.stages[:0]
+	for _, s := range this.stages {
+		if s.Name() != name {
+			stages = append(stages, s)
+		}
+	}
+	this.stages = stages
+	return this
+}
+
+// Run 依次执行各阶段
+func (this *Pipeline) Run(ctx *Context) error {
+	return this.RunContext(context.Background(), ctx)
+}
+
+// RunContext 依次执行各阶段，每个阶段开始前检查 c 是否已取消，取消时返回 c.Err()
+func (this *Pipeline) RunContext(c context.Context, ctx *Context) error {
+	for i, stage := range this.stages {
+		if err := c.Err(); err != nil {
+			return err
+		}
+		if !ctx.Passthrough || stage.Name() == StageWrite {
+			if err := stage.Process(ctx); err != nil {
+				logx.ErrorF("%s阶段处理失败：%s", stage.Name(), ctx.FilePath)
+				return fmt.Errorf("%s: %w", stage.Name(), err)
+			}
+		}
+		if ctx.Progress != nil {
+			ctx.Progress(i+1, len(this.stages))
+		}
+	}
+	return nil
+}
+
+// ConvertFile
+//
+//	@Description: 		使用默认流水线转换文件
+//	@param config
+//	@param filePath
+//	@return *model.StepLife
+//	@return error
+func ConvertFile(config model.Config, filePath string) (*model.StepLife, error) {
+	ctx := &Context{Config: config, FilePath: filePath}
+	if err := Default().Run(ctx); err != nil {
+		return nil, err
+	}
+	return ctx.StepLife, nil
+}
+
+// ConvertTrack
+//
+//	@Description: 		使用默认流水线（跳过解析阶段）转换已解析的轨迹
+//	@param config
+//	@param track
+//	@return *model.StepLife
+//	@return error
+func ConvertTrack(config model.Config, track *model.Track) (*model.StepLife, error) {
+	ctx := &Context{Config: config, Track: track}
+	if err := Default().Remove(StageParse).Run(ctx); err != nil {
+		return nil, err
+	}
+	return ctx.StepLife, nil
+}
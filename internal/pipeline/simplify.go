@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/simplify"
+)
+
+// simplifyStage 逐段简化轨迹
+func simplifyStage(ctx *Context) error {
+	for i := range ctx.Track.Segments {
+		segment := &ctx.Track.Segments[i]
+		segment.Points = simplifyPoints(ctx, segment.Points)
+	}
+	return nil
+}
+
+// simplifyPoints 按配置在插点前简化轨迹
+func simplifyPoints(ctx *Context, points []model.Point) []model.Point {
+	config := ctx.Config
+	var simplified []model.Point
+	switch config.SimplifyAlgorithm {
+	case consts.SimplifyDouglasPeucker:
+		simplified = simplify.DouglasPeucker(points, config.SimplifyTolerance)
+	case consts.SimplifyVisvalingam:
+		simplified = simplify.Visvalingam(points, config.SimplifyTolerance)
+	default:
+		return points
+	}
+
+	ctx.Infof("轨迹简化（%s，容差%.1f米）：%d个点 → %d个点",
+		config.SimplifyAlgorithm, config.SimplifyTolerance, len(points), len(simplified))
+	return simplified
+}
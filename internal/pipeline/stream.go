@@ -0,0 +1,487 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/utils/dem"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"steplife-universal-importer-gui/internal/utils/segment"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"steplife-universal-importer-gui/internal/utils/timezone"
+	"steplife-universal-importer-gui/internal/writer"
+	"time"
+)
+
+// streamCheckRows 流式转换时检查取消和报告进度的行数间隔
+const streamCheckRows = 10000
+
+// ErrNotStreamable 配置或文件内容需要完整的轨迹才能处理，不能流式转换
+var ErrNotStreamable = errors.New("不支持流式转换")
+
+// PointIterator 流式转换各阶段之间传递的点迭代器，读完时返回 io.EOF
+type PointIterator interface {
+	Next() (model.Point, error)
+}
+
+type iteratorFunc func() (model.Point, error)
+
+func (this iteratorFunc) Next() (model.Point, error) {
+	return this()
+}
+
+// Streamable
+//
+//	@Description: 	检查配置是否支持流式转换：过滤、平滑、停留点、简化、按结束时间分配时间、线性或飞行海拔、
+//	                	拆分输出以及 CSV 以外的输出格式都需要完整的轨迹
+//	@param config
+//	@return error	不支持时返回包装了 ErrNotStreamable 的错误
+func Streamable(config model.Config) error {
+	reason := ""
+	switch {
+	case len(config.GetOutputFormats()) > 1:
+		reason = "需要输出 CSV 以外的格式"
+	case config.OutlierFilter != "" || config.SmoothMethod != "":
+		reason = "开启了异常点过滤或平滑"
+	case config.EnableStayPointDetection == 1:
+		reason = "开启了停留点检测"
+	case config.SimplifyAlgorithm != "":
+		reason = "开启了轨迹简化"
+	case config.TimeMode != consts.TimeModeAuto && (config.PathEndTime != "" || config.PathEndTimestamp > 0):
+		reason = "需要按结束时间均匀分配时间"
+	case config.AltitudeMode == consts.AltitudeModeLinear || config.AltitudeMode == consts.AltitudeModeFlight:
+		reason = "海拔处理方式需要轨迹总长度"
+	}
+	if reason != "" {
+		return fmt.Errorf("%w：%s", ErrNotStreamable, reason)
+	}
+
+	overrides, err := config.GetSegmentGapModes()
+	if err != nil {
+		return err
+	}
+	modes := []string{config.SegmentGapMode}
+	for _, mode := range overrides {
+		modes = append(modes, mode)
+	}
+	for _, mode := range modes {
+		if _, ok := segmentModeNames[mode]; !ok && mode != "" {
+			return fmt.Errorf("不支持的缺口处理方式：%s", mode)
+		}
+		if mode == consts.SegmentModeSplit {
+			return fmt.Errorf("%w：缺口处理方式为拆分输出", ErrNotStreamable)
+		}
+	}
+	return nil
+}
+
+// Stream
+//
+//	@Description: 	流式转换：根据内容判断格式，逐点解析、处理并逐行写出一生足迹 CSV，内存占用与输入大小无关
+//	@param c		取消时停止转换并返回 c.Err()
+//	@param config
+//	@param r		轨迹文件内容
+//	@param w		CSV 输出
+//	@return error	配置或内容需要完整轨迹时返回包装了 ErrNotStreamable 的错误，此时 w 中可能已写出部分内容
+func Stream(c context.Context, config model.Config, r io.Reader, w io.Writer) error {
+	if err := Streamable(config); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(r, parser.SniffSize)
+	head, _ := reader.Peek(parser.SniffSize)
+	format, ok := parser.Lookup(parser.Detect(head))
+	if !ok {
+		return fmt.Errorf("无法识别输入的格式，仅支持 %s 文件", parser.ExtensionList())
+	}
+	return streamFormat(c, &Context{Config: config}, format, reader, w, nil)
+}
+
+// StreamFile
+//
+//	@Description: 		流式转换 ctx.FilePath 并写出 CSV
+//	@param c			取消时停止转换并返回 c.Err()
+//	@param ctx			Config、FilePath 为输入；设置了 Progress 时按已读取的字节数报告进度，Warn 接收处理中的警告
+//	@param csvFilePath	CSV 输出路径
+//	@return error		配置或内容需要完整轨迹时返回包装了 ErrNotStreamable 的错误，调用方应改用普通流水线
+func StreamFile(c context.Context, ctx *Context, csvFilePath string) error {
+	if err := Streamable(ctx.Config); err != nil {
+		return err
+	}
+	format, err := parser.Identify(ctx.FilePath, nil)
+	if err != nil {
+		return err
+	}
+	warnFormatMismatch(ctx, format)
+
+	file, err := os.Open(ctx.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	output, err := os.OpenFile(csvFilePath, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	var reader io.Reader = file
+	var report func()
+	if info, err := file.Stat(); err == nil && ctx.Progress != nil && info.Size() > 0 {
+		counter := &countingReader{reader: file}
+		reader = counter
+		report = func() {
+			ctx.Progress(int(min(counter.count, info.Size())), int(info.Size()))
+		}
+	}
+
+	bw := bufio.NewWriter(output)
+	if err = streamFormat(c, ctx, format, reader, bw, report); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// countingReader 统计已读取的字节数，用于报告流式转换的进度
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (this *countingReader) Read(p []byte) (int, error) {
+	n, err := this.reader.Read(p)
+	this.count += int64(n)
+	return n, err
+}
+
+// streamFormat 按指定格式流式转换，不支持流式解析的格式完整读取后逐点处理；每写出 streamCheckRows 行检查一次取消并报告进度
+func streamFormat(c context.Context, ctx *Context, format parser.Format, r io.Reader, w io.Writer, report func()) error {
+	csvWriter := writer.NewCSVWriter(w)
+	adaptor := format.New()
+
+	var source parser.PointIterator
+	if streamAdaptor, ok := adaptor.(parser.StreamAdaptor); ok {
+		source = streamAdaptor.Stream(r)
+	} else {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		ctx.Infof("%s 不支持流式解析，已完整读取", format.Name)
+
+		// 一生足迹 CSV 已是转换结果，原样写出
+		if rowAdaptor, ok := adaptor.(parser.RowAdaptor); ok {
+			rows, err := rowAdaptor.ParseRows(content)
+			if err != nil {
+				return err
+			}
+			warnIgnoredSettings(ctx)
+			for _, row := range rows {
+				if err = csvWriter.WriteRow(row); err != nil {
+					return err
+				}
+			}
+			return csvWriter.Flush()
+		}
+
+		track, err := adaptor.Parse(content)
+		if err != nil {
+			return err
+		}
+		source = parser.NewTrackIterator(track)
+	}
+
+	var points PointIterator = localizeStream(ctx, source)
+	for _, stage := range []func(*Context, PointIterator) PointIterator{gapStream, interpolateStream, timeStream, altitudeStream} {
+		points = stage(ctx, points)
+	}
+	rows := attributesStream(ctx, points)
+	for {
+		row, err := rows()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = csvWriter.WriteRow(row); err != nil {
+			return err
+		}
+		if csvWriter.Count()%streamCheckRows == 0 {
+			if err = c.Err(); err != nil {
+				return err
+			}
+			if report != nil {
+				report()
+			}
+		}
+	}
+	ctx.Infof("流式转换完成，共%d行", csvWriter.Count())
+	return csvWriter.Flush()
+}
+
+// localizeStream 与 localizeTrack 相同：不带时区信息的时间按配置的时区换算，自动推断时按每段第一个点的坐标推断
+func localizeStream(ctx *Context, source parser.PointIterator) PointIterator {
+	config := ctx.Config
+	started := false
+	zone := config.Timezone
+	var loc *time.Location
+	return iteratorFunc(func() (model.Point, error) {
+		point, err := source.Next()
+		if err != nil {
+			return point, err
+		}
+
+		if !started || point.SegmentStart {
+			loc = nil
+			if config.Timezone == consts.TimezoneAuto {
+				zone = timezone.Infer(point.Latitude, point.Longitude)
+				if !started {
+					ctx.Timezone = zone
+					ctx.Infof("根据坐标推断时区：%s", zone)
+				}
+			}
+		}
+		started = true
+
+		if point.LocalTime && point.DataTime > 0 {
+			if loc == nil {
+				if loc, err = timeUtils.Location(zone); err != nil {
+					return point, err
+				}
+			}
+			point.DataTime = timeUtils.Localize(point.DataTime, loc)
+			point.LocalTime = false
+		}
+		return point, nil
+	})
+}
+
+// gapStream 与 filterStage 的缺口检测相同：轨迹段边界和段内超过阈值的间隔为缺口，插点连接的缺口两侧并为同一轨迹段
+func gapStream(ctx *Context, in PointIterator) PointIterator {
+	config := ctx.Config
+	overrides, _ := config.GetSegmentGapModes()
+	defaultMode := config.SegmentGapMode
+	if defaultMode == "" {
+		defaultMode = consts.SegmentModeEmpty
+	}
+
+	var prev model.Point
+	index, gaps := 0, 0
+	return iteratorFunc(func() (model.Point, error) {
+		point, err := in.Next()
+		if err != nil {
+			return point, err
+		}
+		defer func() {
+			prev = point
+			index++
+		}()
+		if index == 0 {
+			return point, nil
+		}
+
+		boundary, ok := segment.Check(prev, point, index, config.SegmentGapDuration, config.SegmentGapDistance)
+		if point.SegmentStart {
+			boundary.Reason, ok = "轨迹段边界", true
+		}
+		if !ok {
+			return point, nil
+		}
+
+		gaps++
+		mode, found := overrides[gaps]
+		if !found {
+			mode = defaultMode
+		}
+		ctx.Infof("轨迹缺口#%d（%s）：%s", gaps, boundary, segmentModeNames[mode])
+		point.SegmentStart = mode != consts.SegmentModeInterpolate
+		return point, nil
+	})
+}
+
+// interpolateStream 与 interpolateStage 相同：在同一轨迹段内按距离插点，段与段之间不插点
+func interpolateStream(ctx *Context, in PointIterator) PointIterator {
+	config := ctx.Config
+	var prev model.Point
+	var queue []model.Point
+	started := false
+	original, total := 0, 0
+	return iteratorFunc(func() (model.Point, error) {
+		if len(queue) == 0 {
+			point, err := in.Next()
+			if err == io.EOF {
+				ctx.Infof("处理经纬度完成，原始坐标%d个，插点后坐标%d个", original, total)
+			}
+			if err != nil {
+				return point, err
+			}
+
+			original++
+			if started && !point.SegmentStart && config.EnableInsertPointStrategy == 1 {
+				queue = pointcalc.Calculate(prev, point, config.InsertPointDistance)
+			} else {
+				queue = []model.Point{point}
+			}
+			prev, started = point, true
+		}
+
+		point := queue[0]
+		queue = queue[1:]
+		total++
+		return point, nil
+	})
+}
+
+// timeStream 与 timeStage 相同：第一个点带原始时间时保留原始时间，否则按开始时间和时间间隔分配；
+// 只有部分点带原始时间（需要补齐）、或需要按结束时间分配时返回 ErrNotStreamable
+func timeStream(ctx *Context, in PointIterator) PointIterator {
+	config := ctx.Config
+	started := false
+	var startTimestamp, index int64
+	return iteratorFunc(func() (model.Point, error) {
+		point, err := in.Next()
+		if err != nil {
+			return point, err
+		}
+
+		if !started {
+			started = true
+			if config.TimeMode == consts.TimeModeAuto && point.DataTime > 0 {
+				ctx.Infof("轨迹带有原始时间，保留原始时间")
+				ctx.SourceTime = true
+			} else {
+				start, end, err := userTimestamps(ctx)
+				if err != nil {
+					return point, err
+				}
+				if end > 0 {
+					return point, fmt.Errorf("%w：需要按结束时间均匀分配时间", ErrNotStreamable)
+				}
+				if start == 0 {
+					start = time.Now().Unix()
+				}
+				startTimestamp = start
+			}
+		}
+
+		if ctx.SourceTime {
+			if point.DataTime <= 0 {
+				return point, fmt.Errorf("%w：部分点没有原始时间", ErrNotStreamable)
+			}
+			return point, nil
+		}
+		if config.TimeMode == consts.TimeModeAuto && point.DataTime > 0 {
+			return point, fmt.Errorf("%w：部分点没有原始时间", ErrNotStreamable)
+		}
+		point.DataTime = startTimestamp + index*config.TimeInterval
+		index++
+		return point, nil
+	})
+}
+
+// altitudeStream 与 altitudeStage 相同，仅支持逐点计算的海拔处理方式
+func altitudeStream(ctx *Context, in PointIterator) PointIterator {
+	config := ctx.Config
+	var store *dem.Store
+	missing := 0
+	return iteratorFunc(func() (model.Point, error) {
+		point, err := in.Next()
+		if err == io.EOF && missing > 0 {
+			ctx.Warnf("%d个点不在高程数据覆盖范围内，保留原始海拔", missing)
+		}
+		if err != nil {
+			return point, err
+		}
+
+		switch config.AltitudeMode {
+		case "", consts.AltitudeModeSource:
+		case consts.AltitudeModeConstant:
+			point.Altitude = config.DefaultAltitude
+		case consts.AltitudeModeDEM:
+			if store == nil {
+				if config.DemDirectory == "" {
+					return point, fmt.Errorf("海拔处理方式为地形高程时需要设置高程数据目录")
+				}
+				if store, err = dem.Open(config.DemDirectory); err != nil {
+					return point, err
+				}
+			}
+			if elevation, ok := store.Elevation(point.Latitude, point.Longitude); ok {
+				point.Altitude = elevation
+			} else {
+				missing++
+			}
+		default:
+			return point, fmt.Errorf("不支持的海拔处理方式：%s", config.AltitudeMode)
+		}
+		if point.Altitude == 0 {
+			point.Altitude = config.DefaultAltitude
+		}
+		return point, nil
+	})
+}
+
+// attributesStream 与 attributesStage 相同，按前后各一个点的窗口计算速度、方向和距离
+func attributesStream(ctx *Context, in PointIterator) func() (model.Row, error) {
+	var window []model.Point // 上一个点（若有）、当前点、下一个点（若有）
+	hasPrev, done := false, false
+	heading, cumulative := 0, 0.0
+
+	read := func() error {
+		point, err := in.Next()
+		if err == io.EOF {
+			done = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		window = append(window, point)
+		return nil
+	}
+
+	return func() (model.Row, error) {
+		// 保证窗口中有当前点和下一个点
+		for !done && len(window) < 3 && (len(window) < 2 || hasPrev) {
+			if err := read(); err != nil {
+				return model.Row{}, err
+			}
+		}
+		current := 0
+		if hasPrev {
+			current = 1
+		}
+		if current >= len(window) {
+			return model.Row{}, io.EOF
+		}
+
+		steps := stepDistances(window)
+		point := window[current]
+		if point.SegmentStart {
+			heading = 0
+		}
+		heading = calculateHeading(window, steps, current, heading)
+		cumulative += steps[current]
+
+		row := model.NewRow()
+		row.Point = point
+		row.Heading = heading
+		row.Distance = calculateDistance(ctx.Config, steps[current], cumulative)
+		row.Speed = calculateSpeed(ctx.Config, window, steps, current, ctx.SourceTime)
+
+		// 当前点成为下一次的上一个点
+		if hasPrev {
+			window = window[1:]
+		}
+		hasPrev = true
+		return *row, nil
+	}
+}
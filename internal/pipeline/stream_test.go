@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"steplife-universal-importer-gui/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// benchmarkSizes 生成的 GPX 点数，约 110 字节一个点，覆盖流式转换阈值上下
+var benchmarkSizes = []int{20_000, 200_000, 600_000}
+
+// writeBenchmarkGPX 生成一个 points 个点的 GPX：每秒一个点，相邻点约 12 米，不触发插点和缺口
+func writeBenchmarkGPX(b *testing.B, points int) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), fmt.Sprintf("track_%d.gpx", points))
+	file, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<gpx version="1.1" creator="benchmark"><trk><name>benchmark</name><trkseg>`)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < points; i++ {
+		// 纬度来回往返，避免超出有效范围
+		step := i % 40000
+		if step > 20000 {
+			step = 40000 - step
+		}
+		fmt.Fprintf(w, `<trkpt lat="%.7f" lon="%.7f"><ele>%.1f</ele><time>%s</time></trkpt>`+"\n",
+			30+float64(step)*0.0001, 120+float64(i%2)*0.00005, 50+float64(i%100),
+			start.Add(time.Duration(i)*time.Second).Format(time.RFC3339))
+	}
+	fmt.Fprintln(w, `</trkseg></trk></gpx>`)
+	if err = w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// peakHeap 在 fn 执行期间每 5 毫秒采样一次堆上对象占用的内存，返回峰值（字节）
+func peakHeap(fn func()) uint64 {
+	runtime.GC()
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	read := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+
+	var peak uint64
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			peak = max(peak, read())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	fn()
+	close(done)
+	wg.Wait()
+	return max(peak, read())
+}
+
+// benchmarkConvert 按文件大小分组运行 convert，报告耗时、分配次数和峰值堆内存
+func benchmarkConvert(b *testing.B, convert func(config model.Config, filePath, csvFilePath string) error) {
+	config := model.NewDefaultConfig()
+	for _, points := range benchmarkSizes {
+		b.Run(fmt.Sprintf("points=%d", points), func(b *testing.B) {
+			filePath := writeBenchmarkGPX(b, points)
+			csvFilePath := filepath.Join(b.TempDir(), "out.csv")
+			if info, err := os.Stat(filePath); err == nil {
+				b.SetBytes(info.Size())
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				peak = max(peak, peakHeap(func() {
+					if err := convert(config, filePath, csvFilePath); err != nil {
+						b.Fatal(err)
+					}
+				}))
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+		})
+	}
+}
+
+// BenchmarkStreamFile 流式转换：逐点解析、处理并逐行写出
+func BenchmarkStreamFile(b *testing.B) {
+	benchmarkConvert(b, func(config model.Config, filePath, csvFilePath string) error {
+		return StreamFile(context.Background(), &Context{Config: config, FilePath: filePath}, csvFilePath)
+	})
+}
+
+// BenchmarkPipelineFile 完整流水线：整个文件读入内存后逐阶段处理
+func BenchmarkPipelineFile(b *testing.B) {
+	benchmarkConvert(b, func(config model.Config, filePath, csvFilePath string) error {
+		ctx := &Context{Config: config, FilePath: filePath}
+		return Default().Append(NewWriteStage(csvFilePath, "benchmark")).RunContext(context.Background(), ctx)
+	})
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"steplife-universal-importer-gui/internal/utils/timezone"
+)
+
+// InferTimezone
+//
+//	@Description: 		解析文件并按第一个点的坐标推断时区，供界面展示
+//	@param filePath
+//	@return string		时区名称
+//	@return error
+func InferTimezone(filePath string) (string, error) {
+	ctx := &Context{Config: model.NewDefaultConfig(), FilePath: filePath}
+	if err := parseStage(ctx); err != nil {
+		return "", err
+	}
+	if ctx.Passthrough {
+		return "", nil
+	}
+	return trackTimezone(ctx.Track), nil
+}
+
+// trackTimezone 按第一个点的坐标推断时区，没有点时返回空字符串
+func trackTimezone(track *model.Track) string {
+	for _, segment := range track.Segments {
+		if len(segment.Points) > 0 {
+			return timezone.Infer(segment.Points[0].Latitude, segment.Points[0].Longitude)
+		}
+	}
+	return ""
+}
+
+// localizeTrack
+//
+//	@Description: 	将不带时区信息（LocalTime）的点的时间按配置的时区换算，带时区标记的点保持不变。
+//	              	自动推断时，点的时间按所在轨迹段第一个点的坐标推断的时区换算；
+//	              	ctx.Timezone 则始终取整条轨迹第一个点的时区，用于展示及解析用户输入的开始、结束时间
+//	@param ctx
+//	@return error
+func localizeTrack(ctx *Context) error {
+	config := ctx.Config
+	if config.Timezone == consts.TimezoneAuto {
+		ctx.Timezone = trackTimezone(ctx.Track)
+		if ctx.Timezone != "" {
+			ctx.Infof("根据坐标推断时区：%s", ctx.Timezone)
+		}
+	}
+
+	for i := range ctx.Track.Segments {
+		segment := &ctx.Track.Segments[i]
+		if !hasLocalTime(segment.Points) {
+			continue
+		}
+
+		zone := config.Timezone
+		if zone == consts.TimezoneAuto {
+			zone = timezone.Infer(segment.Points[0].Latitude, segment.Points[0].Longitude)
+			if zone != ctx.Timezone {
+				ctx.Infof("轨迹段「%s」推断时区：%s", segment.Name, zone)
+			}
+		}
+		loc, err := timeUtils.Location(zone)
+		if err != nil {
+			return err
+		}
+		for j := range segment.Points {
+			point := &segment.Points[j]
+			if point.LocalTime && point.DataTime > 0 {
+				point.DataTime = timeUtils.Localize(point.DataTime, loc)
+			}
+			point.LocalTime = false
+		}
+	}
+	return nil
+}
+
+// hasLocalTime 是否有点的时间不带时区信息
+func hasLocalTime(points []model.Point) bool {
+	for _, point := range points {
+		if point.LocalTime {
+			return true
+		}
+	}
+	return false
+}
+
+// userTimestamps 自动推断时区时，按推断出的时区重新解析用户输入的开始、结束时间
+func userTimestamps(ctx *Context) (int64, int64, error) {
+	config := ctx.Config
+	if config.Timezone != consts.TimezoneAuto {
+		return config.PathStartTimestamp, config.PathEndTimestamp, nil
+	}
+	if ctx.Timezone == "" && len(ctx.Points) > 0 {
+		ctx.Timezone = timezone.Infer(ctx.Points[0].Latitude, ctx.Points[0].Longitude)
+	}
+
+	start, end := config.PathStartTimestamp, config.PathEndTimestamp
+	var err error
+	if config.PathStartTime != "" {
+		if start, err = timeUtils.ToTimestampWithTimezone(config.PathStartTime, ctx.Timezone); err != nil {
+			return 0, 0, err
+		}
+	}
+	if config.PathEndTime != "" {
+		if end, err = timeUtils.ToTimestampWithTimezone(config.PathEndTime, ctx.Timezone); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, end, nil
+}
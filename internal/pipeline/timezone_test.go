@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+func TestLocalizeOnlyNaivePoints(t *testing.T) {
+	content := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1"><trk><trkseg>
+<trkpt lat="31.23" lon="121.47"><time>2024-05-01T08:00:00Z</time></trkpt>
+<trkpt lat="31.24" lon="121.47"><time>2024-05-01T16:01:00+08:00</time></trkpt>
+<trkpt lat="31.25" lon="121.47"><time>2024-05-01T16:02:00</time></trkpt>
+</trkseg></trk></gpx>`)
+
+	config := model.NewDefaultConfig()
+	config.Timezone = "Asia/Shanghai"
+	ctx := &Context{Config: config, FilePath: "mixed.gpx", Content: content}
+	if err := parseStage(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-05-01T08:00:00Z 起每分钟一个点，不带时区的点按上海时间换算
+	want := []int64{1714550400, 1714550460, 1714550520}
+	points := ctx.Track.Segments[0].Points
+	for i, point := range points {
+		if point.DataTime != want[i] {
+			t.Errorf("point %d DataTime = %d, want %d", i, point.DataTime, want[i])
+		}
+		if point.LocalTime {
+			t.Errorf("point %d still marked as local time", i)
+		}
+	}
+}
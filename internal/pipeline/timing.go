@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"math"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"time"
+)
+
+// timeStage 分配时间戳：轨迹带原始时间时保留原始时间，个别没有时间的点按前后带时间的点补齐；否则按配置分配
+// 优先级：结束时间 > 用户指定的时间间隔 > 统一为开始时间
+func timeStage(ctx *Context) error {
+	points := ctx.Points
+	if len(points) == 0 {
+		return nil
+	}
+
+	if ctx.Config.TimeMode == consts.TimeModeAuto && fillSourceTimes(ctx, points) {
+		ctx.Infof("轨迹带有原始时间，保留原始时间")
+		ctx.SourceTime = true
+		return nil
+	}
+
+	config := ctx.Config
+	startTimestamp, endTimestamp, err := userTimestamps(ctx)
+	if err != nil {
+		return err
+	}
+	if startTimestamp == 0 {
+		startTimestamp = time.Now().Unix()
+	}
+
+	// 如果开始时间大于结束时间，反转轨迹点顺序并交换时间戳
+	if endTimestamp > 0 && startTimestamp > endTimestamp {
+		ctx.Warnf("检测到开始时间大于结束时间，自动反转轨迹顺序")
+		reversePoints(points)
+		startTimestamp, endTimestamp = endTimestamp, startTimestamp
+		ctx.Reversed = true
+	}
+
+	totalPoints := int64(len(points))
+	var timeInterval int64 = 0
+	useEndTime := endTimestamp > 0 && totalPoints > 1
+	useTimeInterval := config.TimeInterval != 0 && totalPoints > 1
+
+	if useEndTime {
+		// 如果设置了结束时间，计算均匀分配的时间间隔
+		timeInterval = (endTimestamp - startTimestamp) / (totalPoints - 1)
+		if timeInterval < 1 {
+			timeInterval = 1
+		}
+	} else if useTimeInterval {
+		// 如果用户指定了时间间隔，使用指定的间隔
+		timeInterval = config.TimeInterval
+	}
+
+	// 未设置结束时间和间隔时，所有时间统一为开始时间
+	for i := range points {
+		points[i].DataTime = startTimestamp + int64(i)*timeInterval
+	}
+	// 确保最后一个点的时间戳等于结束时间
+	if useEndTime {
+		points[len(points)-1].DataTime = endTimestamp
+	}
+	return nil
+}
+
+// fillSourceTimes 有点带原始时间时补齐其余没有时间的点并给出警告，返回是否有点带原始时间
+func fillSourceTimes(ctx *Context, points []model.Point) bool {
+	missing := countMissingTime(points)
+	if missing == len(points) {
+		return false
+	}
+	if missing > 0 {
+		fillMissingTimes(points)
+		ctx.Warnf("%d个点没有原始时间，已按前后带时间的点插值补齐", missing)
+	}
+	return true
+}
+
+// countMissingTime 没有原始时间的点数
+func countMissingTime(points []model.Point) int {
+	missing := 0
+	for _, point := range points {
+		if point.DataTime <= 0 {
+			missing++
+		}
+	}
+	return missing
+}
+
+// fillMissingTimes 补齐没有原始时间的点：位于两个带时间的点之间时按距离线性插值（距离为 0 时按点数），
+// 位于轨迹首尾时取最近的带时间的点的时间；至少需要一个带时间的点
+func fillMissingTimes(points []model.Point) {
+	previous := -1
+	for i := range points {
+		if points[i].DataTime <= 0 {
+			continue
+		}
+		if previous < 0 {
+			for j := 0; j < i; j++ {
+				points[j].DataTime = points[i].DataTime
+			}
+		} else if i-previous > 1 {
+			interpolateTimes(points[previous : i+1])
+		}
+		previous = i
+	}
+	for j := previous + 1; j < len(points); j++ {
+		points[j].DataTime = points[previous].DataTime
+	}
+}
+
+// interpolateTimes 按累计距离为首尾两点之间的点分配时间
+func interpolateTimes(points []model.Point) {
+	last := len(points) - 1
+	distances := make([]float64, len(points))
+	for i := 1; i <= last; i++ {
+		distances[i] = distances[i-1] + pointcalc.Distance(points[i-1], points[i])
+	}
+	start, duration := points[0].DataTime, points[last].DataTime-points[0].DataTime
+	for i := 1; i < last; i++ {
+		fraction := float64(i) / float64(last)
+		if distances[last] > 0 {
+			fraction = distances[i] / distances[last]
+		}
+		points[i].DataTime = start + int64(math.Round(fraction*float64(duration)))
+	}
+}
+
+// reversePoints 反转点的顺序，轨迹段标记随之后移一位，仍落在每段的第一个点上
+func reversePoints(points []model.Point) {
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	for i := len(points) - 1; i > 0; i-- {
+		points[i].SegmentStart = points[i-1].SegmentStart
+	}
+	points[0].SegmentStart = false
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+func TestTimeStageFillsMissingTimes(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Times []int64
+		Want  []int64
+		Warn  bool
+	}{
+		{"全部带时间", []int64{100, 110, 120}, []int64{100, 110, 120}, false},
+		{"中间缺失按距离插值", []int64{100, 0, 0, 130}, []int64{100, 110, 120, 130}, true},
+		{"首尾缺失取最近的时间", []int64{0, 100, 0, 120, 0}, []int64{100, 100, 110, 120, 120}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			points := make([]model.Point, len(c.Times))
+			for i, dataTime := range c.Times {
+				// 等距的点，按距离插值与按点数插值结果相同
+				points[i] = model.Point{Latitude: 30, Longitude: 120 + float64(i)*0.001, DataTime: dataTime}
+			}
+			var warnings []string
+			ctx := &Context{
+				Config: model.Config{TimeMode: consts.TimeModeAuto},
+				Points: points,
+				Warn:   func(message string) { warnings = append(warnings, message) },
+			}
+			if err := timeStage(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if !ctx.SourceTime {
+				t.Error("SourceTime = false, want true")
+			}
+			for i, point := range ctx.Points {
+				if point.DataTime != c.Want[i] {
+					t.Errorf("point %d DataTime = %d, want %d", i, point.DataTime, c.Want[i])
+				}
+			}
+			if (len(warnings) > 0) != c.Warn {
+				t.Errorf("warnings = %v", warnings)
+			}
+		})
+	}
+}
+
+func TestPipelineAutoTimeWithInsertPoints(t *testing.T) {
+	// 相邻点约 330 米，只有首尾两个点带时间
+	content := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns:gx="http://www.google.com/kml/ext/2.2"><Placemark><gx:Track>
+<when>2023-11-14T22:13:20Z</when><when></when><when></when><when>2023-11-14T22:23:20Z</when>
+<gx:coord>120.000 30.000 0</gx:coord><gx:coord>120.000 30.003 0</gx:coord>
+<gx:coord>120.000 30.006 0</gx:coord><gx:coord>120.000 30.009 0</gx:coord>
+</gx:Track></Placemark></kml>`)
+	const start, end = 1700000000, 1700000600
+
+	config := model.NewDefaultConfig()
+	config.TimeMode = consts.TimeModeAuto
+	config.EnableInsertPointStrategy = 1
+	config.InsertPointDistance = 100
+	ctx := &Context{Config: config, FilePath: "track.kml", Content: content}
+	if err := Default().RunContext(context.Background(), ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := ctx.StepLife.Rows
+	if len(rows) <= 4 {
+		t.Fatalf("len(rows) = %d, want inserted points", len(rows))
+	}
+	if rows[0].DataTime != start || rows[len(rows)-1].DataTime != end {
+		t.Errorf("first/last DataTime = %d/%d, want %d/%d", rows[0].DataTime, rows[len(rows)-1].DataTime, start, end)
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i].DataTime < rows[i-1].DataTime || rows[i].DataTime > end {
+			t.Errorf("row %d DataTime = %d after %d", i, rows[i].DataTime, rows[i-1].DataTime)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"steplife-universal-importer-gui/internal/writer"
+	"strings"
+)
+
+// NewWriteStage
+//
+//	@Description: 		写出阶段：写入 CSV 以及配置的其它输出格式，缺口处拆分时每部分输出为独立文件
+//	@param csvFilePath	CSV 输出路径，其它格式与其同名
+//	@param name			轨迹名称
+//	@return Stage
+func NewWriteStage(csvFilePath, name string) Stage {
+	return NewStage(StageWrite, func(ctx *Context) error {
+		parts := splitStepLife(ctx.StepLife)
+		for i, part := range parts {
+			partPath, partName := csvFilePath, name
+			// 在缺口处拆分时，每部分输出为独立文件，文件名追加序号
+			if len(parts) > 1 {
+				ext := filepath.Ext(csvFilePath)
+				partPath = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(csvFilePath, ext), i+1, ext)
+				partName = fmt.Sprintf("%s-%d", name, i+1)
+				ctx.Infof("拆分输出第%d部分（%d行）：%s", i+1, len(part.Rows), partPath)
+			}
+
+			// 合并文件头和数据，直接覆盖写入
+			allRows := append(append([][]string{}, part.CSVHeader...), part.CSVData...)
+			if err := utils.WriteCSV(partPath, allRows); err != nil {
+				logx.ErrorF("写入CSV文件失败：%s", partPath)
+				return err
+			}
+
+			if err := WriteExtraOutputs(partPath, partName, part.SegmentRows(), part.Stops, ctx.Config); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WriteExtraOutputs 按配置写出 CSV 以外的输出格式，文件与 CSV 同名，仅扩展名不同；开启停留点导出时一并写出停留点
+func WriteExtraOutputs(csvFilePath, name string, segments [][]model.Row, stops []model.Stop, config model.Config) error {
+	if config.ExportStayPoints != 1 {
+		stops = nil
+	}
+	basePath := strings.TrimSuffix(csvFilePath, filepath.Ext(csvFilePath))
+	for _, format := range config.GetOutputFormats() {
+		if format == consts.OutputFormatCSV {
+			continue
+		}
+		fw := writer.CreateWriter(format)
+		if fw == nil {
+			return fmt.Errorf("不支持的输出格式：%s", format)
+		}
+
+		outputPath := basePath + fw.Ext()
+		if err := writer.WriteFile(outputPath, fw, name, segments, stops); err != nil {
+			logx.ErrorF("写入%s文件失败：%s", format, outputPath)
+			return err
+		}
+		logx.InfoF("已导出%s文件：%s", format, outputPath)
+	}
+	return nil
+}
+
+// splitStepLife 按 SplitAt 拆分为多个独立输出，停留点归入距离最近的轨迹行所在的部分
+func splitStepLife(sl *model.StepLife) []*model.StepLife {
+	if len(sl.SplitAt) == 0 {
+		return []*model.StepLife{sl}
+	}
+
+	var parts []*model.StepLife
+	start := 0
+	for _, end := range append(append([]int{}, sl.SplitAt...), len(sl.Rows)) {
+		part := model.NewStepLife()
+		for _, row := range sl.Rows[start:end] {
+			part.AddCSVRow(row)
+		}
+		parts = append(parts, part)
+		start = end
+	}
+
+	for _, stop := range sl.Stops {
+		stopPoint := model.Point{Latitude: stop.Latitude, Longitude: stop.Longitude}
+		nearest, nearestDistance := 0, -1.0
+		for i, part := range parts {
+			for _, row := range part.Rows {
+				if d := pointcalc.Distance(stopPoint, row.Point); nearestDistance < 0 || d < nearestDistance {
+					nearest, nearestDistance = i, d
+				}
+			}
+		}
+		parts[nearest].Stops = append(parts[nearest].Stops, stop)
+	}
+	return parts
+}
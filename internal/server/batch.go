@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strings"
+)
+
+// BatchFile 批量转换中的一个文件
+type BatchFile struct {
+	FilePath   string
+	OutputPath string        // CSV 输出路径
+	Config     *model.Config // 该文件单独的配置（如按文件设置的时间），为 nil 时使用任务的配置
+}
+
+// ErrDuplicateOutput 批量中的多个文件输出到同一个 CSV，并发转换时会互相覆盖
+var ErrDuplicateOutput = errors.New("输出路径重复")
+
+// FileResult 单个文件的转换结果
+type FileResult struct {
+	BatchFile
+	Index int   // 在批量文件列表中的序号
+	Err   error // 转换失败的原因，取消后未处理的文件为 context.Canceled
+}
+
+// NewBatchFile 构造批量中的一个文件，按 FileConfig 查找文件单独的时间设置
+func NewBatchFile(filePath, outputPath string, config model.Config, manual map[string]FileTime) (BatchFile, error) {
+	file := BatchFile{FilePath: filePath, OutputPath: outputPath}
+	fileConfig, fileTime, err := FileConfig(filePath, config, manual)
+	if err != nil {
+		return file, err
+	}
+	if !fileTime.IsZero() {
+		logx.InfoF("%s 使用%s中的时间设置：%s", filepath.Base(filePath), fileTime.SourceName(), fileTime)
+		file.Config = &fileConfig
+	}
+	return file, nil
+}
+
+// processBatchFile 转换批量中的一个文件，panic 转为该文件的错误，不影响其它文件
+func processBatchFile(ctx context.Context, file BatchFile, config model.Config, hooks FileHooks) (err error) {
+	if file.Config != nil {
+		config = *file.Config
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("处理过程中发生严重错误: %v", r)
+		}
+	}()
+	return ProcessFile(ctx, consts.FileTypeCommon, file.FilePath, file.OutputPath, config, hooks)
+}
+
+// OutputPaths
+//
+//	@Description: 		为每个源文件生成 CSV 输出路径 outputDir/原文件名_steplife.csv；原文件名相同（如 trip.gpx、trip.kml）时
+//	                  		保留扩展名（trip.gpx_steplife.csv），仍相同（不同子目录中的同名文件）时追加序号（trip.gpx_2_steplife.csv）；
+//	                  		文件名按不区分大小写比较，保证在 Windows、macOS 上也不会重复
+//	@param filePaths
+//	@param outputDir
+//	@return []string	与 filePaths 一一对应的输出路径
+func OutputPaths(filePaths []string, outputDir string) []string {
+	names := make([]string, len(filePaths))
+	counts := make(map[string]int)
+	for i, filePath := range filePaths {
+		names[i] = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		counts[strings.ToLower(names[i])]++
+	}
+
+	taken := make(map[string]bool)
+	paths := make([]string, len(filePaths))
+	for i, filePath := range filePaths {
+		name := names[i]
+		if counts[strings.ToLower(name)] > 1 {
+			name = filepath.Base(filePath)
+		}
+		candidate := name
+		for n := 2; taken[strings.ToLower(candidate)]; n++ {
+			candidate = fmt.Sprintf("%s_%d", name, n)
+		}
+		taken[strings.ToLower(candidate)] = true
+		paths[i] = filepath.Join(outputDir, candidate+"_steplife.csv")
+	}
+	return paths
+}
+
+// CheckOutputPaths 检查批量中是否有多个文件输出到同一个 CSV，有时返回包装了 ErrDuplicateOutput 的错误
+func CheckOutputPaths(files []BatchFile) error {
+	outputs := make(map[string]string)
+	for _, file := range files {
+		key := strings.ToLower(filepath.Clean(file.OutputPath))
+		if other, ok := outputs[key]; ok {
+			return fmt.Errorf("%w：%s 与 %s 都输出到 %s", ErrDuplicateOutput, other, file.FilePath, file.OutputPath)
+		}
+		outputs[key] = file.FilePath
+	}
+	return nil
+}
+
+// CollectFiles 收集待处理的文件：input 为文件时识别其格式，为目录时递归扫描并跳过隐藏文件和 outputDir 中的输出结果
+func CollectFiles(input, outputDir string) ([]string, error) {
+	fileInfo, err := os.Stat(input)
+	if err != nil {
+		return nil, fmt.Errorf("访问路径失败：%w", err)
+	}
+	if !fileInfo.IsDir() {
+		if _, err := parser.Identify(input, nil); err != nil {
+			return nil, err
+		}
+		return []string{input}, nil
+	}
+
+	var filePaths []string
+	err = filepath.Walk(input, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != input && (strings.HasPrefix(info.Name(), ".") || (info.IsDir() && path == filepath.Clean(outputDir))) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && utils.IsGeneratedOutput(path, outputDir) {
+			return nil
+		}
+		if !info.IsDir() && parser.SupportedFile(path) {
+			filePaths = append(filePaths, path)
+		}
+		return nil
+	})
+	return filePaths, err
+}
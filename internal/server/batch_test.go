@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOutputPaths(t *testing.T) {
+	out := filepath.Join("data", "output")
+	cases := []struct {
+		Name  string
+		Files []string
+		Want  []string
+	}{
+		{
+			"不同文件名",
+			[]string{"data/a.gpx", "data/b.kml"},
+			[]string{"a_steplife.csv", "b_steplife.csv"},
+		},
+		{
+			"同名不同扩展名",
+			[]string{"data/trip.gpx", "data/trip.kml", "data/other.gpx"},
+			[]string{"trip.gpx_steplife.csv", "trip.kml_steplife.csv", "other_steplife.csv"},
+		},
+		{
+			"不同子目录中的同名文件",
+			[]string{"data/2023/trip.gpx", "data/2024/trip.gpx", "data/2024/trip.kml"},
+			[]string{"trip.gpx_steplife.csv", "trip.gpx_2_steplife.csv", "trip.kml_steplife.csv"},
+		},
+		{
+			"仅大小写不同",
+			[]string{"data/Trip.gpx", "data/trip.gpx"},
+			[]string{"Trip.gpx_steplife.csv", "trip.gpx_2_steplife.csv"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got := OutputPaths(c.Files, out)
+			want := make([]string, len(c.Want))
+			for i, name := range c.Want {
+				want[i] = filepath.Join(out, name)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("OutputPaths(%v) = %v, want %v", c.Files, got, want)
+			}
+
+			files := make([]BatchFile, len(c.Files))
+			for i := range c.Files {
+				files[i] = BatchFile{FilePath: c.Files[i], OutputPath: got[i]}
+			}
+			if err := CheckOutputPaths(files); err != nil {
+				t.Errorf("CheckOutputPaths: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckOutputPathsDuplicate(t *testing.T) {
+	files := []BatchFile{
+		{FilePath: "data/trip.gpx", OutputPath: "output/trip_steplife.csv"},
+		{FilePath: "data/trip.kml", OutputPath: "output/Trip_steplife.csv"},
+	}
+	if err := CheckOutputPaths(files); !errors.Is(err, ErrDuplicateOutput) {
+		t.Errorf("CheckOutputPaths = %v, want ErrDuplicateOutput", err)
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.gpx":        `<?xml version="1.0"?><gpx version="1.1"></gpx>`,
+		"steplife.csv": "dataTime,locType,longitude,latitude\n1714521600,1,121.47,31.23\n",
+		"expense.csv":  "date,amount\n2024-05-01,12.5\n",
+		"track.txt":    `<?xml version="1.0"?><gpx version="1.1"></gpx>`,
+		"notes.txt":    "hello",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := CollectFiles(dir, filepath.Join(dir, "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.gpx"), filepath.Join(dir, "steplife.csv"), filepath.Join(dir, "track.txt")}
+	if !slices.Equal(got, want) {
+		t.Errorf("CollectFiles = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/pipeline"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CombineResult 多个文件合并为一个 CSV 的统计
+type CombineResult struct {
+	Files         int          // 合并的文件数
+	Order         []string     // 合并后的文件顺序
+	ShiftedFiles  int          // 为避免时间重叠而整体平移的文件数
+	ConnectorRows int          // 文件之间连接段的行数
+	TotalRows     int          // 输出行数
+	Failed        []FileResult // 转换失败而跳过的文件及原因
+}
+
+// combineSource 合并前已转换的单个文件
+type combineSource struct {
+	filePath   string
+	sourceTime bool // 是否保留了源文件中的时间
+	rows       []model.Row
+	stops      []model.Stop
+}
+
+func (this *combineSource) start() int64 {
+	return this.rows[0].DataTime
+}
+
+func (this *combineSource) end() int64 {
+	return this.rows[len(this.rows)-1].DataTime
+}
+
+// shift 整体平移轨迹及停留点的时间
+func (this *combineSource) shift(offset int64) {
+	for i := range this.rows {
+		this.rows[i].DataTime += offset
+	}
+	for i := range this.stops {
+		this.stops[i].StartTime += offset
+		this.stops[i].EndTime += offset
+	}
+}
+
+// CombineFiles
+//
+//	@Description: 		将多个文件并发转换（并发数为 CPU 核数）后按顺序合并为一个 CSV：按 config.BatchOrder 排列，
+//	                  		后一个文件与前一个文件时间重叠时整体平移到其后，设置了 config.BatchConnector 时在相邻文件之间生成连接段；
+//	                  		转换失败的文件通过 hooks.Warn 提示后跳过，记录在 CombineResult.Failed 中
+//	@param ctx			取消后不再开始新的文件，正在处理的文件在阶段之间停止，不写出结果，返回 ctx.Err()
+//	@param files		按列表顺序排列的文件，OutputPath 不使用，设置了 Config 时按该配置转换
+//	@param outputPath	输出 CSV 路径
+//	@param config		全局配置，合并顺序、间隔、连接方式及输出格式以此为准
+//	@param hooks		Progress 为整体进度，Warn 接收各文件的警告、平移及跳过提示，Info 接收各文件的处理日志；
+//	                	回调可能来自不同的 goroutine，但不会同时执行
+//	@return *CombineResult
+//	@return error		没有可合并的轨迹（全部文件失败时包含各文件的失败原因）或写出失败
+func CombineFiles(ctx context.Context, files []BatchFile, outputPath string, config model.Config, hooks FileHooks) (*CombineResult, error) {
+	order := config.BatchOrder
+	switch order {
+	case "":
+		order = consts.BatchOrderTime
+	case consts.BatchOrderTime, consts.BatchOrderList:
+	default:
+		return nil, fmt.Errorf("不支持的合并顺序：%s", order)
+	}
+	switch config.BatchConnector {
+	case "", consts.FillModeStraight, consts.FillModeGreatCircle, consts.FillModeFlight, consts.FillModeRail:
+	default:
+		return nil, fmt.Errorf("不支持的连接方式：%s", config.BatchConnector)
+	}
+	gap := config.BatchGap
+	if gap < 0 {
+		gap = 0
+	}
+
+	hooks = serialHooks(hooks)
+	sources, failed, err := convertCombineFiles(ctx, files, config, hooks)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		if len(failed) > 0 {
+			errs := make([]error, len(failed))
+			for i, f := range failed {
+				errs[i] = fmt.Errorf("%s：%w", filepath.Base(f.FilePath), f.Err)
+			}
+			return nil, fmt.Errorf("没有可合并的轨迹：%w", errors.Join(errs...))
+		}
+		return nil, fmt.Errorf("没有可合并的轨迹")
+	}
+
+	// 按轨迹开始时间排序时，开始时间相同（如都没有原始时间）的文件保持列表顺序
+	if order == consts.BatchOrderTime {
+		sort.SliceStable(sources, func(i, j int) bool {
+			return sources[i].start() < sources[j].start()
+		})
+	}
+
+	result := &CombineResult{Files: len(sources), Failed: failed}
+	sl := model.NewStepLife()
+	var segments [][]model.Row
+	var stops []model.Stop
+	for i, source := range sources {
+		result.Order = append(result.Order, source.filePath)
+		if i > 0 {
+			prev := sources[i-1]
+			earliest := prev.end() + gap
+			if config.BatchConnector != "" {
+				distance := pointcalc.Distance(prev.rows[len(prev.rows)-1].Point, source.rows[0].Point)
+				earliest = prev.end() + max(gap, connectorDuration(config.BatchConnector, distance))
+			}
+			if source.start() < earliest {
+				offset := earliest - source.start()
+				source.shift(offset)
+				result.ShiftedFiles++
+				message := fmt.Sprintf("%s 与 %s 的时间重叠或间隔不足，整体平移%s", filepath.Base(source.filePath),
+					filepath.Base(prev.filePath), time.Duration(offset)*time.Second)
+				if source.sourceTime {
+					// 源文件自带的时间被改变，需要提醒用户
+					warn(hooks, message+"（原始时间已改变）")
+				} else {
+					info(hooks, message)
+				}
+			}
+
+			if config.BatchConnector != "" {
+				connector, err := connectorRows(prev.rows[len(prev.rows)-1], source.rows[0], config)
+				if err != nil {
+					return nil, err
+				}
+				if len(connector) > 0 {
+					info(hooks, fmt.Sprintf("%s → %s 生成连接段%d个点", filepath.Base(prev.filePath), filepath.Base(source.filePath), len(connector)))
+					connector[0].SegmentStart = true
+					for _, row := range connector {
+						sl.AddCSVRow(row)
+					}
+					segments = append(segments, connector)
+					result.ConnectorRows += len(connector)
+				}
+			}
+		}
+
+		source.rows[0].SegmentStart = i > 0
+		for _, row := range source.rows {
+			sl.AddCSVRow(row)
+		}
+		segments = append(segments, (&model.StepLife{Rows: source.rows}).SegmentRows()...)
+		stops = append(stops, source.stops...)
+	}
+	result.TotalRows = len(sl.CSVData)
+
+	allRows := append(append([][]string{}, sl.CSVHeader...), sl.CSVData...)
+	if err := utils.WriteCSV(outputPath, allRows); err != nil {
+		logx.ErrorF("写入CSV文件失败：%s", outputPath)
+		return nil, err
+	}
+	trackName := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	if err := pipeline.WriteExtraOutputs(outputPath, trackName, segments, stops, config); err != nil {
+		return nil, err
+	}
+	if hooks.Progress != nil {
+		hooks.Progress(1)
+	}
+
+	logx.InfoF("合并输出完成：%d个文件，跳过%d个，平移%d个，连接段%d行，共%d行：%s",
+		result.Files, len(result.Failed), result.ShiftedFiles, result.ConnectorRows, result.TotalRows, outputPath)
+	return result, nil
+}
+
+// convertCombineFiles 并发转换待合并的文件，返回按列表顺序排列的转换结果（没有轨迹点的文件不包含在内）以及转换失败的文件；
+// 留出 5% 的进度给合并和写出，取消时返回 ctx.Err()
+func convertCombineFiles(ctx context.Context, files []BatchFile, config model.Config, hooks FileHooks) ([]*combineSource, []FileResult, error) {
+	converted := make([]*combineSource, len(files))
+	errs := make([]error, len(files))
+
+	var mu sync.Mutex
+	fractions := make([]float64, len(files))
+	progress := func(index int, fraction float64) {
+		if hooks.Progress == nil {
+			return
+		}
+		// 在锁内通知，保证整体进度不会倒退
+		mu.Lock()
+		defer mu.Unlock()
+		fractions[index] = fraction
+		total := 0.0
+		for _, f := range fractions {
+			total += f
+		}
+		hooks.Progress(0.95 * total / float64(len(files)))
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if errs[index] = ctx.Err(); errs[index] != nil {
+					continue
+				}
+				converted[index], errs[index] = convertCombineFile(ctx, index, files[index], config, hooks, progress)
+				progress(index, 1)
+			}
+		}()
+	}
+	for index := range files {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	var sources []*combineSource
+	var failed []FileResult
+	for index, file := range files {
+		switch {
+		case errs[index] != nil:
+			warn(hooks, fmt.Sprintf("%s 转换失败，已跳过：%v", filepath.Base(file.FilePath), errs[index]))
+			failed = append(failed, FileResult{BatchFile: file, Index: index, Err: errs[index]})
+		case converted[index] == nil:
+			info(hooks, fmt.Sprintf("%s 没有轨迹点，跳过", filepath.Base(file.FilePath)))
+		default:
+			sources = append(sources, converted[index])
+		}
+	}
+	return sources, failed, nil
+}
+
+// convertCombineFile 转换待合并的第 index 个文件，没有轨迹点时返回 nil
+func convertCombineFile(ctx context.Context, index int, file BatchFile, config model.Config, hooks FileHooks,
+	progress func(index int, fraction float64)) (*combineSource, error) {
+	filePath := file.FilePath
+	info(hooks, fmt.Sprintf("合并输出：转换第%d个文件（%s）", index+1, filepath.Base(filePath)))
+	fileConfig := config
+	if file.Config != nil {
+		fileConfig = *file.Config
+	}
+	pctx := &pipeline.Context{Config: fileConfig, FilePath: filePath, Warn: hooks.Warn, Info: hooks.Info}
+	pctx.Progress = func(done, total int) {
+		progress(index, float64(done)/float64(total))
+	}
+	if err := pipeline.Default().RunContext(ctx, pctx); err != nil {
+		return nil, err
+	}
+	if pctx.StepLife == nil || len(pctx.StepLife.Rows) == 0 {
+		return nil, nil
+	}
+	return &combineSource{
+		filePath:   filePath,
+		sourceTime: pctx.SourceTime || pctx.Passthrough,
+		rows:       append([]model.Row{}, pctx.StepLife.Rows...),
+		stops:      append([]model.Stop{}, pctx.StepLife.Stops...),
+	}, nil
+}
+
+// serialHooks 包装 hooks，使并发转换时的回调不会同时执行
+func serialHooks(hooks FileHooks) FileHooks {
+	var mu sync.Mutex
+	wrap := func(fn func(string)) func(string) {
+		if fn == nil {
+			return nil
+		}
+		return func(message string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fn(message)
+		}
+	}
+	serial := FileHooks{Warn: wrap(hooks.Warn), Info: wrap(hooks.Info)}
+	if hooks.Progress != nil {
+		serial.Progress = func(progress float64) {
+			mu.Lock()
+			defer mu.Unlock()
+			hooks.Progress(progress)
+		}
+	}
+	return serial
+}
+
+// connectorDuration 按连接方式估算两个文件之间移动 distance 米所需的时间（秒）
+func connectorDuration(mode string, distance float64) int64 {
+	speed := consts.ConnectorDriveSpeed
+	switch mode {
+	case consts.FillModeRail:
+		speed = consts.RailFillSpeed
+	case consts.FillModeFlight:
+		speed = consts.ConnectorFlightSpeed
+	}
+	return int64(distance / speed)
+}
+
+// connectorRows 生成前一个文件终点到后一个文件起点之间的连接段（不含两端的点）
+func connectorRows(before, after model.Row, config model.Config) ([]model.Row, error) {
+	return FillGap(Gap{
+		Before:   before,
+		After:    after,
+		Duration: after.DataTime - before.DataTime,
+		Distance: pointcalc.Distance(before.Point, after.Point),
+	}, config.BatchConnector, config)
+}
+
+// info 记录处理日志，设置了 hooks.Info 时同时通知调用方
+func info(hooks FileHooks, message string) {
+	logx.Info(message)
+	if hooks.Info != nil {
+		hooks.Info(message)
+	}
+}
+
+// warn 记录警告日志，设置了 hooks.Warn 时同时通知调用方
+func warn(hooks FileHooks, message string) {
+	logx.Info(message)
+	if hooks.Warn != nil {
+		hooks.Warn(message)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"steplife-universal-importer-gui/internal/model"
+	"strings"
+	"testing"
+)
+
+// writeCombineGPX 写出一个带时间的 GPX，start 为第一个点的时间
+func writeCombineGPX(t *testing.T, dir, name, start string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><gpx version="1.1"><trk><trkseg>`)
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&b, `<trkpt lat="30.%03d" lon="120.000"><time>%s:0%dZ</time></trkpt>`, i, start, i)
+	}
+	b.WriteString(`</trkseg></trk></gpx>`)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCombineFilesSkipsFailedFiles(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "b.gpx")
+	if err := os.WriteFile(broken, []byte("<gpx><trk>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []BatchFile{
+		{FilePath: writeCombineGPX(t, dir, "a.gpx", "2024-05-01T08:00")},
+		{FilePath: broken},
+		{FilePath: writeCombineGPX(t, dir, "c.gpx", "2024-05-01T09:00")},
+	}
+
+	var warnings []string
+	config := model.NewDefaultConfig()
+	config.EnableInsertPointStrategy = 0
+	result, err := CombineFiles(context.Background(), files, filepath.Join(dir, "out.csv"), config, FileHooks{
+		Warn: func(message string) { warnings = append(warnings, message) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 2 || result.TotalRows != 6 {
+		t.Errorf("Files = %d, TotalRows = %d, want 2, 6", result.Files, result.TotalRows)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].FilePath != broken || result.Failed[0].Index != 1 {
+		t.Errorf("Failed = %+v, want %s", result.Failed, broken)
+	}
+	if len(warnings) == 0 || !strings.Contains(warnings[len(warnings)-1], "b.gpx") {
+		t.Errorf("warnings = %q, want a warning for b.gpx", warnings)
+	}
+}
+
+func TestCombineFilesAllFailed(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "b.gpx")
+	if err := os.WriteFile(broken, []byte("<gpx><trk>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := CombineFiles(context.Background(), []BatchFile{{FilePath: broken}}, filepath.Join(dir, "out.csv"),
+		model.NewDefaultConfig(), FileHooks{})
+	if err == nil || !strings.Contains(err.Error(), "b.gpx") {
+		t.Errorf("err = %v, want the failure of b.gpx", err)
+	}
+}
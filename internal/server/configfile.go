@@ -0,0 +1,195 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strconv"
+
+	"gopkg.in/ini.v1"
+)
+
+// configMigrations 配置文件的迁移，键为迁移前的版本，依次作用于默认分区和每个命名配置分区，返回需要告知用户的改动
+var configMigrations = map[int]func(section *ini.Section) []string{
+	// 版本 1：enableBatchProcessing 没有作用，删除；插点距离没有校验，小于下限的值调整为下限
+	1: func(section *ini.Section) []string {
+		var notes []string
+		if section.HasKey("enableBatchProcessing") {
+			section.DeleteKey("enableBatchProcessing")
+			notes = append(notes, "删除不起作用的 enableBatchProcessing，合并为一个 CSV 请使用 combineOutput")
+		}
+		if section.HasKey("insertPointDistance") {
+			key := section.Key("insertPointDistance")
+			if distance, err := key.Int(); err == nil && distance < consts.MinInsertPointDistance {
+				key.SetValue(strconv.Itoa(consts.MinInsertPointDistance))
+				notes = append(notes, fmt.Sprintf("insertPointDistance 由 %d 调整为下限 %d", distance, consts.MinInsertPointDistance))
+			}
+		}
+		return notes
+	},
+	// 版本 2：enableBatchProcessing 表示合并为一个 CSV，改名为 combineOutput，保留原值
+	2: func(section *ini.Section) []string {
+		if !section.HasKey("enableBatchProcessing") {
+			return nil
+		}
+		value := section.Key("enableBatchProcessing").String()
+		section.DeleteKey("enableBatchProcessing")
+		if !section.HasKey("combineOutput") {
+			section.Key("combineOutput").SetValue(value)
+		}
+		return []string{fmt.Sprintf("enableBatchProcessing = %s 改名为 combineOutput", value)}
+	},
+}
+
+// ConfigFile 配置文件（config.ini）：默认分区为当前配置，其它分区为命名配置
+type ConfigFile struct {
+	Path     string
+	Config   model.Config // 当前配置
+	Profile  string       // 当前配置来自的命名配置，为空表示未选择
+	Profiles []Profile    // 按在文件中的顺序排列
+	Migrated bool         // 读取时从旧版本迁移，保存后才会写入文件
+	Notes    []string     // 迁移时对配置项的改动，供调用方提示用户
+}
+
+// DefaultConfigPath 配置文件的默认路径：环境变量 STEPLIFE_CONFIG 优先，其次为系统用户配置目录下的 steplife-importer/config.ini
+func DefaultConfigPath() string {
+	if path := os.Getenv(consts.ConfigPathEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		// 无法确定用户配置目录时使用工作目录
+		return consts.ConfigFileName
+	}
+	return filepath.Join(dir, consts.ConfigDirName, consts.ConfigFileName)
+}
+
+// LoadDefaultConfigFile
+//
+//	@Description: 	读取默认路径的配置文件；该路径还没有配置文件时读取工作目录下旧版本位置的 config.ini。
+//	                	从旧位置或旧版本读取的配置会立即写入默认路径，之后不再需要迁移
+//	@return *ConfigFile	出错时仍返回使用默认配置的文件，便于调用方继续使用
+//	@return error
+func LoadDefaultConfigFile() (*ConfigFile, error) {
+	path := DefaultConfigPath()
+	source := path
+	if _, err := os.Stat(path); os.IsNotExist(err) && os.Getenv(consts.ConfigPathEnv) == "" {
+		if _, err = os.Stat(consts.ConfigFileName); err == nil {
+			source = consts.ConfigFileName
+		}
+	}
+
+	file, err := LoadConfigFile(source)
+	file.Path = path
+	if err != nil || (source == path && !file.Migrated) {
+		return file, err
+	}
+	if err = file.Save(); err != nil {
+		logx.ErrorF("保存迁移后的配置文件失败：%v", err)
+		return file, nil
+	}
+	logx.InfoF("配置文件已迁移：%s -> %s", source, path)
+	return file, nil
+}
+
+// LoadConfigFile
+//
+//	@Description: 	读取配置文件并迁移旧版本，文件不存在时使用默认配置；文件中没有命名配置时提供内置的命名配置。
+//	                	配置项的取值不在这里校验，由使用配置的一方调用 model.Config.Validate
+//	@param path
+//	@return *ConfigFile	出错时仍返回使用默认配置的文件，便于调用方继续使用
+//	@return error		文件无法读取、版本高于程序支持的版本或配置项类型错误
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	file := &ConfigFile{Path: path, Config: model.NewDefaultConfig(), Profiles: BuiltinProfiles()}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		logx.Info("配置文件不存在，使用默认配置")
+		return file, nil
+	}
+
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return file, fmt.Errorf("读取配置文件失败：%w", err)
+	}
+	version, notes, err := migrateConfig(cfg)
+	if err != nil {
+		return file, fmt.Errorf("%s：%w", path, err)
+	}
+	config := model.NewDefaultConfig()
+	if err = cfg.Section("").MapTo(&config); err != nil {
+		return file, fmt.Errorf("加载配置失败：%w", err)
+	}
+	profiles, err := readProfiles(cfg)
+	if err != nil {
+		return file, err
+	}
+
+	file.Config = config
+	file.Profile = cfg.Section("").Key(consts.ProfileKey).String()
+	if len(profiles) > 0 {
+		file.Profiles = profiles
+	}
+	if version < consts.ConfigVersion {
+		file.Migrated = true
+		file.Notes = notes
+		logx.InfoF("配置文件 %s 从版本%d迁移到版本%d", path, version, consts.ConfigVersion)
+	}
+	return file, nil
+}
+
+// Save 保存当前配置和全部命名配置，目录不存在时创建
+func (this *ConfigFile) Save() error {
+	cfg := ini.Empty()
+	section := cfg.Section("")
+	section.Key(consts.ConfigVersionKey).SetValue(strconv.Itoa(consts.ConfigVersion))
+	if err := section.ReflectFrom(&this.Config); err != nil {
+		return err
+	}
+	if this.Profile != "" {
+		section.Key(consts.ProfileKey).SetValue(this.Profile)
+	}
+	if err := writeProfiles(cfg, this.Profiles); err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(this.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建配置目录失败：%w", err)
+		}
+	}
+	if err := cfg.SaveTo(this.Path); err != nil {
+		return err
+	}
+	this.Migrated = false
+	return nil
+}
+
+// migrateConfig 按默认分区中的版本依次执行迁移，返回迁移前的版本以及对配置项的改动（命名配置的改动带配置名）
+func migrateConfig(cfg *ini.File) (int, []string, error) {
+	version := 1
+	if key, err := cfg.Section("").GetKey(consts.ConfigVersionKey); err == nil {
+		if version, err = key.Int(); err != nil || version < 1 {
+			return 0, nil, fmt.Errorf("配置文件版本 %q 无效", key.String())
+		}
+	}
+	if version > consts.ConfigVersion {
+		return version, nil, fmt.Errorf("配置文件版本为%d，当前程序仅支持到版本%d，请升级程序", version, consts.ConfigVersion)
+	}
+
+	var notes []string
+	for v := version; v < consts.ConfigVersion; v++ {
+		for _, section := range cfg.Sections() {
+			for _, note := range configMigrations[v](section) {
+				if section.Name() != ini.DefaultSection {
+					note = fmt.Sprintf("命名配置「%s」：%s", section.Name(), note)
+				}
+				logx.InfoF("配置迁移：%s", note)
+				notes = append(notes, note)
+			}
+		}
+	}
+	cfg.Section("").Key(consts.ConfigVersionKey).SetValue(strconv.Itoa(consts.ConfigVersion))
+	return version, notes, nil
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigFileMigration(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Content       string
+		CombineOutput int
+		Distance      int
+		Notes         int
+	}{
+		{
+			"版本1删除不起作用的键",
+			"insertPointDistance = 10\nenableBatchProcessing = 1\n",
+			0, 30, 2,
+		},
+		{
+			"版本2改名并保留原值",
+			"configVersion = 2\ninsertPointDistance = 100\nenableBatchProcessing = 1\n",
+			1, 100, 1,
+		},
+		{
+			"命名配置同样迁移",
+			"configVersion = 2\n[骑行]\nenableBatchProcessing = 1\n",
+			0, 100, 1,
+		},
+		{
+			"当前版本不迁移",
+			"configVersion = 3\ncombineOutput = 1\n",
+			1, 100, 0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.ini")
+			if err := os.WriteFile(path, []byte(c.Content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := LoadConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Config.CombineOutput != c.CombineOutput {
+				t.Errorf("CombineOutput = %d, want %d", file.Config.CombineOutput, c.CombineOutput)
+			}
+			if file.Config.InsertPointDistance != c.Distance {
+				t.Errorf("InsertPointDistance = %d, want %d", file.Config.InsertPointDistance, c.Distance)
+			}
+			if len(file.Notes) != c.Notes {
+				t.Errorf("Notes = %q, want %d notes", file.Notes, c.Notes)
+			}
+			if file.Migrated != (c.Notes > 0) {
+				t.Errorf("Migrated = %v", file.Migrated)
+			}
+		})
+	}
+}
@@ -1,16 +1,18 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"math"
-	"path"
+	"os"
+	"path/filepath"
+	"sort"
 	consts "steplife-universal-importer-gui/internal/const"
 	"steplife-universal-importer-gui/internal/model"
-	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/pipeline"
 	"steplife-universal-importer-gui/internal/utils"
 	"steplife-universal-importer-gui/internal/utils/logx"
-	"steplife-universal-importer-gui/internal/utils/pointcalc"
-	"time"
+	"strings"
 )
 
 // Run
@@ -49,306 +51,92 @@ func Run(config model.Config) error {
 		csvFilePath = "./output.csv"
 	}
 
-	// 收集所有数据
-	sl := model.NewStepLife()
-	allData := append([][]string{}, sl.CSVHeader...) // 先添加文件头
-
+	// 所有文件按时间顺序合并为一个 CSV，时间重叠的文件整体平移
+	var filePaths []string
 	for fileType, paths := range filePathMap {
-		for i, filePath := range paths {
-			logx.InfoF("处理第%d个文件（%s）", i, filePath)
-
-			sl, err := parseOne(fileType, filePath, config)
-			if err != nil {
-				logx.ErrorF("处理第%d个文件（%s）失败：%s", i, filePath, err)
-				return err
-			}
-
-			// 收集数据，不立即写入
-			allData = append(allData, sl.CSVData...)
-
-			// 更新起始时间戳
-			config.PathStartTimestamp += int64(len(sl.CSVData))
+		if err = checkFileType(fileType); err != nil {
+			return err
 		}
+		filePaths = append(filePaths, paths...)
 	}
+	sort.Strings(filePaths)
 
-	// 一次性写入所有数据（覆盖模式）
-	err = utils.WriteCSV(csvFilePath, allData)
-	if err != nil {
-		logx.ErrorF("写入CSV文件失败：%s", csvFilePath)
-		return err
+	// 同名配置文件或文件名中的时间优先于全局配置
+	files := make([]BatchFile, len(filePaths))
+	for i, filePath := range filePaths {
+		if files[i], err = NewBatchFile(filePath, "", config, nil); err != nil {
+			return err
+		}
 	}
-
-	return nil
+	_, err = CombineFiles(context.Background(), files, csvFilePath, config, FileHooks{})
+	return err
 }
 
-// ProcessSingleFile 处理单个文件
-func ProcessSingleFile(fileType, filePath, csvFilePath string, config model.Config) error {
-	logx.InfoF("处理文件：%s", filePath)
-
-	sl, err := processOneFile(fileType, filePath, config)
-	if err != nil {
-		logx.ErrorF("处理文件失败：%s", filePath)
-		return err
-	}
-
-	// 合并文件头和数据，直接覆盖写入
-	allRows := append(append([][]string{}, sl.CSVHeader...), sl.CSVData...)
-	err = utils.WriteCSV(csvFilePath, allRows)
-	if err != nil {
-		logx.ErrorF("写入CSV文件失败：%s", csvFilePath)
-		return err
-	}
-
-	return nil
+// FileHooks 处理单个文件时的回调，均可为 nil
+type FileHooks struct {
+	Progress func(float64) // 该文件的处理进度（0~1）
+	Warn     func(string)  // 处理过程中的警告
+	Info     func(string)  // 各阶段的处理日志
 }
 
-func processOneFile(fileType, filePath string, config model.Config) (*model.StepLife, error) {
-	var adaptor parser.FileAdaptor
-
-	switch fileType {
-	case consts.FileTypeCommon:
-		adaptor = parser.CreateAdaptor(path.Ext(filePath))
-	case consts.FileTypeVariFlight:
-		logx.ErrorF("飞常准数据暂不支持......")
-		return nil, nil
-	default:
-		logx.ErrorF("不支持的文件类型：%s", fileType)
-		return nil, fmt.Errorf("不支持的文件类型：%s", fileType)
-	}
-
-	if adaptor == nil {
-		return nil, fmt.Errorf("不支持的结构解析（%s）", fileType)
-	}
-
-	content, err := utils.ReadFile(filePath)
-	if err != nil {
-		logx.ErrorF("读取文件失败：%s", filePath)
-		return nil, err
-	}
-
-	latLngData, err := adaptor.Parse(content)
-	if err != nil {
-		logx.ErrorF("解析文件失败：%s", filePath)
-		return nil, err
-	}
-
-	sl, err := convertToStepLifeWithAdvancedOptions(config, latLngData)
-	if err != nil {
-		logx.ErrorF("转换文件失败：%s", filePath)
-		return nil, err
-	}
-
-	return sl, nil
-}
-
-func convertToStepLifeWithAdvancedOptions(config model.Config, points []model.Point) (*model.StepLife, error) {
-	sl := model.NewStepLife()
-	logx.Info("处理经纬度坐标（高级模式）")
-
-	startTimestamp := config.PathStartTimestamp
-	if startTimestamp == 0 {
-		startTimestamp = time.Now().Unix()
-	}
-
-	// 如果开始时间大于结束时间，反转轨迹点顺序并交换时间戳
-	if config.PathEndTimestamp > 0 && startTimestamp > config.PathEndTimestamp {
-		logx.Info("检测到开始时间大于结束时间，自动反转轨迹顺序")
-		// 反转点数组
-		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
-			points[i], points[j] = points[j], points[i]
+// context 构造处理该文件的流水线上下文，进度按已完成的阶段数（流式转换时按已读取的字节数）换算
+func (this FileHooks) context(config model.Config, filePath string) *pipeline.Context {
+	pctx := &pipeline.Context{Config: config, FilePath: filePath, Warn: this.Warn, Info: this.Info}
+	if this.Progress != nil {
+		pctx.Progress = func(done, total int) {
+			this.Progress(float64(done) / float64(total))
 		}
-		// 交换时间戳
-		startTimestamp, config.PathEndTimestamp = config.PathEndTimestamp, startTimestamp
 	}
+	return pctx
+}
 
-	// 如果设置了结束时间，需要先计算总点数（包括插值点）以正确计算时间间隔
-	var totalPoints int64 = int64(len(points))
-	if config.EnableInsertPointStrategy == 1 {
-		// 计算插值后的总点数
-		totalPoints = 1 // 第一个点
-		for i := 1; i < len(points); i++ {
-			interpolatedPoints := pointcalc.Calculate(points[i-1], points[i], config.InsertPointDistance)
-			totalPoints += int64(len(interpolatedPoints))
-		}
-	}
+// ProcessFile
+//
+//	@Description: 		处理单个文件，可取消
+//	@param ctx			取消时在阶段之间（流式转换时每处理一批行）停止，返回 ctx.Err()
+//	@param fileType
+//	@param filePath
+//	@param csvFilePath
+//	@param config
+//	@param hooks		进度及警告回调
+//	@return error
+func ProcessFile(ctx context.Context, fileType, filePath, csvFilePath string, config model.Config, hooks FileHooks) error {
+	logx.InfoF("处理文件：%s", filePath)
 
-	// 计算时间间隔
-	// 优先级：结束时间 > 用户指定的时间间隔 > 统一为开始时间
-	var timeInterval int64 = 0
-	useEndTime := config.PathEndTimestamp > 0 && startTimestamp > 0 && totalPoints > 1
-	useTimeInterval := config.TimeInterval != 0 && startTimestamp > 0 && totalPoints > 1
-	
-	if useEndTime {
-		// 如果设置了结束时间，计算均匀分配的时间间隔
-		totalDuration := config.PathEndTimestamp - startTimestamp
-		timeInterval = totalDuration / (totalPoints - 1)
-		if timeInterval < 1 {
-			timeInterval = 1
-		}
-	} else if useTimeInterval {
-		// 如果用户指定了时间间隔，使用指定的间隔
-		timeInterval = config.TimeInterval
+	if err := checkFileType(fileType); err != nil {
+		return err
 	}
 
-	// 使用点索引来计算时间戳，确保最后一个点的时间戳等于结束时间
-	pointIndex := int64(0)
-
-	for i, point := range points {
-		// 第0个坐标或者不需要插入值，不需要计算中间点，直接写入
-		if i == 0 || config.EnableInsertPointStrategy == 0 {
-			// 计算当前点的时间戳
-			var currentTimestamp int64
-			if useEndTime {
-				currentTimestamp = startTimestamp + pointIndex*timeInterval
-				// 如果是最后一个点，使用精确的结束时间
-				if i == len(points)-1 {
-					currentTimestamp = config.PathEndTimestamp
-				}
-			} else if useTimeInterval {
-				// 如果设置了时间间隔，按照间隔递增
-				currentTimestamp = startTimestamp + pointIndex*timeInterval
-			} else {
-				// 如果都没有设置，所有时间统一为开始时间
-				currentTimestamp = startTimestamp
-			}
-
-			row := model.NewRow()
-			row.DataTime = currentTimestamp
-			row.Altitude = config.DefaultAltitude         // 使用配置的海拔高度
-			row.Speed = calculateSpeed(config, points, i) // 计算速度
-			row.Latitude = point.Latitude
-			row.Longitude = point.Longitude
-			sl.AddCSVRow(*row)
-			pointIndex++
-		} else {
-			interpolatedPoints := pointcalc.Calculate(points[i-1], point, config.InsertPointDistance)
-			for j, interpolatedPoint := range interpolatedPoints {
-				// 计算当前点的时间戳
-				var currentTimestamp int64
-				if useEndTime {
-					currentTimestamp = startTimestamp + pointIndex*timeInterval
-					// 如果是最后一个点，使用精确的结束时间
-					if i == len(points)-1 && j == len(interpolatedPoints)-1 {
-						currentTimestamp = config.PathEndTimestamp
-					}
-				} else if useTimeInterval {
-					// 如果设置了时间间隔，按照间隔递增
-					currentTimestamp = startTimestamp + pointIndex*timeInterval
-				} else {
-					// 如果都没有设置，所有时间统一为开始时间
-					currentTimestamp = startTimestamp
-				}
-
-				row := model.NewRow()
-				row.Point = interpolatedPoint
-				row.DataTime = currentTimestamp
-				row.Altitude = config.DefaultAltitude
-				row.Speed = calculateSpeed(config, points, i)
-				sl.AddCSVRow(*row)
-				pointIndex++
-			}
+	// 大文件在配置允许时流式转换，内存占用与文件大小无关
+	if info, err := os.Stat(filePath); err == nil && info.Size() >= consts.StreamFileSize {
+		err = pipeline.StreamFile(ctx, hooks.context(config, filePath), csvFilePath)
+		if err == nil || !errors.Is(err, pipeline.ErrNotStreamable) {
+			return err
 		}
+		logx.InfoF("%s，使用完整流水线转换", err)
 	}
 
-	// 确保最后一个点的时间戳等于结束时间（如果设置了结束时间）
-	if useEndTime && len(sl.CSVData) > 0 {
-		// CSVData 的第一个元素是 DataTime
-		sl.CSVData[len(sl.CSVData)-1][0] = fmt.Sprintf("%d", config.PathEndTimestamp)
-	}
-
-	logx.InfoF("处理经纬度完成，原始坐标%d个，插点后坐标%d个", len(points), len(sl.CSVData))
-	return sl, nil
-}
-
-// calculateSpeed 计算速度
-func calculateSpeed(config model.Config, points []model.Point, currentIndex int) float64 {
-	if config.SpeedMode == "manual" {
-		return config.ManualSpeed
-	}
-
-	// 自动计算速度
-	if currentIndex == 0 || currentIndex >= len(points) {
-		return 0.0
-	}
-
-	// 计算两点间的距离和时间差来估算速度
-	prevPoint := points[currentIndex-1]
-	currPoint := points[currentIndex]
-
-	// 使用Haversine公式计算距离（米）
-	distance := calculateHaversineDistance(prevPoint.Latitude, prevPoint.Longitude, currPoint.Latitude, currPoint.Longitude)
-
-	// 估算时间差（假设平均速度）
-	estimatedTimeDiff := 1.0 // 1秒
-	if distance > 0 {
-		// 假设平均步行速度为1.5 m/s，计算合理的时间差
-		estimatedTimeDiff = distance / 1.5
-		if estimatedTimeDiff < 1 {
-			estimatedTimeDiff = 1
+	trackName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	pctx := hooks.context(config, filePath)
+	if err := pipeline.Default().Append(pipeline.NewWriteStage(csvFilePath, trackName)).RunContext(ctx, pctx); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			logx.ErrorF("处理文件失败：%s", filePath)
 		}
+		return err
 	}
-
-	return distance / estimatedTimeDiff
-}
-
-// calculateHaversineDistance 计算两点间的球面距离（米）
-func calculateHaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadius = 6371000.0 // 地球半径（米）
-
-	// 转换为弧度
-	lat1Rad := lat1 * math.Pi / 180
-	lon1Rad := lon1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	lon2Rad := lon2 * math.Pi / 180
-
-	deltaLat := lat2Rad - lat1Rad
-	deltaLon := lon2Rad - lon1Rad
-
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadius * c
+	return nil
 }
 
-func parseOne(fileType, filePath string, config model.Config) (*model.StepLife, error) {
-
-	var adaptor parser.FileAdaptor
-
+// checkFileType 检查数据类型，目前只支持通用轨迹文件
+func checkFileType(fileType string) error {
 	switch fileType {
 	case consts.FileTypeCommon:
-		adaptor = parser.CreateAdaptor(path.Ext(filePath))
+		return nil
 	case consts.FileTypeVariFlight:
-		// TODO
-		logx.ErrorF("飞常准数据后续支持......")
-		return nil, nil
+		logx.ErrorF("飞常准数据暂不支持......")
+		return fmt.Errorf("飞常准数据暂不支持")
 	default:
 		logx.ErrorF("不支持的文件类型：%s", fileType)
-		return nil, fmt.Errorf("不支持的文件类型：%s", fileType)
-	}
-
-	if adaptor == nil {
-		return nil, fmt.Errorf("不支持的结构解析（%s）", fileType)
-	}
-
-	content, err := utils.ReadFile(filePath)
-	if err != nil {
-		logx.ErrorF("读取文件失败：%s", filePath)
-		return nil, err
+		return fmt.Errorf("不支持的文件类型：%s", fileType)
 	}
-
-	latLngData, err := adaptor.Parse(content)
-	if err != nil {
-		logx.ErrorF("解析文件失败：%s", filePath)
-		return nil, err
-	}
-
-	sl, err := adaptor.Convert2StepLife(config, latLngData)
-	if err != nil {
-		logx.ErrorF("转换文件失败：%s", filePath)
-		return nil, err
-	}
-
-	return sl, nil
 }
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// fileNameTimePattern 文件名开头的日期和时间段：2024-05-01_0800-1230_xxx.kml、20240501_080000.gpx，结束时间可省略
+var fileNameTimePattern = regexp.MustCompile(`^(\d{4})-?(\d{2})-?(\d{2})[_ ](\d{2})(\d{2})(\d{2})?(?:-(\d{2})(\d{2})(\d{2})?)?(?:[_ .]|$)`)
+
+// sidecarExtensions 同名配置文件的扩展名，追加在轨迹文件名之后，如 trip.kml.ini
+var sidecarExtensions = []string{".ini", ".json"}
+
+// fileTimeSourceNames 文件时间设置来源的显示名称
+var fileTimeSourceNames = map[string]string{
+	consts.FileTimeSidecar:  "同名配置文件",
+	consts.FileTimeFileName: "文件名",
+	consts.FileTimeManual:   "手动设置",
+}
+
+// FileTime 单个文件的时间设置，空字段使用全局配置；设置了开始时间时结束时间也以此为准
+type FileTime struct {
+	PathStartTime string `ini:"pathStartTime" json:"pathStartTime,omitempty"`
+	PathEndTime   string `ini:"pathEndTime" json:"pathEndTime,omitempty"`
+	TimeInterval  int64  `ini:"timeInterval" json:"timeInterval,omitempty"`
+	Timezone      string `ini:"timezone" json:"timezone,omitempty"`
+	Source        string `ini:"-" json:"-"` // 设置的来源：sidecar、filename、manual
+}
+
+// IsZero 没有任何设置
+func (this FileTime) IsZero() bool {
+	return this.PathStartTime == "" && this.PathEndTime == "" && this.TimeInterval == 0 && this.Timezone == ""
+}
+
+// SourceName 设置来源的显示名称
+func (this FileTime) SourceName() string {
+	return fileTimeSourceNames[this.Source]
+}
+
+// String 设置的可读描述，用于日志
+func (this FileTime) String() string {
+	var parts []string
+	if this.PathStartTime != "" {
+		parts = append(parts, "开始 "+this.PathStartTime)
+	}
+	if this.PathEndTime != "" {
+		parts = append(parts, "结束 "+this.PathEndTime)
+	}
+	if this.TimeInterval != 0 {
+		parts = append(parts, fmt.Sprintf("间隔 %d 秒", this.TimeInterval))
+	}
+	if this.Timezone != "" {
+		parts = append(parts, "时区 "+this.Timezone)
+	}
+	return strings.Join(parts, "，")
+}
+
+// DetectFileTime
+//
+//	@Description: 		查找文件单独的时间设置：先找同名配置文件（trip.kml.ini、trip.kml.json），再解析文件名中的日期和时间段
+//	@param filePath
+//	@return FileTime	没有找到时返回零值
+//	@return error		同名配置文件格式错误
+func DetectFileTime(filePath string) (FileTime, error) {
+	for _, ext := range sidecarExtensions {
+		sidecarPath := filePath + ext
+		if _, err := os.Stat(sidecarPath); err != nil {
+			continue
+		}
+		fileTime, err := loadSidecar(sidecarPath)
+		if err != nil {
+			return FileTime{}, fmt.Errorf("读取 %s 失败：%w", filepath.Base(sidecarPath), err)
+		}
+		fileTime.Source = consts.FileTimeSidecar
+		return fileTime, nil
+	}
+
+	if fileTime, ok := ParseFileNameTime(filepath.Base(filePath)); ok {
+		return fileTime, nil
+	}
+	return FileTime{}, nil
+}
+
+// loadSidecar 读取同名配置文件，键名与 config.ini 相同
+func loadSidecar(sidecarPath string) (FileTime, error) {
+	var fileTime FileTime
+	if strings.EqualFold(filepath.Ext(sidecarPath), ".json") {
+		content, err := os.ReadFile(sidecarPath)
+		if err != nil {
+			return fileTime, err
+		}
+		err = json.Unmarshal(content, &fileTime)
+		return fileTime, err
+	}
+
+	cfg, err := ini.Load(sidecarPath)
+	if err != nil {
+		return fileTime, err
+	}
+	err = cfg.Section("").MapTo(&fileTime)
+	return fileTime, err
+}
+
+// ParseFileNameTime
+//
+//	@Description: 		解析文件名开头的日期和时间段，如 2024-05-01_0800-1230_xxx.kml，结束时间早于开始时间时视为次日
+//	@param fileName
+//	@return FileTime
+//	@return bool		文件名不符合格式或日期无效时返回 false
+func ParseFileNameTime(fileName string) (FileTime, bool) {
+	m := fileNameTimePattern.FindStringSubmatch(fileName)
+	if m == nil {
+		return FileTime{}, false
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	date := fmt.Sprintf("%s-%s-%s", m[1], m[2], m[3])
+	start, err := time.Parse(layout, fmt.Sprintf("%s %s:%s:%s", date, m[4], m[5], seconds(m[6])))
+	if err != nil {
+		return FileTime{}, false
+	}
+	fileTime := FileTime{PathStartTime: start.Format(layout), Source: consts.FileTimeFileName}
+
+	if m[7] != "" {
+		end, err := time.Parse(layout, fmt.Sprintf("%s %s:%s:%s", date, m[7], m[8], seconds(m[9])))
+		if err != nil {
+			return FileTime{}, false
+		}
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		fileTime.PathEndTime = end.Format(layout)
+	}
+	return fileTime, true
+}
+
+func seconds(s string) string {
+	if s == "" {
+		return "00"
+	}
+	return s
+}
+
+// ApplyFileTime
+//
+//	@Description: 		将文件单独的时间设置覆盖到全局配置上，并重新解析开始、结束时间戳
+//	@param config		全局配置
+//	@param fileTime
+//	@return model.Config
+//	@return error		时间或时区格式错误
+func ApplyFileTime(config model.Config, fileTime FileTime) (model.Config, error) {
+	if fileTime.IsZero() {
+		return config, nil
+	}
+	if fileTime.PathStartTime != "" {
+		config.PathStartTime = fileTime.PathStartTime
+		config.PathEndTime = fileTime.PathEndTime
+	}
+	if fileTime.TimeInterval != 0 {
+		config.TimeInterval = fileTime.TimeInterval
+	}
+	if fileTime.Timezone != "" {
+		config.Timezone = fileTime.Timezone
+	}
+	if err := config.Validate(); err != nil {
+		return config, err
+	}
+	if err := ResolveTimestamps(&config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// FileConfig
+//
+//	@Description: 		文件使用的配置：manual 中有该文件的设置时优先，其次同名配置文件、文件名，都没有时使用全局配置
+//	@param filePath
+//	@param config		全局配置
+//	@param manual		按文件路径填写的时间设置，可为 nil
+//	@return model.Config
+//	@return FileTime	生效的文件时间设置，使用全局配置时为零值
+//	@return error
+func FileConfig(filePath string, config model.Config, manual map[string]FileTime) (model.Config, FileTime, error) {
+	fileTime, ok := manual[filePath]
+	if ok && !fileTime.IsZero() {
+		fileTime.Source = consts.FileTimeManual
+	} else {
+		var err error
+		if fileTime, err = DetectFileTime(filePath); err != nil {
+			return config, FileTime{}, err
+		}
+	}
+
+	fileConfig, err := ApplyFileTime(config, fileTime)
+	if err != nil {
+		return config, FileTime{}, fmt.Errorf("%s 的时间设置错误：%w", filepath.Base(filePath), err)
+	}
+	return fileConfig, fileTime, nil
+}
+
+// ResolveTimestamps 将配置中的时间字符串解析为时间戳，未设置开始时间时使用当前时间
+func ResolveTimestamps(config *model.Config) error {
+	config.PathStartTimestamp = time.Now().Unix()
+	if config.PathStartTime != "" {
+		timestamp, err := timeUtils.ToTimestampWithTimezone(config.PathStartTime, config.Timezone)
+		if err != nil {
+			return fmt.Errorf("开始时间格式错误：%w", err)
+		}
+		config.PathStartTimestamp = timestamp
+	}
+
+	config.PathEndTimestamp = 0
+	if config.PathEndTime != "" {
+		timestamp, err := timeUtils.ToTimestampWithTimezone(config.PathEndTime, config.Timezone)
+		if err != nil {
+			return fmt.Errorf("结束时间格式错误：%w", err)
+		}
+		config.PathEndTimestamp = timestamp
+	}
+	return nil
+}
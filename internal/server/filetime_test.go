@@ -0,0 +1,141 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+func TestParseFileNameTime(t *testing.T) {
+	cases := []struct {
+		FileName string
+		OK       bool
+		Start    string
+		End      string
+	}{
+		{"2024-05-01_0800-1230.kml", true, "2024-05-01 08:00:00", "2024-05-01 12:30:00"},
+		{"2024-05-01_0800-1230_骑行.kml", true, "2024-05-01 08:00:00", "2024-05-01 12:30:00"},
+		{"20240501_080000.gpx", true, "2024-05-01 08:00:00", ""},
+		{"20240501_080000-093015.gpx", true, "2024-05-01 08:00:00", "2024-05-01 09:30:15"},
+		{"2024-05-01 0800", true, "2024-05-01 08:00:00", ""},
+		{"2024-05-01_2300-0130.kml", true, "2024-05-01 23:00:00", "2024-05-02 01:30:00"},
+		{"2024-05-01.kml", false, "", ""},
+		{"2024-13-01_0800.kml", false, "", ""},
+		{"2024-05-01_0800x.kml", false, "", ""},
+		{"trip_2024-05-01_0800.kml", false, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.FileName, func(t *testing.T) {
+			fileTime, ok := ParseFileNameTime(c.FileName)
+			if ok != c.OK {
+				t.Fatalf("ok = %v, want %v", ok, c.OK)
+			}
+			if fileTime.PathStartTime != c.Start || fileTime.PathEndTime != c.End {
+				t.Errorf("time = %q - %q, want %q - %q", fileTime.PathStartTime, fileTime.PathEndTime, c.Start, c.End)
+			}
+			if ok && fileTime.Source != consts.FileTimeFileName {
+				t.Errorf("Source = %q", fileTime.Source)
+			}
+		})
+	}
+}
+
+func TestDetectFileTime(t *testing.T) {
+	cases := []struct {
+		Name     string
+		FileName string
+		Sidecars map[string]string // 扩展名 → 内容
+		Source   string
+		Start    string
+		Interval int64
+		Err      bool
+	}{
+		{"ini同名配置", "2024-05-01_0800.kml", map[string]string{".ini": "pathStartTime = 2024-06-01 09:00:00\ntimeInterval = 5\n"},
+			consts.FileTimeSidecar, "2024-06-01 09:00:00", 5, false},
+		{"json同名配置", "trip.kml", map[string]string{".json": `{"pathStartTime": "2024-06-01 09:00:00"}`},
+			consts.FileTimeSidecar, "2024-06-01 09:00:00", 0, false},
+		{"ini优先于json", "trip.kml", map[string]string{".ini": "timeInterval = 5\n", ".json": `{"timeInterval": 10}`},
+			consts.FileTimeSidecar, "", 5, false},
+		{"没有同名配置时解析文件名", "2024-05-01_0800.kml", nil,
+			consts.FileTimeFileName, "2024-05-01 08:00:00", 0, false},
+		{"都没有", "trip.kml", nil, "", "", 0, false},
+		{"同名配置格式错误", "trip.kml", map[string]string{".json": `{"timeInterval": "5"`}, "", "", 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), c.FileName)
+			for ext, content := range c.Sidecars {
+				if err := os.WriteFile(filePath+ext, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fileTime, err := DetectFileTime(filePath)
+			if (err != nil) != c.Err {
+				t.Fatalf("err = %v, want error %v", err, c.Err)
+			}
+			if fileTime.Source != c.Source || fileTime.PathStartTime != c.Start || fileTime.TimeInterval != c.Interval {
+				t.Errorf("DetectFileTime = %+v, want source %q, start %q, interval %d", fileTime, c.Source, c.Start, c.Interval)
+			}
+		})
+	}
+}
+
+func TestFileConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	withSidecar := filepath.Join(dir, "2024-05-01_0800-1230.kml")
+	if err := os.WriteFile(withSidecar+".ini", []byte("pathStartTime = 2024-06-01 09:00:00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fromName := filepath.Join(dir, "2024-05-01_0800-1230_骑行.kml")
+	plain := filepath.Join(dir, "trip.kml")
+
+	config := model.NewDefaultConfig()
+	config.Timezone = "Asia/Shanghai"
+	config.PathStartTime = "2024-01-01 00:00:00"
+	manual := map[string]FileTime{
+		withSidecar: {PathStartTime: "2024-07-01 10:00:00", PathEndTime: "2024-07-01 11:00:00"},
+		plain:       {},
+	}
+
+	cases := []struct {
+		Name      string
+		FilePath  string
+		Source    string
+		Start     string
+		Timestamp int64
+	}{
+		{"手动设置优先于同名配置", withSidecar, consts.FileTimeManual, "2024-07-01 10:00:00", 1719799200},
+		{"没有同名配置时使用文件名", fromName, consts.FileTimeFileName, "2024-05-01 08:00:00", 1714521600},
+		{"空的手动设置使用全局配置", plain, "", "2024-01-01 00:00:00", 1704038400},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			fileConfig, fileTime, err := FileConfig(c.FilePath, config, manual)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fileTime.Source != c.Source {
+				t.Errorf("Source = %q, want %q", fileTime.Source, c.Source)
+			}
+			if fileConfig.PathStartTime != c.Start {
+				t.Errorf("PathStartTime = %q, want %q", fileConfig.PathStartTime, c.Start)
+			}
+			if c.Source != "" && fileConfig.PathStartTimestamp != c.Timestamp {
+				t.Errorf("PathStartTimestamp = %d, want %d", fileConfig.PathStartTimestamp, c.Timestamp)
+			}
+		})
+	}
+
+	// 没有手动设置时同名配置优先于文件名
+	delete(manual, withSidecar)
+	fileConfig, fileTime, err := FileConfig(withSidecar, config, manual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileTime.Source != consts.FileTimeSidecar || fileConfig.PathStartTime != "2024-06-01 09:00:00" || fileConfig.PathEndTime != "" {
+		t.Errorf("FileConfig = %+v, start %q, end %q", fileTime, fileConfig.PathStartTime, fileConfig.PathEndTime)
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/parser"
+	"steplife-universal-importer-gui/internal/pipeline"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"steplife-universal-importer-gui/internal/utils/timezone"
+	"time"
+)
+
+// Gap 已有足迹中相邻两行之间的缺口
+type Gap struct {
+	Index    int       // 缺口序号，从 1 开始
+	Before   model.Row // 缺口前的最后一个点
+	After    model.Row // 缺口后的第一个点
+	Duration int64     // 时间跨度（秒）
+	Distance float64   // 球面距离（米）
+}
+
+// GapFill 需要补全的缺口及补全方式
+type GapFill struct {
+	Gap  Gap
+	Mode string
+}
+
+// Describe 缺口的可读描述，包含前后位置和时间；时间按配置的时区显示，自动推断时按各自的坐标推断
+func (this Gap) Describe(zone string) string {
+	before := timezone.LocationAt(zone, this.Before.Latitude, this.Before.Longitude)
+	after := timezone.LocationAt(zone, this.After.Latitude, this.After.Longitude)
+	return fmt.Sprintf("#%d %s (%.6f, %.6f) → %s (%.6f, %.6f)，间隔 %s，距离 %.1f 公里",
+		this.Index,
+		time.Unix(this.Before.DataTime, 0).In(before).Format("2006-01-02 15:04:05"), this.Before.Latitude, this.Before.Longitude,
+		time.Unix(this.After.DataTime, 0).In(after).Format("2006-01-02 15:04:05"), this.After.Latitude, this.After.Longitude,
+		time.Duration(this.Duration)*time.Second, this.Distance/1000)
+}
+
+// LoadStepLifeRows
+//
+//	@Description: 	读取一生足迹 CSV 并按时间排序
+//	@param filePath
+//	@return []model.Row
+//	@return error
+func LoadStepLifeRows(filePath string) ([]model.Row, error) {
+	content, err := utils.ReadFile(filePath)
+	if err != nil {
+		logx.ErrorF("读取文件失败：%s", filePath)
+		return nil, err
+	}
+	rows, err := parser.NewStepLifeAdaptor().ParseRows(content)
+	if err != nil {
+		logx.ErrorF("解析文件失败：%s", filePath)
+		return nil, err
+	}
+	sortRows(rows)
+	return rows, nil
+}
+
+// FindGaps
+//
+//	@Description: 		查找相邻两行之间时间或距离跳变超过阈值的缺口
+//	@param rows			按时间排序的轨迹行
+//	@param minDuration	时间阈值（秒），<= 0 时使用默认值
+//	@param minDistance	距离阈值（米），<= 0 时使用默认值
+//	@return []Gap
+func FindGaps(rows []model.Row, minDuration int64, minDistance float64) []Gap {
+	if minDuration <= 0 {
+		minDuration = consts.DefaultGapMinDuration
+	}
+	if minDistance <= 0 {
+		minDistance = consts.DefaultGapMinDistance
+	}
+
+	var gaps []Gap
+	for i := 1; i < len(rows); i++ {
+		duration := rows[i].DataTime - rows[i-1].DataTime
+		distance := pointcalc.Distance(rows[i-1].Point, rows[i].Point)
+		if duration < minDuration && distance < minDistance {
+			continue
+		}
+		gaps = append(gaps, Gap{
+			Index:    len(gaps) + 1,
+			Before:   rows[i-1],
+			After:    rows[i],
+			Duration: duration,
+			Distance: distance,
+		})
+	}
+	logx.InfoF("共%d行，找到%d个缺口", len(rows), len(gaps))
+	return gaps
+}
+
+// FillGap
+//
+//	@Description: 	生成缺口前后两点之间的补全轨迹（不含前后两点本身），时间严格递增，补点数不超过移动耗时的秒数；
+//	              	速度、方向和距离由流水线的属性阶段计算
+//	@param gap
+//	@param mode		补全方式：straight、greatcircle、flight、rail
+//	@param config	补点间距为 InsertPointDistance，速度、距离按配置计算
+//	@return []model.Row
+//	@return error
+func FillGap(gap Gap, mode string, config model.Config) ([]model.Row, error) {
+	spacing := config.InsertPointDistance
+	if spacing <= 0 {
+		spacing = consts.DefaultInsertPointDistance
+	}
+	numPoints := int(gap.Distance / float64(spacing))
+	if numPoints == 0 || gap.Duration <= 0 {
+		return nil, nil
+	}
+
+	// 移动耗时，默认占满整个缺口；高铁按固定速度行驶，提前到达后停留在终点
+	moveDuration := float64(gap.Duration)
+	switch mode {
+	case consts.FillModeStraight, consts.FillModeGreatCircle, consts.FillModeFlight:
+	case consts.FillModeRail:
+		moveDuration = math.Min(moveDuration, math.Max(1, gap.Distance/consts.RailFillSpeed))
+	default:
+		return nil, fmt.Errorf("不支持的补全方式：%s", mode)
+	}
+	// 相邻点至少间隔 1 秒，否则时间会重复
+	numPoints = min(numPoints, int(moveDuration)-1)
+	if numPoints <= 0 {
+		return nil, nil
+	}
+
+	// 连同前后两点一起计算属性，使首尾补点的距离和方向与前后两点衔接
+	points := make([]model.Point, 0, numPoints+2)
+	points = append(points, gap.Before.Point)
+	for i := 1; i <= numPoints; i++ {
+		fraction := float64(i) / float64(numPoints+1)
+
+		point := model.Point{
+			DataTime: gap.Before.DataTime + int64(fraction*moveDuration),
+			Altitude: gap.Before.Altitude + fraction*(gap.After.Altitude-gap.Before.Altitude),
+		}
+		if mode == consts.FillModeStraight {
+			point.Latitude = gap.Before.Latitude + fraction*(gap.After.Latitude-gap.Before.Latitude)
+			point.Longitude = gap.Before.Longitude + fraction*(gap.After.Longitude-gap.Before.Longitude)
+		} else {
+			point.Latitude, point.Longitude = pointcalc.GreatCircleFraction(gap.Before.Point, gap.After.Point, fraction)
+		}
+		if mode == consts.FillModeFlight {
+			point.Altitude = pointcalc.FlightAltitude(point.Altitude, fraction, gap.Distance)
+		}
+		points = append(points, point)
+	}
+	after := gap.After.Point
+	after.SegmentStart = false
+	points = append(points, after)
+
+	pctx := &pipeline.Context{Config: config, Points: points, SourceTime: true}
+	if err := pipeline.NewAttributesStage().Process(pctx); err != nil {
+		return nil, err
+	}
+	return pctx.StepLife.Rows[1 : numPoints+1], nil
+}
+
+// FillGaps
+//
+//	@Description: 		按选择的缺口和方式生成补全轨迹，仅输出补全的行，便于直接导入一生足迹
+//	@param fills
+//	@param outputPath	输出 CSV 路径
+//	@param config
+//	@return int			补全的行数
+//	@return error
+func FillGaps(fills []GapFill, outputPath string, config model.Config) (int, error) {
+	// 每个缺口的补全轨迹作为一段，供其它格式导出
+	segments := make([][]model.Row, 0, len(fills))
+	var rows []model.Row
+	for _, fill := range fills {
+		filled, err := FillGap(fill.Gap, fill.Mode, config)
+		if err != nil {
+			return 0, err
+		}
+		logx.InfoF("缺口%d（%s）补全%d个点", fill.Gap.Index, fill.Mode, len(filled))
+		segments = append(segments, filled)
+		rows = append(rows, filled...)
+	}
+	sortRows(rows)
+
+	sl := model.NewStepLife()
+	for _, row := range rows {
+		sl.AddCSVRow(row)
+	}
+	allRows := append(append([][]string{}, sl.CSVHeader...), sl.CSVData...)
+	if err := utils.WriteCSV(outputPath, allRows); err != nil {
+		logx.ErrorF("写入CSV文件失败：%s", outputPath)
+		return 0, err
+	}
+
+	if err := pipeline.WriteExtraOutputs(outputPath, "gapfill", segments, nil, config); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"testing"
+)
+
+func TestFillGap(t *testing.T) {
+	before := model.Row{Point: model.Point{DataTime: 1000, Latitude: 30, Longitude: 120}}
+	after := model.Row{Point: model.Point{DataTime: 1010, Latitude: 30, Longitude: 120.1}}
+	gap := Gap{Before: before, After: after, Duration: 10, Distance: pointcalc.Distance(before.Point, after.Point)}
+	config := model.NewDefaultConfig()
+
+	cases := []struct {
+		Name  string
+		Mode  string
+		Count int
+	}{
+		// 约 9.6 公里按 100 米补点需要 96 个点，超过缺口的 10 秒，限制为 9 个
+		{"直线", consts.FillModeStraight, 9},
+		{"大圆航线", consts.FillModeGreatCircle, 9},
+		{"飞行剖面", consts.FillModeFlight, 9},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			rows, err := FillGap(gap, c.Mode, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != c.Count {
+				t.Fatalf("len(rows) = %d, want %d", len(rows), c.Count)
+			}
+			previous := before.DataTime
+			for i, row := range rows {
+				if row.DataTime <= previous || row.DataTime >= after.DataTime {
+					t.Errorf("row %d DataTime = %d, want in (%d, %d)", i, row.DataTime, previous, after.DataTime)
+				}
+				previous = row.DataTime
+				// 向正东方向移动
+				if row.Heading < 89 || row.Heading > 91 {
+					t.Errorf("row %d Heading = %d, want 90", i, row.Heading)
+				}
+				if row.Distance <= 0 || row.Speed <= 0 {
+					t.Errorf("row %d Distance = %d, Speed = %f, want > 0", i, row.Distance, row.Speed)
+				}
+			}
+		})
+	}
+}
+
+func TestFillGapTooShort(t *testing.T) {
+	before := model.Row{Point: model.Point{DataTime: 1000, Latitude: 30, Longitude: 120}}
+	after := model.Row{Point: model.Point{DataTime: 1001, Latitude: 30, Longitude: 120.1}}
+	gap := Gap{Before: before, After: after, Duration: 1, Distance: pointcalc.Distance(before.Point, after.Point)}
+	rows, err := FillGap(gap, consts.FillModeStraight, model.NewDefaultConfig())
+	if err != nil || len(rows) != 0 {
+		t.Errorf("FillGap = %d rows, %v, want no rows", len(rows), err)
+	}
+}
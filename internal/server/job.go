@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"steplife-universal-importer-gui/internal/model"
+	"sync"
+	"time"
+)
+
+// EventType 批量转换任务的事件类型
+type EventType string
+
+const (
+	EventStarted  EventType = "started"  // 任务开始：Total、Workers
+	EventProgress EventType = "progress" // 文件处理进度：Index、FilePath、Progress、Overall，处理日志还有 Message
+	EventWarning  EventType = "warning"  // 文件处理中的警告：Index、FilePath、Message
+	EventFileDone EventType = "fileDone" // 单个文件处理结束：Index、FilePath、OutputPath、Err、Overall
+	EventFinished EventType = "finished" // 任务结束：Succeeded、Failed、Canceled，清单合并输出时还有 OutputPath、Err
+)
+
+// Event 批量转换任务的事件，字段是否有效见 EventType，可直接序列化为 JSON
+type Event struct {
+	Type       EventType `json:"type"`
+	Time       time.Time `json:"time"`
+	Total      int       `json:"total"`   // 文件总数
+	Workers    int       `json:"workers"` // 并发数
+	Index      int       `json:"index"`   // 文件在任务中的序号
+	FilePath   string    `json:"filePath,omitempty"`
+	OutputPath string    `json:"outputPath,omitempty"`
+	Progress   float64   `json:"progress"` // 当前文件的处理进度（0~1）
+	Overall    float64   `json:"overall"`  // 整体进度（0~1），按每个文件的处理进度汇总
+	Message    string    `json:"message,omitempty"`
+	Err        error     `json:"-"`
+	Error      string    `json:"error,omitempty"` // Err 的文字描述
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+	Canceled   bool      `json:"canceled"`
+}
+
+// Job 批量转换任务，转换过程通过事件通知订阅方（GUI、命令行等）
+type Job struct {
+	files       []BatchFile
+	config      model.Config
+	subscribers []func(Event)
+
+	mu        sync.Mutex
+	events    chan Event
+	fractions []float64
+}
+
+// NewJob 创建批量转换任务，每个文件使用相同的配置
+func NewJob(files []BatchFile, config model.Config) *Job {
+	return &Job{files: files, config: config}
+}
+
+// Files 任务中的文件
+func (this *Job) Files() []BatchFile {
+	return this.files
+}
+
+// Subscribe 订阅任务事件，需在 Run 之前调用；
+// 回调在同一个 goroutine 中按事件发生的顺序串行执行，回调阻塞时转换会等待，更新界面时应转到界面线程
+func (this *Job) Subscribe(handler func(Event)) {
+	this.subscribers = append(this.subscribers, handler)
+}
+
+// Run
+//
+//	@Description: 	并发转换任务中的文件，并发数为 CPU 核数；单个文件结束的事件按文件顺序发出，先完成的文件等待之前的文件
+//	@param ctx		取消后不再开始新的文件，正在处理的文件在阶段之间停止，未处理的文件以 context.Canceled 结束
+//	@return error	取消时返回 ctx.Err()，所有事件回调完成后才返回；多个文件的输出路径相同时不开始转换、不发出事件，
+//	              	返回包装了 ErrDuplicateOutput 的错误
+func (this *Job) Run(ctx context.Context) error {
+	if err := CheckOutputPaths(this.files); err != nil {
+		return err
+	}
+
+	this.events = make(chan Event, 64)
+	this.fractions = make([]float64, len(this.files))
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for event := range this.events {
+			for _, handler := range this.subscribers {
+				handler(event)
+			}
+		}
+	}()
+	defer func() {
+		close(this.events)
+		<-dispatched
+	}()
+
+	workers := min(runtime.NumCPU(), len(this.files))
+	this.emit(Event{Type: EventStarted, Total: len(this.files), Workers: workers})
+
+	jobs := make(chan int)
+	results := make(chan FileResult)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				file := this.files[index]
+				err := ctx.Err()
+				if err == nil {
+					err = processBatchFile(ctx, file, this.config, FileHooks{
+						Progress: func(fraction float64) {
+							this.progress(index, fraction)
+						},
+						Warn: func(message string) {
+							this.emit(Event{Type: EventWarning, Index: index, FilePath: file.FilePath, Message: message})
+						},
+						Info: func(message string) {
+							this.info(index, message)
+						},
+					})
+				}
+				results <- FileResult{BatchFile: file, Index: index, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for index := range this.files {
+			jobs <- index
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// 按文件顺序发出结束事件
+	finished := Event{Type: EventFinished, Total: len(this.files), Workers: workers}
+	pending := make(map[int]FileResult)
+	next := 0
+	for result := range results {
+		pending[result.Index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			switch {
+			case r.Err == nil:
+				finished.Succeeded++
+			case errors.Is(r.Err, context.Canceled):
+			default:
+				finished.Failed++
+			}
+			this.emitProgress(Event{Type: EventFileDone, Index: r.Index, FilePath: r.FilePath, OutputPath: r.OutputPath, Err: r.Err}, 1)
+		}
+	}
+
+	finished.Canceled = ctx.Err() != nil
+	finished.Overall = 1
+	this.emit(finished)
+	return ctx.Err()
+}
+
+// progress 发出文件处理进度事件
+func (this *Job) progress(index int, fraction float64) {
+	this.emitProgress(Event{Type: EventProgress, Index: index, FilePath: this.files[index].FilePath}, fraction)
+}
+
+// info 以进度事件发出文件的处理日志，进度保持不变
+func (this *Job) info(index int, message string) {
+	this.mu.Lock()
+	fraction := this.fractions[index]
+	this.mu.Unlock()
+	this.emitProgress(Event{Type: EventProgress, Index: index, FilePath: this.files[index].FilePath, Message: message}, fraction)
+}
+
+// emitProgress 记录 event.Index 对应文件的处理进度，汇总整体进度后发出事件；
+// 在锁内放入队列，保证订阅方收到的整体进度不会倒退
+func (this *Job) emitProgress(event Event, fraction float64) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.fractions[event.Index] = fraction
+	total := 0.0
+	for _, f := range this.fractions {
+		total += f
+	}
+	event.Progress = fraction
+	event.Overall = total / float64(len(this.fractions))
+	this.emit(event)
+}
+
+// emit 补全事件的公共字段并放入事件队列
+func (this *Job) emit(event Event) {
+	event.Time = time.Now()
+	event.Total = len(this.files)
+	if event.Err != nil {
+		event.Error = event.Err.Error()
+	}
+	this.events <- event
+}
@@ -0,0 +1,434 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest 批量任务清单（JSON 或 YAML），记录一次转换的输入、每个输入的配置、输出和合并方式，便于提交到仓库后原样重跑；
+// 清单中的相对路径以清单所在目录为准
+type Manifest struct {
+	Version  int                    `json:"version" yaml:"version"`   // 清单版本，为空时视为当前版本
+	Name     string                 `json:"name" yaml:"name"`         // 任务名称，合并输出的默认文件名
+	Config   string                 `json:"config" yaml:"config"`     // 基础配置文件（config.ini），为空时使用调用方的配置
+	Defaults map[string]interface{} `json:"defaults" yaml:"defaults"` // 覆盖基础配置的配置项，键名与 config.ini 相同
+	Inputs   []ManifestInput        `json:"inputs" yaml:"inputs"`
+	Output   ManifestOutput         `json:"output" yaml:"output"`
+	Merge    ManifestMerge          `json:"merge" yaml:"merge"`
+
+	dir string // 清单所在目录
+}
+
+// ManifestInput 清单中的一个输入
+type ManifestInput struct {
+	Path   string                 `json:"path" yaml:"path"`     // 轨迹文件或目录（递归扫描）
+	Config map[string]interface{} `json:"config" yaml:"config"` // 该输入单独的配置项，键名与 config.ini 相同，优先于同名配置文件和文件名中的时间
+	Output string                 `json:"output" yaml:"output"` // 该输入的 CSV 输出路径（相对输出目录），仅 path 为文件时可用
+}
+
+// ManifestOutput 清单的输出目标
+type ManifestOutput struct {
+	Dir     string   `json:"dir" yaml:"dir"`         // 输出目录，默认为清单所在目录下的 output 文件夹
+	Formats []string `json:"formats" yaml:"formats"` // 输出格式，如 [csv, gpx]，为空时使用配置中的 outputFormats
+}
+
+// ManifestMerge 清单的合并方式
+type ManifestMerge struct {
+	Mode      string `json:"mode" yaml:"mode"`           // none、combine、archive，默认为 none
+	Output    string `json:"output" yaml:"output"`       // 合并输出的文件名（相对输出目录）
+	Order     string `json:"order" yaml:"order"`         // combine：time、list
+	Gap       *int64 `json:"gap" yaml:"gap"`             // combine：相邻两个文件之间至少间隔的时间（秒）
+	Connector string `json:"connector" yaml:"connector"` // combine：文件之间的连接段
+	Archive   string `json:"archive" yaml:"archive"`     // archive：已有的一生足迹 CSV
+	Strategy  string `json:"strategy" yaml:"strategy"`   // archive：keep、replace、shift
+}
+
+// ManifestPlan 按清单展开后的任务
+type ManifestPlan struct {
+	Name       string
+	Config     model.Config // 清单的全局配置，合并方式及输出格式以此为准
+	Files      []BatchFile  // 按清单中的顺序排列
+	Merge      string       // none、combine、archive
+	OutputDir  string
+	OutputPath string // 合并输出路径，Merge 为 none 时为空
+	Archive    string
+}
+
+// LoadManifest
+//
+//	@Description: 	读取任务清单，按扩展名解析：.json 为 JSON，.yaml、.yml 为 YAML；未知字段视为错误，避免拼写错误被忽略
+//	@param path
+//	@return *Manifest
+//	@return error
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取任务清单失败：%w", err)
+	}
+
+	manifest := &Manifest{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(manifest)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(manifest)
+	default:
+		return nil, fmt.Errorf("不支持的任务清单格式：%s，仅支持 .json、.yaml、.yml", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析任务清单 %s 失败：%w", filepath.Base(path), err)
+	}
+
+	if manifest.Version == 0 {
+		manifest.Version = consts.ManifestVersion
+	}
+	if manifest.Version > consts.ManifestVersion {
+		return nil, fmt.Errorf("任务清单版本为%d，当前程序仅支持到版本%d", manifest.Version, consts.ManifestVersion)
+	}
+	if len(manifest.Inputs) == 0 {
+		return nil, fmt.Errorf("任务清单中没有输入")
+	}
+	if manifest.Name == "" {
+		manifest.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if manifest.dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Plan
+//
+//	@Description: 	展开清单：依次叠加基础配置、defaults 和合并设置得到全局配置，扫描每个输入并叠加其配置，创建输出目录
+//	@param base		清单未指定 config 时使用的基础配置
+//	@return *ManifestPlan
+//	@return error
+func (this *Manifest) Plan(base model.Config) (*ManifestPlan, error) {
+	config := base
+	if this.Config != "" {
+		configPath := this.resolve(this.Config)
+		cfg, err := ini.Load(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("加载清单配置 %s 失败：%w", configPath, err)
+		}
+		if _, _, err = migrateConfig(cfg); err != nil {
+			return nil, fmt.Errorf("加载清单配置 %s 失败：%w", configPath, err)
+		}
+		config = model.NewDefaultConfig()
+		if err = cfg.MapTo(&config); err != nil {
+			return nil, fmt.Errorf("加载清单配置 %s 失败：%w", configPath, err)
+		}
+	}
+	if err := applyOverrides(&config, this.Defaults); err != nil {
+		return nil, fmt.Errorf("defaults 配置错误：%w", err)
+	}
+	if len(this.Output.Formats) > 0 {
+		config.OutputFormats = strings.Join(this.Output.Formats, ",")
+	}
+
+	plan := &ManifestPlan{Name: this.Name, Merge: this.Merge.Mode}
+	if err := this.applyMerge(plan, &config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置错误：\n%w", err)
+	}
+	if err := ResolveTimestamps(&config); err != nil {
+		return nil, err
+	}
+	plan.Config = config
+
+	plan.OutputDir = filepath.Join(this.dir, "output")
+	if this.Output.Dir != "" {
+		plan.OutputDir = this.resolve(this.Output.Dir)
+	}
+	if err := os.MkdirAll(plan.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败：%w", err)
+	}
+	switch plan.Merge {
+	case consts.ManifestMergeCombine:
+		plan.OutputPath = this.outputPath(plan.OutputDir, this.Merge.Output, this.Name+"_steplife.csv")
+	case consts.ManifestMergeArchive:
+		baseName := strings.TrimSuffix(filepath.Base(plan.Archive), filepath.Ext(plan.Archive))
+		plan.OutputPath = this.outputPath(plan.OutputDir, this.Merge.Output, baseName+"_merged.csv")
+	}
+
+	seen := make(map[string]bool)
+	for i, input := range this.Inputs {
+		if input.Path == "" {
+			return nil, fmt.Errorf("第%d个输入缺少 path", i+1)
+		}
+		inputPath := this.resolve(input.Path)
+		files, err := this.planInput(input, inputPath, plan.OutputDir, config)
+		if err != nil {
+			return nil, fmt.Errorf("输入 %s：%w", input.Path, err)
+		}
+		for _, file := range files {
+			if seen[file.FilePath] {
+				return nil, fmt.Errorf("%s 在清单中重复出现", file.FilePath)
+			}
+			seen[file.FilePath] = true
+		}
+		plan.Files = append(plan.Files, files...)
+	}
+	if plan.Merge == consts.ManifestMergeNone {
+		if err := CheckOutputPaths(plan.Files); err != nil {
+			return nil, fmt.Errorf("%w，请为其中的输入设置 output", err)
+		}
+	}
+
+	logx.InfoF("任务清单 %s：%d个文件，合并方式：%s，输出目录：%s", plan.Name, len(plan.Files), plan.Merge, plan.OutputDir)
+	return plan, nil
+}
+
+// applyMerge 校验合并方式，并将合并设置写入全局配置
+func (this *Manifest) applyMerge(plan *ManifestPlan, config *model.Config) error {
+	merge := this.Merge
+	switch plan.Merge {
+	case "":
+		plan.Merge = consts.ManifestMergeNone
+	case consts.ManifestMergeNone, consts.ManifestMergeCombine:
+	case consts.ManifestMergeArchive:
+		if merge.Archive == "" {
+			return fmt.Errorf("合并方式为 archive 时需要设置 merge.archive")
+		}
+		plan.Archive = this.resolve(merge.Archive)
+	default:
+		return fmt.Errorf("不支持的合并方式：%s，可选 none、combine、archive", plan.Merge)
+	}
+
+	config.CombineOutput = 0
+	if plan.Merge == consts.ManifestMergeCombine {
+		config.CombineOutput = 1
+	}
+	if merge.Order != "" {
+		config.BatchOrder = merge.Order
+	}
+	if merge.Gap != nil {
+		config.BatchGap = *merge.Gap
+	}
+	if merge.Connector != "" {
+		config.BatchConnector = merge.Connector
+	}
+	if merge.Strategy != "" {
+		config.MergeStrategy = merge.Strategy
+	}
+	return nil
+}
+
+// planInput 扫描一个输入，叠加该输入的配置；没有在清单中设置时间的文件仍按同名配置文件、文件名查找时间
+func (this *Manifest) planInput(input ManifestInput, inputPath, outputDir string, config model.Config) ([]BatchFile, error) {
+	if fileInfo, err := os.Stat(inputPath); err == nil && fileInfo.IsDir() && input.Output != "" {
+		return nil, fmt.Errorf("输入为目录时不能设置 output")
+	}
+	filePaths, err := CollectFiles(inputPath, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("未找到支持的文件")
+	}
+
+	inputConfig := config
+	if _, ok := input.Config["pathStartTime"]; ok {
+		// 与按文件设置时间相同：设置了开始时间时结束时间也以此为准
+		inputConfig.PathEndTime = ""
+	}
+	if err = applyOverrides(&inputConfig, input.Config); err != nil {
+		return nil, err
+	}
+	if err = inputConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("配置错误：\n%w", err)
+	}
+	if err = ResolveTimestamps(&inputConfig); err != nil {
+		return nil, err
+	}
+	timeSet := false
+	for _, key := range []string{"pathStartTime", "pathEndTime", "timeInterval", "timezone"} {
+		if _, ok := input.Config[key]; ok {
+			timeSet = true
+		}
+	}
+
+	files := make([]BatchFile, 0, len(filePaths))
+	for i, outputPath := range OutputPaths(filePaths, outputDir) {
+		filePath := filePaths[i]
+		if input.Output != "" {
+			outputPath = this.outputPath(outputDir, input.Output, "")
+		}
+
+		fileConfig := inputConfig
+		file := BatchFile{FilePath: filePath, OutputPath: outputPath, Config: &fileConfig}
+		if !timeSet {
+			if file, err = NewBatchFile(filePath, outputPath, inputConfig, nil); err != nil {
+				return nil, err
+			}
+			if file.Config == nil && len(input.Config) > 0 {
+				file.Config = &fileConfig
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// resolve 清单中的相对路径以清单所在目录为准
+func (this *Manifest) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(this.dir, path)
+}
+
+// outputPath 输出文件路径，name 为相对路径时以输出目录为准，为空时使用 defaultName
+func (this *Manifest) outputPath(outputDir, name, defaultName string) string {
+	if name == "" {
+		name = defaultName
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(outputDir, name)
+}
+
+// Run
+//
+//	@Description: 		执行清单：逐个输出时与批量转换任务相同；合并输出时整体执行，同样以开始、进度、警告、结束事件通知 handler
+//	@param ctx			取消时停止转换，返回 ctx.Err()
+//	@param handler		事件回调，可为 nil，按事件发生的顺序串行执行，Run 返回前全部回调完成
+//	@return error		合并输出失败时返回失败原因，逐个输出时单个文件的失败只通过事件通知
+func (this *ManifestPlan) Run(ctx context.Context, handler func(Event)) error {
+	switch this.Merge {
+	case consts.ManifestMergeCombine:
+		return this.runWhole(handler, func(hooks FileHooks) error {
+			_, err := CombineFiles(ctx, this.Files, this.OutputPath, this.Config, hooks)
+			return err
+		})
+	case consts.ManifestMergeArchive:
+		return this.runWhole(handler, func(hooks FileHooks) error {
+			_, err := MergeIntoArchive(ctx, this.Archive, this.Files, this.OutputPath, this.Config)
+			return err
+		})
+	default:
+		job := NewJob(this.Files, this.Config)
+		if handler != nil {
+			job.Subscribe(handler)
+		}
+		return job.Run(ctx)
+	}
+}
+
+// runWhole 将整体执行的合并输出转换为任务事件，所有文件视为一个整体，成功或失败的文件数均为文件总数
+func (this *ManifestPlan) runWhole(handler func(Event), run func(hooks FileHooks) error) error {
+	emit := func(event Event) {
+		if handler == nil {
+			return
+		}
+		event.Time = time.Now()
+		event.Total = len(this.Files)
+		event.Workers = 1
+		if event.Err != nil {
+			event.Error = event.Err.Error()
+		}
+		handler(event)
+	}
+
+	emit(Event{Type: EventStarted})
+	progress := 0.0
+	err := run(FileHooks{
+		Progress: func(fraction float64) {
+			progress = fraction
+			emit(Event{Type: EventProgress, Progress: fraction, Overall: fraction})
+		},
+		Warn: func(message string) {
+			emit(Event{Type: EventWarning, Message: message})
+		},
+		Info: func(message string) {
+			emit(Event{Type: EventProgress, Progress: progress, Overall: progress, Message: message})
+		},
+	})
+
+	finished := Event{Type: EventFinished, Overall: 1, OutputPath: this.OutputPath, Err: err}
+	switch {
+	case err == nil:
+		finished.Succeeded = len(this.Files)
+	case errors.Is(err, context.Canceled):
+		finished.Canceled = true
+	default:
+		finished.Failed = len(this.Files)
+	}
+	emit(finished)
+	return err
+}
+
+// applyOverrides 按 config.ini 的键名覆盖配置，未知的键名视为错误；布尔值写为 0/1，列表以逗号连接
+func applyOverrides(config *model.Config, overrides map[string]interface{}) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	keys := configKeys()
+	section := ini.Empty().Section("")
+	for key, value := range overrides {
+		if !keys[key] {
+			return fmt.Errorf("未知的配置项：%s", key)
+		}
+		section.Key(key).SetValue(overrideValue(value))
+	}
+	return section.MapTo(config)
+}
+
+// configKeys 配置结构体中的 ini 键名
+func configKeys() map[string]bool {
+	keys := make(map[string]bool)
+	configType := reflect.TypeOf(model.Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		if key := configType.Field(i).Tag.Get("ini"); key != "" && key != "-" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// overrideValue 将 JSON / YAML 中的值转换为 config.ini 中的写法
+func overrideValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = overrideValue(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
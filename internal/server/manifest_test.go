@@ -0,0 +1,199 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles 在 dir 下写入文件，按需创建子目录
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	cases := []struct {
+		Name     string
+		FileName string
+		Content  string
+		Err      string
+	}{
+		{"JSON", "trip.json", `{"inputs": [{"path": "a.gpx"}]}`, ""},
+		{"YAML", "trip.yml", "version: 1\ninputs:\n  - path: a.gpx\n", ""},
+		{"JSON未知字段", "trip.json", `{"inputs": [{"path": "a.gpx"}], "ouput": {"dir": "out"}}`, "ouput"},
+		{"YAML未知字段", "trip.yaml", "inputs:\n  - path: a.gpx\n    outptu: a.csv\n", "outptu"},
+		{"版本过高", "trip.json", `{"version": 2, "inputs": [{"path": "a.gpx"}]}`, "版本"},
+		{"没有输入", "trip.json", `{"name": "trip"}`, "没有输入"},
+		{"不支持的格式", "trip.toml", "inputs = []", "不支持"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, c.FileName)
+			writeFiles(t, dir, map[string]string{c.FileName: c.Content})
+
+			manifest, err := LoadManifest(path)
+			if c.Err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.Err) {
+					t.Fatalf("err = %v, want error containing %q", err, c.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if manifest.Version != consts.ManifestVersion || manifest.Name != "trip" || manifest.dir != dir {
+				t.Errorf("manifest = version %d, name %q, dir %q", manifest.Version, manifest.Name, manifest.dir)
+			}
+		})
+	}
+}
+
+func TestManifestPlan(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"2024-05-01_0700.gpx":       "",
+		"rides/2024-05-02_0900.kml": "",
+		"rides/c.kml":               "",
+		"trip.yaml": `name: 五一
+defaults:
+  insertPointDistance: 200
+  timezone: Asia/Shanghai
+inputs:
+  - path: 2024-05-01_0700.gpx
+    output: custom/a.csv
+    config:
+      pathStartTime: 2024-05-01 08:00:00
+      insertPointDistance: 300
+  - path: rides
+output:
+  formats: [csv, gpx]
+`,
+	})
+
+	manifest, err := LoadManifest(filepath.Join(dir, "trip.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := manifest.Plan(model.NewDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.OutputDir != filepath.Join(dir, "output") || plan.Merge != consts.ManifestMergeNone {
+		t.Errorf("OutputDir = %q, Merge = %q", plan.OutputDir, plan.Merge)
+	}
+	if plan.Config.InsertPointDistance != 200 || plan.Config.OutputFormats != "csv,gpx" {
+		t.Errorf("Config = insertPointDistance %d, outputFormats %q", plan.Config.InsertPointDistance, plan.Config.OutputFormats)
+	}
+	if len(plan.Files) != 3 {
+		t.Fatalf("len(Files) = %d, want 3", len(plan.Files))
+	}
+
+	cases := []struct {
+		Name       string
+		File       BatchFile
+		FilePath   string
+		OutputPath string
+		Distance   int
+		Start      int64 // 0 表示使用清单的全局配置
+	}{
+		{"输入的配置优先于文件名", plan.Files[0], "2024-05-01_0700.gpx", "custom/a.csv", 300, 1714521600},
+		{"目录中按文件名设置时间", plan.Files[1], "rides/2024-05-02_0900.kml", "2024-05-02_0900_steplife.csv", 200, 1714611600},
+		{"目录中使用全局配置", plan.Files[2], "rides/c.kml", "c_steplife.csv", 200, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if c.File.FilePath != filepath.Join(dir, c.FilePath) {
+				t.Errorf("FilePath = %q, want %q", c.File.FilePath, c.FilePath)
+			}
+			if c.File.OutputPath != filepath.Join(plan.OutputDir, c.OutputPath) {
+				t.Errorf("OutputPath = %q, want %q", c.File.OutputPath, c.OutputPath)
+			}
+			config := plan.Config
+			if c.File.Config != nil {
+				config = *c.File.Config
+			}
+			if config.InsertPointDistance != c.Distance {
+				t.Errorf("InsertPointDistance = %d, want %d", config.InsertPointDistance, c.Distance)
+			}
+			if (c.Start == 0) != (c.File.Config == nil) || (c.Start != 0 && config.PathStartTimestamp != c.Start) {
+				t.Errorf("Config = %v, PathStartTimestamp = %d, want %d", c.File.Config != nil, config.PathStartTimestamp, c.Start)
+			}
+		})
+	}
+}
+
+func TestManifestPlanDuplicateInput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"rides/a.gpx": "",
+		"trip.json":   `{"inputs": [{"path": "rides/a.gpx"}, {"path": "rides"}]}`,
+	})
+
+	manifest, err := LoadManifest(filepath.Join(dir, "trip.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = manifest.Plan(model.NewDefaultConfig()); err == nil || !strings.Contains(err.Error(), "重复") {
+		t.Errorf("Plan err = %v, want duplicate error", err)
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	config := model.NewDefaultConfig()
+	err := applyOverrides(&config, map[string]interface{}{
+		"enableStayPointDetection": true,
+		"simplifyTolerance":        float64(7.5),
+		"insertPointDistance":      300,
+		"outputFormats":            []interface{}{"csv", "kml"},
+		"pathStartTime":            time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		"smoothMethod":             nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.EnableStayPointDetection != 1 || config.SimplifyTolerance != 7.5 || config.InsertPointDistance != 300 ||
+		config.OutputFormats != "csv,kml" || config.PathStartTime != "2024-05-01 08:00:00" || config.SmoothMethod != "" {
+		t.Errorf("config = %+v", config)
+	}
+
+	if err = applyOverrides(&config, map[string]interface{}{"insertPointDistanse": 300}); err == nil {
+		t.Error("unknown key accepted")
+	}
+}
+
+func TestOverrideValue(t *testing.T) {
+	cases := []struct {
+		Value interface{}
+		Want  string
+	}{
+		{nil, ""},
+		{true, "1"},
+		{false, "0"},
+		{float64(100), "100"},
+		{float64(0.25), "0.25"},
+		{42, "42"},
+		{"Asia/Shanghai", "Asia/Shanghai"},
+		{time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), "2024-05-01 08:30:00"},
+		{[]interface{}{"csv", float64(1), true}, "csv,1,1"},
+	}
+	for _, c := range cases {
+		if got := overrideValue(c.Value); got != c.Want {
+			t.Errorf("overrideValue(%#v) = %q, want %q", c.Value, got, c.Want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/pipeline"
+	"steplife-universal-importer-gui/internal/utils"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strings"
+)
+
+// MergeResult 合并结果统计
+type MergeResult struct {
+	ExistingRows  int // 已有足迹行数
+	AddedRows     int // 新增轨迹行数
+	DroppedRows   int // 因与已有数据重叠而丢弃的新轨迹行数
+	ReplacedRows  int // 被新轨迹替换的已有行数
+	ShiftedTracks int // 被平移到空闲时间段的新轨迹数
+	DuplicateRows int // 去除的完全重复行数
+	TotalRows     int // 输出行数
+}
+
+// timeRange 已有数据占用的时间段（闭区间）
+type timeRange struct {
+	Start int64
+	End   int64
+}
+
+// MergeIntoArchive
+//
+//	@Description: 		将新轨迹合并到已有的一生足迹导出数据，按策略处理时间重叠，去重后按时间排序写出
+//	@param ctx			取消时在文件之间（及文件的阶段之间）停止，不写出结果，返回 ctx.Err()
+//	@param archivePath	已有的一生足迹 CSV
+//	@param files		新轨迹文件，OutputPath 不使用，设置了 Config 时按该配置转换
+//	@param outputPath	输出 CSV 路径
+//	@param config
+//	@return *MergeResult
+//	@return error
+func MergeIntoArchive(ctx context.Context, archivePath string, files []BatchFile, outputPath string, config model.Config) (*MergeResult, error) {
+	strategy := config.MergeStrategy
+	switch strategy {
+	case "":
+		strategy = consts.MergeStrategyKeep
+	case consts.MergeStrategyKeep, consts.MergeStrategyReplace, consts.MergeStrategyShift:
+	default:
+		return nil, fmt.Errorf("不支持的合并策略：%s", strategy)
+	}
+	gapThreshold := config.MergeGapThreshold
+	if gapThreshold <= 0 {
+		gapThreshold = consts.DefaultMergeGapThreshold
+	}
+
+	rows, err := LoadStepLifeRows(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{ExistingRows: len(rows)}
+	logx.InfoF("已有足迹共%d行，合并策略：%s", len(rows), strategy)
+
+	for _, file := range files {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		filePath := file.FilePath
+		if filepath.Clean(filePath) == filepath.Clean(archivePath) {
+			continue
+		}
+		logx.InfoF("合并文件：%s", filePath)
+
+		fileConfig := config
+		if file.Config != nil {
+			fileConfig = *file.Config
+		}
+		pctx := &pipeline.Context{Config: fileConfig, FilePath: filePath}
+		if err = pipeline.Default().RunContext(ctx, pctx); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logx.ErrorF("处理文件失败：%s", filePath)
+			}
+			return nil, err
+		}
+		if pctx.StepLife == nil || len(pctx.StepLife.Rows) == 0 {
+			continue
+		}
+		rows = mergeTrack(rows, pctx.StepLife.Rows, strategy, gapThreshold, result)
+	}
+
+	// 去除完全重复的行（所有字段格式化后一致）
+	sl := model.NewStepLife()
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		key := strings.Join(model.FormatCSVRow(row), ",")
+		if seen[key] {
+			result.DuplicateRows++
+			continue
+		}
+		seen[key] = true
+		sl.AddCSVRow(row)
+	}
+	result.TotalRows = len(sl.CSVData)
+
+	allRows := append(append([][]string{}, sl.CSVHeader...), sl.CSVData...)
+	if err = utils.WriteCSV(outputPath, allRows); err != nil {
+		logx.ErrorF("写入CSV文件失败：%s", outputPath)
+		return nil, err
+	}
+
+	logx.InfoF("合并完成：已有%d行，新增%d行，丢弃重叠%d行，替换%d行，平移轨迹%d条，去重%d行，输出%d行",
+		result.ExistingRows, result.AddedRows, result.DroppedRows, result.ReplacedRows,
+		result.ShiftedTracks, result.DuplicateRows, result.TotalRows)
+
+	trackName := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	if err = pipeline.WriteExtraOutputs(outputPath, trackName, [][]model.Row{sl.Rows}, nil, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeTrack 将一条新轨迹按策略合并到已按时间排序的数据中，返回仍按时间排序的结果
+func mergeTrack(rows []model.Row, track []model.Row, strategy string, gapThreshold int64, result *MergeResult) []model.Row {
+	track = append([]model.Row{}, track...)
+	sortRows(track)
+	start, end := track[0].DataTime, track[len(track)-1].DataTime
+
+	occupied := occupiedRanges(rows, gapThreshold)
+	overlapped := false
+	for _, r := range occupied {
+		if start <= r.End && end >= r.Start {
+			overlapped = true
+			break
+		}
+	}
+
+	if overlapped {
+		logx.InfoF("新轨迹（%d - %d）与已有数据时间重叠", start, end)
+		switch strategy {
+		case consts.MergeStrategyKeep:
+			kept := track[:0]
+			for _, row := range track {
+				if inRanges(row.DataTime, occupied) {
+					result.DroppedRows++
+					continue
+				}
+				kept = append(kept, row)
+			}
+			track = kept
+		case consts.MergeStrategyReplace:
+			remaining := rows[:0:0]
+			for _, row := range rows {
+				if row.DataTime >= start && row.DataTime <= end {
+					result.ReplacedRows++
+					continue
+				}
+				remaining = append(remaining, row)
+			}
+			rows = remaining
+		case consts.MergeStrategyShift:
+			offset := findFreeSlotOffset(occupied, start, end)
+			for i := range track {
+				track[i].DataTime += offset
+			}
+			result.ShiftedTracks++
+			logx.InfoF("新轨迹平移%d秒到空闲时间段", offset)
+		}
+	}
+
+	result.AddedRows += len(track)
+	rows = append(rows, track...)
+	sortRows(rows)
+	return rows
+}
+
+// occupiedRanges 将已排序的数据按间隔阈值划分为连续占用的时间段
+func occupiedRanges(rows []model.Row, gapThreshold int64) []timeRange {
+	var ranges []timeRange
+	for _, row := range rows {
+		if len(ranges) > 0 && row.DataTime-ranges[len(ranges)-1].End <= gapThreshold {
+			ranges[len(ranges)-1].End = row.DataTime
+			continue
+		}
+		ranges = append(ranges, timeRange{Start: row.DataTime, End: row.DataTime})
+	}
+	return ranges
+}
+
+func inRanges(timestamp int64, ranges []timeRange) bool {
+	for _, r := range ranges {
+		if timestamp >= r.Start && timestamp <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// findFreeSlotOffset 找到能容纳 [start, end] 且距原始位置最近的空闲时间段，返回需要平移的秒数
+func findFreeSlotOffset(occupied []timeRange, start, end int64) int64 {
+	duration := end - start
+	bestOffset := int64(math.MaxInt64)
+	found := false
+
+	// 空闲时间段为相邻占用时间段之间的开区间，首尾两端无限延伸
+	for i := 0; i <= len(occupied); i++ {
+		lower := int64(math.MinInt64 / 2)
+		upper := int64(math.MaxInt64 / 2)
+		if i > 0 {
+			lower = occupied[i-1].End + 1
+		}
+		if i < len(occupied) {
+			upper = occupied[i].Start - 1
+		}
+		if upper-lower < duration {
+			continue
+		}
+
+		newStart := start
+		if newStart < lower {
+			newStart = lower
+		}
+		if newStart+duration > upper {
+			newStart = upper - duration
+		}
+		offset := newStart - start
+		if !found || abs64(offset) < abs64(bestOffset) {
+			bestOffset = offset
+			found = true
+		}
+	}
+	return bestOffset
+}
+
+func sortRows(rows []model.Row) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].DataTime < rows[j].DataTime
+	})
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"strconv"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// Profile 命名配置，如 高铁、飞机、徒步、驾车，保存为配置文件中以名称命名的分区
+type Profile struct {
+	Name   string
+	Config model.Config
+}
+
+// BuiltinProfiles 内置的命名配置，配置文件中没有任何命名配置时提供
+func BuiltinProfiles() []Profile {
+	rail := model.NewDefaultConfig()
+	rail.InsertPointDistance = 500
+	rail.SpeedMode = "manual"
+	rail.ManualSpeed = consts.RailFillSpeed
+	rail.OutlierFilter = consts.TransportRail
+
+	flight := model.NewDefaultConfig()
+	flight.InsertPointDistance = 2000
+	flight.SpeedMode = "manual"
+	flight.ManualSpeed = consts.ConnectorFlightSpeed
+	flight.AltitudeMode = consts.AltitudeModeFlight
+	flight.OutlierFilter = consts.TransportFlight
+
+	hiking := model.NewDefaultConfig()
+	hiking.InsertPointDistance = consts.MinInsertPointDistance
+	hiking.SpeedMode = "manual"
+	hiking.ManualSpeed = 1.2
+	hiking.OutlierFilter = consts.TransportWalk
+	hiking.EnableStayPointDetection = 1
+
+	drive := model.NewDefaultConfig()
+	drive.SpeedMode = "manual"
+	drive.ManualSpeed = consts.ConnectorDriveSpeed
+	drive.OutlierFilter = consts.TransportCar
+
+	return []Profile{
+		{Name: consts.ProfileRail, Config: rail},
+		{Name: consts.ProfileFlight, Config: flight},
+		{Name: consts.ProfileHiking, Config: hiking},
+		{Name: consts.ProfileDrive, Config: drive},
+	}
+}
+
+// Names 命名配置的名称
+func (this *ConfigFile) Names() []string {
+	names := make([]string, len(this.Profiles))
+	for i, profile := range this.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// Lookup 按名称查找命名配置
+func (this *ConfigFile) Lookup(name string) (model.Config, bool) {
+	for _, profile := range this.Profiles {
+		if profile.Name == name {
+			return profile.Config, true
+		}
+	}
+	return model.Config{}, false
+}
+
+// Use 将命名配置设为当前配置
+func (this *ConfigFile) Use(name string) error {
+	config, ok := this.Lookup(name)
+	if !ok {
+		return fmt.Errorf("没有名为「%s」的配置，可选：%s", name, strings.Join(this.Names(), "、"))
+	}
+	this.Config = config
+	this.Profile = name
+	return nil
+}
+
+// SetProfile 保存命名配置，同名时覆盖
+func (this *ConfigFile) SetProfile(name string, config model.Config) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	for i, profile := range this.Profiles {
+		if profile.Name == name {
+			this.Profiles[i].Config = config
+			return nil
+		}
+	}
+	this.Profiles = append(this.Profiles, Profile{Name: name, Config: config})
+	return nil
+}
+
+// DeleteProfile 删除命名配置，当前配置来自该配置时取消选择
+func (this *ConfigFile) DeleteProfile(name string) {
+	for i, profile := range this.Profiles {
+		if profile.Name == name {
+			this.Profiles = append(this.Profiles[:i], this.Profiles[i+1:]...)
+			break
+		}
+	}
+	if this.Profile == name {
+		this.Profile = ""
+	}
+}
+
+// ValidateProfileName 命名配置的名称用作 ini 分区名，不能为空、DEFAULT 或包含方括号、点（点会被视为父子分区）
+func ValidateProfileName(name string) error {
+	if strings.TrimSpace(name) != name || name == "" {
+		return fmt.Errorf("配置名称不能为空，且首尾不能有空格")
+	}
+	if strings.EqualFold(name, ini.DefaultSection) || strings.ContainsAny(name, "[].") {
+		return fmt.Errorf("配置名称「%s」无效：不能为 DEFAULT 或包含 [ ] .", name)
+	}
+	return nil
+}
+
+// ExportProfiles 将命名配置写为 ini，每个配置一个分区，默认分区记录配置文件版本
+func ExportProfiles(w io.Writer, profiles []Profile) error {
+	cfg := ini.Empty()
+	cfg.Section("").Key(consts.ConfigVersionKey).SetValue(strconv.Itoa(consts.ConfigVersion))
+	if err := writeProfiles(cfg, profiles); err != nil {
+		return err
+	}
+	_, err := cfg.WriteTo(w)
+	return err
+}
+
+// ImportProfiles
+//
+//	@Description: 	读取导出的命名配置，旧版本的文件按版本迁移；文件中没有命名分区时（如另一份 config.ini）将默认分区作为以文件名命名的配置。
+//	                	任一配置校验失败时不导入
+//	@param path
+//	@return []Profile
+//	@return error
+func ImportProfiles(path string) ([]Profile, error) {
+	cfg, err := ini.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败：%w", err)
+	}
+	if _, _, err = migrateConfig(cfg); err != nil {
+		return nil, err
+	}
+	profiles, err := readProfiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) > 0 {
+		for _, profile := range profiles {
+			if err = profile.Config.Validate(); err != nil {
+				return nil, fmt.Errorf("配置「%s」无效：\n%w", profile.Name, err)
+			}
+		}
+		return profiles, nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	config := model.NewDefaultConfig()
+	if err = cfg.Section("").MapTo(&config); err != nil {
+		return nil, fmt.Errorf("加载配置失败：%w", err)
+	}
+	if err = ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置「%s」无效：\n%w", name, err)
+	}
+	return []Profile{{Name: name, Config: config}}, nil
+}
+
+// readProfiles 读取默认分区以外的分区，缺少的配置项使用默认值
+func readProfiles(cfg *ini.File) ([]Profile, error) {
+	var profiles []Profile
+	for _, section := range cfg.Sections() {
+		if section.Name() == ini.DefaultSection {
+			continue
+		}
+		config := model.NewDefaultConfig()
+		if err := section.MapTo(&config); err != nil {
+			return nil, fmt.Errorf("加载配置「%s」失败：%w", section.Name(), err)
+		}
+		profiles = append(profiles, Profile{Name: section.Name(), Config: config})
+	}
+	return profiles, nil
+}
+
+// writeProfiles 将命名配置写为以名称命名的分区
+func writeProfiles(cfg *ini.File, profiles []Profile) error {
+	for _, profile := range profiles {
+		section, err := cfg.NewSection(profile.Name)
+		if err != nil {
+			return err
+		}
+		if err = section.ReflectFrom(&profile.Config); err != nil {
+			return fmt.Errorf("保存配置「%s」失败：%w", profile.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	consts "steplife-universal-importer-gui/internal/const"
+	"testing"
+)
+
+func TestExportImportProfiles(t *testing.T) {
+	profiles := BuiltinProfiles()
+	profiles[0].Config.Timezone = "Asia/Shanghai"
+	profiles[0].Config.OutputFormats = "csv,gpx"
+
+	var buf bytes.Buffer
+	if err := ExportProfiles(&buf, profiles); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "profiles.ini")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != len(profiles) {
+		t.Fatalf("imported %d profiles, want %d", len(imported), len(profiles))
+	}
+	for i := range profiles {
+		if imported[i] != profiles[i] {
+			t.Errorf("profile %d = %+v, want %+v", i, imported[i], profiles[i])
+		}
+	}
+}
+
+func TestImportProfilesFromConfigFile(t *testing.T) {
+	cases := []struct {
+		Name     string
+		FileName string
+		Content  string
+		Err      bool
+	}{
+		{"默认分区作为以文件名命名的配置", "骑行.ini", "insertPointDistance = 50\noutlierFilter = bike\n", false},
+		{"旧版本按版本迁移", "骑行.ini", "configVersion = 2\ninsertPointDistance = 50\noutlierFilter = bike\nenableBatchProcessing = 1\n", false},
+		{"配置校验失败时不导入", "骑行.ini", "insertPointDistance = 50\noutlierFilter = boat\n", true},
+		{"文件名不能作为配置名称", "v1.2.ini", "insertPointDistance = 50\n", true},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.FileName)
+			if err := os.WriteFile(path, []byte(c.Content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			profiles, err := ImportProfiles(path)
+			if (err != nil) != c.Err {
+				t.Fatalf("err = %v, want error %v", err, c.Err)
+			}
+			if c.Err {
+				return
+			}
+			if len(profiles) != 1 || profiles[0].Name != "骑行" {
+				t.Fatalf("profiles = %+v", profiles)
+			}
+			if config := profiles[0].Config; config.InsertPointDistance != 50 || config.OutlierFilter != consts.TransportBike {
+				t.Errorf("config = %+v", config)
+			}
+		})
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Valid bool
+	}{
+		{"高铁", true},
+		{"周末 骑行", true},
+		{"", false},
+		{" 高铁", false},
+		{"高铁 ", false},
+		{"DEFAULT", false},
+		{"default", false},
+		{"[高铁]", false},
+		{"v1.2", false},
+	}
+	for _, c := range cases {
+		if err := ValidateProfileName(c.Name); (err == nil) != c.Valid {
+			t.Errorf("ValidateProfileName(%q) = %v, want valid %v", c.Name, err, c.Valid)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package dem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// hgt 高程数据中的空值
+const hgtVoid = -32768
+
+// hgtNamePattern SRTM 瓦片文件名，如 N30E120.hgt
+var hgtNamePattern = regexp.MustCompile(`^([NS])(\d{2})([EW])(\d{3})$`)
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// grid 规则经纬度格网高程数据
+type grid struct {
+	West   float64 // 第 0 列采样点的经度
+	North  float64 // 第 0 行采样点的纬度
+	DX     float64 // 相邻列采样点的经度间隔
+	DY     float64 // 相邻行采样点的纬度间隔
+	Width  int
+	Height int
+	Data   []float32 // 按行存储的高程（米），NaN 表示空值
+}
+
+// contains 坐标是否落在格网采样范围内
+func (this *grid) contains(lat, lon float64) bool {
+	x := (lon - this.West) / this.DX
+	y := (this.North - lat) / this.DY
+	return x >= 0 && y >= 0 && x <= float64(this.Width-1) && y <= float64(this.Height-1)
+}
+
+// sample 双线性插值采样，周围四个采样点中的空值不参与计算
+func (this *grid) sample(lat, lon float64) (float64, bool) {
+	if !this.contains(lat, lon) {
+		return 0, false
+	}
+	x := (lon - this.West) / this.DX
+	y := (this.North - lat) / this.DY
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	x1, y1 := min(x0+1, this.Width-1), min(y0+1, this.Height-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var sum, weightSum float64
+	for _, c := range []struct {
+		X, Y   int
+		Weight float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x1, y0, fx * (1 - fy)},
+		{x0, y1, (1 - fx) * fy},
+		{x1, y1, fx * fy},
+	} {
+		v := this.Data[c.Y*this.Width+c.X]
+		if math.IsNaN(float64(v)) || c.Weight == 0 {
+			continue
+		}
+		sum += float64(v) * c.Weight
+		weightSum += c.Weight
+	}
+	if weightSum == 0 {
+		return 0, false
+	}
+	return sum / weightSum, true
+}
+
+// tile 目录中的一个高程瓦片，数据在首次查询时加载
+type tile struct {
+	Path   string
+	Bounds *grid // 仅包含范围信息，Data 为空
+	data   *grid
+	err    error
+	once   sync.Once
+}
+
+func (this *tile) load() (*grid, error) {
+	this.once.Do(func() {
+		if strings.EqualFold(filepath.Ext(this.Path), ".hgt") {
+			this.data, this.err = readHGT(this.Path, this.Bounds.West, this.Bounds.North)
+		} else {
+			this.data, this.err = readGeoTIFF(this.Path, true)
+		}
+		if this.err != nil {
+			logx.ErrorF("读取高程瓦片失败：%s，%s", this.Path, this.err)
+		}
+	})
+	return this.data, this.err
+}
+
+// Store 本地高程瓦片目录
+type Store struct {
+	Directory string
+	tiles     []*tile
+}
+
+// Open
+//
+//	@Description: 		打开本地高程瓦片目录，支持 SRTM .hgt 与 GeoTIFF（.tif/.tiff），同一目录只扫描一次
+//	@param directory
+//	@return *Store
+//	@return error
+func Open(directory string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	directory = filepath.Clean(directory)
+	if store, ok := stores[directory]; ok {
+		return store, nil
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("读取高程数据目录失败：%w", err)
+	}
+
+	store := &Store{Directory: directory}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(directory, entry.Name())
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		switch ext {
+		case ".hgt":
+			bounds, err := hgtBounds(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+			if err != nil {
+				logx.ErrorF("跳过高程瓦片：%s，%s", path, err)
+				continue
+			}
+			store.tiles = append(store.tiles, &tile{Path: path, Bounds: bounds})
+		case ".tif", ".tiff":
+			bounds, err := readGeoTIFF(path, false)
+			if err != nil {
+				logx.ErrorF("跳过高程瓦片：%s，%s", path, err)
+				continue
+			}
+			store.tiles = append(store.tiles, &tile{Path: path, Bounds: bounds})
+		}
+	}
+	if len(store.tiles) == 0 {
+		return nil, fmt.Errorf("高程数据目录中没有 .hgt 或 GeoTIFF 瓦片：%s", directory)
+	}
+	logx.InfoF("加载高程数据目录：%s，共%d个瓦片", directory, len(store.tiles))
+
+	stores[directory] = store
+	return store, nil
+}
+
+// Elevation
+//
+//	@Description: 	查询坐标处的地形高程
+//	@param lat
+//	@param lon
+//	@return float64	高程（米）
+//	@return bool	是否有覆盖该坐标的有效数据
+func (this *Store) Elevation(lat, lon float64) (float64, bool) {
+	for _, t := range this.tiles {
+		if !t.Bounds.contains(lat, lon) {
+			continue
+		}
+		g, err := t.load()
+		if err != nil {
+			continue
+		}
+		if v, ok := g.sample(lat, lon); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// hgtBounds 根据 SRTM 瓦片文件名得到瓦片范围，瓦片分辨率在读取数据时确定
+func hgtBounds(name string) (*grid, error) {
+	m := hgtNamePattern.FindStringSubmatch(strings.ToUpper(name))
+	if m == nil {
+		return nil, fmt.Errorf("无法识别的 SRTM 文件名：%s", name)
+	}
+	lat, _ := strconv.Atoi(m[2])
+	lon, _ := strconv.Atoi(m[4])
+	if m[1] == "S" {
+		lat = -lat
+	}
+	if m[3] == "W" {
+		lon = -lon
+	}
+	// 文件名为西南角坐标，瓦片覆盖 1°×1°
+	return &grid{West: float64(lon), North: float64(lat + 1), DX: 1, DY: 1, Width: 2, Height: 2}, nil
+}
+
+// readHGT 读取 SRTM .hgt 瓦片：大端 int16，SRTM1 为 3601×3601，SRTM3 为 1201×1201
+func readHGT(path string, west, north float64) (*grid, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	size := int(math.Round(math.Sqrt(float64(len(content) / 2))))
+	if size < 2 || size*size*2 != len(content) {
+		return nil, fmt.Errorf("不是有效的 .hgt 文件，大小为%d字节", len(content))
+	}
+
+	data := make([]float32, size*size)
+	for i := range data {
+		v := int16(binary.BigEndian.Uint16(content[i*2:]))
+		if v == hgtVoid {
+			data[i] = float32(math.NaN())
+			continue
+		}
+		data[i] = float32(v)
+	}
+	step := 1 / float64(size-1)
+	return &grid{West: west, North: north, DX: step, DY: step, Width: size, Height: size, Data: data}, nil
+}
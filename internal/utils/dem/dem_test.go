@@ -0,0 +1,70 @@
+package dem
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sampleCase 查询坐标及期望的高程，Want 为 NaN 表示没有数据
+type sampleCase struct {
+	Lat, Lon float64
+	Want     float64
+}
+
+func checkElevations(t *testing.T, store *Store, cases []sampleCase) {
+	t.Helper()
+	for _, c := range cases {
+		got, ok := store.Elevation(c.Lat, c.Lon)
+		if math.IsNaN(c.Want) {
+			if ok {
+				t.Errorf("Elevation(%v, %v) = %v, want no data", c.Lat, c.Lon, got)
+			}
+			continue
+		}
+		if !ok || math.Abs(got-c.Want) > 1e-6 {
+			t.Errorf("Elevation(%v, %v) = %v, %v, want %v", c.Lat, c.Lon, got, ok, c.Want)
+		}
+	}
+}
+
+func TestHGT(t *testing.T) {
+	// 3×3 的 N30E120 瓦片，采样间隔 0.5°，自北向南按行存储，右下角之前的一个采样点为空值
+	dir := t.TempDir()
+	values := []int16{
+		100, 200, 300,
+		400, 500, 600,
+		700, hgtVoid, 900,
+	}
+	content := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.BigEndian.PutUint16(content[i*2:], uint16(v))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "N30E120.hgt"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkElevations(t, store, []sampleCase{
+		{31, 120, 100},
+		{30, 121, 900},
+		{30.75, 120.25, 300},
+		{30.25, 120.75, (500 + 600 + 900) / 3.0}, // 空值不参与插值
+		{29.5, 120, math.NaN()},
+	})
+}
+
+func TestHGTInvalidSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "N30E120.hgt")
+	if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readHGT(path, 120, 31); err == nil {
+		t.Error("readHGT accepted a truncated tile")
+	}
+}
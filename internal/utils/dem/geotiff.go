@@ -0,0 +1,368 @@
+package dem
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TIFF/GeoTIFF 标签
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPredictor       = 317
+	tagTileWidth       = 322
+	tagTileLength      = 323
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSampleFormat    = 339
+	tagModelPixelScale = 33550
+	tagModelTiepoint   = 33922
+	tagGeoKeyDirectory = 34735
+	tagGDALNoData      = 42113
+)
+
+const (
+	compressionNone       = 1
+	compressionDeflate    = 8
+	compressionDeflateOld = 32946
+
+	sampleFormatUint  = 1
+	sampleFormatInt   = 2
+	sampleFormatFloat = 3
+
+	// GeoKey：栅格类型，2 表示像素值代表像素点（PixelIsPoint）
+	geoKeyRasterType   = 1025
+	rasterPixelIsPoint = 2
+)
+
+// tiffEntry IFD 中的一个标签
+type tiffEntry struct {
+	Type   uint16
+	Count  uint32
+	Values []float64
+	Text   string
+}
+
+// tiffReader 读取单个 TIFF 文件的首个图像
+type tiffReader struct {
+	r       io.ReaderAt
+	order   binary.ByteOrder
+	entries map[uint16]tiffEntry
+}
+
+// readGeoTIFF
+//
+//	@Description: 		读取经纬度坐标系下的单波段 GeoTIFF 高程数据，支持无压缩和 Deflate 压缩，条带或分块存储
+//	@param path
+//	@param withData		为 false 时只读取范围信息
+//	@return *grid
+//	@return error
+func readGeoTIFF(path string, withData bool) (*grid, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	t := &tiffReader{r: file, entries: make(map[uint16]tiffEntry)}
+	if err = t.readHeader(); err != nil {
+		return nil, err
+	}
+
+	g, err := t.bounds()
+	if err != nil || !withData {
+		return g, err
+	}
+	g.Data, err = t.readData(g.Width, g.Height)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// readHeader 读取文件头和首个 IFD
+func (this *tiffReader) readHeader() error {
+	header := make([]byte, 8)
+	if _, err := this.r.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("读取 TIFF 文件头失败：%w", err)
+	}
+	switch string(header[:2]) {
+	case "II":
+		this.order = binary.LittleEndian
+	case "MM":
+		this.order = binary.BigEndian
+	default:
+		return fmt.Errorf("不是有效的 TIFF 文件")
+	}
+	if this.order.Uint16(header[2:]) != 42 {
+		return fmt.Errorf("不支持 BigTIFF 文件")
+	}
+
+	offset := int64(this.order.Uint32(header[4:]))
+	countBuf := make([]byte, 2)
+	if _, err := this.r.ReadAt(countBuf, offset); err != nil {
+		return fmt.Errorf("读取 IFD 失败：%w", err)
+	}
+	count := int(this.order.Uint16(countBuf))
+	buf := make([]byte, count*12)
+	if _, err := this.r.ReadAt(buf, offset+2); err != nil {
+		return fmt.Errorf("读取 IFD 失败：%w", err)
+	}
+
+	for i := 0; i < count; i++ {
+		raw := buf[i*12 : i*12+12]
+		tag := this.order.Uint16(raw)
+		entry, err := this.readEntry(raw)
+		if err != nil {
+			return err
+		}
+		this.entries[tag] = entry
+	}
+	return nil
+}
+
+// readEntry 解析 IFD 标签的值，值超过 4 字节时从偏移处读取
+func (this *tiffReader) readEntry(raw []byte) (tiffEntry, error) {
+	entry := tiffEntry{Type: this.order.Uint16(raw[2:]), Count: this.order.Uint32(raw[4:])}
+	size := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 6: 1, 7: 1, 8: 2, 9: 4, 11: 4, 12: 8}[entry.Type]
+	if size == 0 {
+		return entry, nil
+	}
+
+	data := raw[8:12]
+	if total := size * int(entry.Count); total > 4 {
+		data = make([]byte, total)
+		if _, err := this.r.ReadAt(data, int64(this.order.Uint32(raw[8:]))); err != nil {
+			return entry, fmt.Errorf("读取 TIFF 标签失败：%w", err)
+		}
+	}
+
+	if entry.Type == 2 {
+		entry.Text = strings.TrimRight(string(data[:entry.Count]), "\x00")
+		return entry, nil
+	}
+	entry.Values = make([]float64, entry.Count)
+	for i := range entry.Values {
+		b := data[i*size:]
+		switch entry.Type {
+		case 1, 7:
+			entry.Values[i] = float64(b[0])
+		case 6:
+			entry.Values[i] = float64(int8(b[0]))
+		case 3:
+			entry.Values[i] = float64(this.order.Uint16(b))
+		case 8:
+			entry.Values[i] = float64(int16(this.order.Uint16(b)))
+		case 4:
+			entry.Values[i] = float64(this.order.Uint32(b))
+		case 9:
+			entry.Values[i] = float64(int32(this.order.Uint32(b)))
+		case 11:
+			entry.Values[i] = float64(math.Float32frombits(this.order.Uint32(b)))
+		case 12:
+			entry.Values[i] = math.Float64frombits(this.order.Uint64(b))
+		}
+	}
+	return entry, nil
+}
+
+// value 读取标签的第 index 个数值，标签不存在时返回默认值
+func (this *tiffReader) value(tag uint16, index int, defaultValue float64) float64 {
+	entry, ok := this.entries[tag]
+	if !ok || index >= len(entry.Values) {
+		return defaultValue
+	}
+	return entry.Values[index]
+}
+
+// bounds 根据 ModelTiepoint 和 ModelPixelScale 计算采样点格网
+func (this *tiffReader) bounds() (*grid, error) {
+	width := int(this.value(tagImageWidth, 0, 0))
+	height := int(this.value(tagImageLength, 0, 0))
+	if width < 2 || height < 2 {
+		return nil, fmt.Errorf("图像尺寸无效：%d×%d", width, height)
+	}
+	scale, okScale := this.entries[tagModelPixelScale]
+	tiepoint, okTiepoint := this.entries[tagModelTiepoint]
+	if !okScale || !okTiepoint || len(scale.Values) < 2 || len(tiepoint.Values) < 6 {
+		return nil, fmt.Errorf("缺少 GeoTIFF 地理参考信息")
+	}
+
+	g := &grid{DX: scale.Values[0], DY: scale.Values[1], Width: width, Height: height}
+	if g.DX <= 0 || g.DY <= 0 || g.DX > 1 || g.DY > 1 {
+		return nil, fmt.Errorf("仅支持经纬度坐标系的 GeoTIFF")
+	}
+	// 控制点 (i, j) 对应 (x, y)，换算为第 0 行第 0 列的位置
+	g.West = tiepoint.Values[3] - tiepoint.Values[0]*g.DX
+	g.North = tiepoint.Values[4] + tiepoint.Values[1]*g.DY
+	// 默认像素值代表像素区域，采样点位于像素中心
+	if this.rasterType() != rasterPixelIsPoint {
+		g.West += g.DX / 2
+		g.North -= g.DY / 2
+	}
+	return g, nil
+}
+
+// rasterType 从 GeoKeyDirectory 中读取栅格类型
+func (this *tiffReader) rasterType() int {
+	keys := this.entries[tagGeoKeyDirectory].Values
+	for i := 4; i+3 < len(keys); i += 4 {
+		if int(keys[i]) == geoKeyRasterType && keys[i+1] == 0 {
+			return int(keys[i+3])
+		}
+	}
+	return 0
+}
+
+// readData 读取并解码全部高程数据
+func (this *tiffReader) readData(width, height int) ([]float32, error) {
+	if samples := int(this.value(tagSamplesPerPixel, 0, 1)); samples != 1 {
+		return nil, fmt.Errorf("仅支持单波段 GeoTIFF，当前为%d个波段", samples)
+	}
+	compression := int(this.value(tagCompression, 0, compressionNone))
+	if compression != compressionNone && compression != compressionDeflate && compression != compressionDeflateOld {
+		return nil, fmt.Errorf("不支持的 TIFF 压缩方式：%d", compression)
+	}
+	predictor := int(this.value(tagPredictor, 0, 1))
+	bits := int(this.value(tagBitsPerSample, 0, 1))
+	format := int(this.value(tagSampleFormat, 0, sampleFormatUint))
+	if predictor != 1 && (predictor != 2 || format == sampleFormatFloat) {
+		return nil, fmt.Errorf("不支持的 TIFF 预测器：%d", predictor)
+	}
+
+	noData := math.NaN()
+	if text := strings.TrimSpace(this.entries[tagGDALNoData].Text); text != "" {
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			noData = v
+		}
+	}
+
+	// 条带视为宽度等于图像宽度的分块
+	chunkWidth, chunkHeight := width, int(this.value(tagRowsPerStrip, 0, float64(height)))
+	offsets, counts := this.entries[tagStripOffsets].Values, this.entries[tagStripByteCounts].Values
+	if _, ok := this.entries[tagTileOffsets]; ok {
+		chunkWidth, chunkHeight = int(this.value(tagTileWidth, 0, 0)), int(this.value(tagTileLength, 0, 0))
+		offsets, counts = this.entries[tagTileOffsets].Values, this.entries[tagTileByteCounts].Values
+	}
+	if chunkWidth <= 0 || chunkHeight <= 0 || len(offsets) == 0 || len(offsets) != len(counts) {
+		return nil, fmt.Errorf("TIFF 数据布局无效")
+	}
+	across := (width + chunkWidth - 1) / chunkWidth
+
+	data := make([]float32, width*height)
+	for i := range data {
+		data[i] = float32(math.NaN())
+	}
+	sampleSize := bits / 8
+	for index := range offsets {
+		raw := make([]byte, int(counts[index]))
+		if _, err := this.r.ReadAt(raw, int64(offsets[index])); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("读取 TIFF 数据失败：%w", err)
+		}
+		if compression != compressionNone {
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("解压 TIFF 数据失败：%w", err)
+			}
+			raw, err = io.ReadAll(zr)
+			zr.Close()
+			if err != nil {
+				return nil, fmt.Errorf("解压 TIFF 数据失败：%w", err)
+			}
+		}
+
+		values, err := decodeSamples(raw, this.order, bits, format)
+		if err != nil {
+			return nil, err
+		}
+		if predictor == 2 {
+			undoHorizontalPredictor(values, chunkWidth, sampleSize*8, format == sampleFormatInt)
+		}
+
+		col0, row0 := (index%across)*chunkWidth, (index/across)*chunkHeight
+		for i, v := range values {
+			col, row := col0+i%chunkWidth, row0+i/chunkWidth
+			if col >= width || row >= height {
+				continue
+			}
+			if v == noData || math.IsNaN(v) {
+				continue
+			}
+			data[row*width+col] = float32(v)
+		}
+	}
+	return data, nil
+}
+
+// decodeSamples 按采样位数和格式解码原始字节
+func decodeSamples(raw []byte, order binary.ByteOrder, bits, format int) ([]float64, error) {
+	size := bits / 8
+	if size == 0 || (format == sampleFormatFloat && size != 4 && size != 8) || size > 8 {
+		return nil, fmt.Errorf("不支持的采样格式：%d 位，格式%d", bits, format)
+	}
+	values := make([]float64, len(raw)/size)
+	for i := range values {
+		b := raw[i*size:]
+		var u uint64
+		switch size {
+		case 1:
+			u = uint64(b[0])
+		case 2:
+			u = uint64(order.Uint16(b))
+		case 4:
+			u = uint64(order.Uint32(b))
+		case 8:
+			u = order.Uint64(b)
+		default:
+			return nil, fmt.Errorf("不支持的采样位数：%d", bits)
+		}
+
+		switch {
+		case format == sampleFormatFloat && size == 4:
+			values[i] = float64(math.Float32frombits(uint32(u)))
+		case format == sampleFormatFloat:
+			values[i] = math.Float64frombits(u)
+		case format == sampleFormatInt:
+			values[i] = float64(signExtend(u, bits))
+		default:
+			values[i] = float64(u)
+		}
+	}
+	return values, nil
+}
+
+// undoHorizontalPredictor 还原水平差分预测（Predictor = 2），按整数位宽回绕
+func undoHorizontalPredictor(values []float64, rowWidth, bits int, signed bool) {
+	mask := uint64(1)<<bits - 1
+	for start := 0; start < len(values); start += rowWidth {
+		end := min(start+rowWidth, len(values))
+		acc := uint64(int64(values[start])) & mask
+		for i := start + 1; i < end; i++ {
+			acc = (acc + uint64(int64(values[i]))) & mask
+			if signed {
+				values[i] = float64(signExtend(acc, bits))
+			} else {
+				values[i] = float64(acc)
+			}
+		}
+	}
+}
+
+// signExtend 将 bits 位的补码整数扩展为 int64
+func signExtend(u uint64, bits int) int64 {
+	shift := 64 - bits
+	return int64(u<<shift) >> shift
+}
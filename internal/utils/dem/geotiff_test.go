@@ -0,0 +1,210 @@
+package dem
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// tiffTag 构造测试 TIFF 用的标签，Data 为按小端编码的值
+type tiffTag struct {
+	Type  uint16
+	Count uint32
+	Data  []byte
+}
+
+func shorts(values ...uint16) tiffTag {
+	data := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(data[i*2:], v)
+	}
+	return tiffTag{Type: 3, Count: uint32(len(values)), Data: data}
+}
+
+func longs(values ...uint32) tiffTag {
+	data := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], v)
+	}
+	return tiffTag{Type: 4, Count: uint32(len(values)), Data: data}
+}
+
+func doubles(values ...float64) tiffTag {
+	data := make([]byte, len(values)*8)
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[i*8:], math.Float64bits(v))
+	}
+	return tiffTag{Type: 12, Count: uint32(len(values)), Data: data}
+}
+
+func ascii(s string) tiffTag {
+	return tiffTag{Type: 2, Count: uint32(len(s) + 1), Data: append([]byte(s), 0)}
+}
+
+// writeTIFF 写出只有一个条带的小端 TIFF：文件头、IFD、超过 4 字节的标签值、像素数据
+func writeTIFF(t *testing.T, path string, tags map[uint16]tiffTag, pixels []byte) {
+	t.Helper()
+	var order []uint16
+	for tag := range tags {
+		order = append(order, tag)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+
+	ifdSize := 2 + 12*(len(order)+2) + 4
+	extraOffset := 8 + ifdSize
+	var ifd, extra bytes.Buffer
+	writeEntry := func(tag uint16, entry tiffTag) {
+		binary.Write(&ifd, binary.LittleEndian, tag)
+		binary.Write(&ifd, binary.LittleEndian, entry.Type)
+		binary.Write(&ifd, binary.LittleEndian, entry.Count)
+		if len(entry.Data) <= 4 {
+			ifd.Write(append(entry.Data, make([]byte, 4-len(entry.Data))...))
+			return
+		}
+		binary.Write(&ifd, binary.LittleEndian, uint32(extraOffset+extra.Len()))
+		extra.Write(entry.Data)
+	}
+
+	binary.Write(&ifd, binary.LittleEndian, uint16(len(order)+2))
+	for _, tag := range order {
+		if tag < tagStripOffsets {
+			writeEntry(tag, tags[tag])
+		}
+	}
+	// 像素数据紧跟在标签值之后，偏移在写完全部标签值后才能确定，先按标签值的总长度计算
+	extraSize := 0
+	for _, tag := range order {
+		if len(tags[tag].Data) > 4 {
+			extraSize += len(tags[tag].Data)
+		}
+	}
+	writeEntry(tagStripOffsets, longs(uint32(extraOffset+extraSize)))
+	for _, tag := range order {
+		if tag > tagStripOffsets && tag < tagStripByteCounts {
+			writeEntry(tag, tags[tag])
+		}
+	}
+	writeEntry(tagStripByteCounts, longs(uint32(len(pixels))))
+	for _, tag := range order {
+		if tag > tagStripByteCounts {
+			writeEntry(tag, tags[tag])
+		}
+	}
+	binary.Write(&ifd, binary.LittleEndian, uint32(0))
+
+	var file bytes.Buffer
+	file.WriteString("II")
+	binary.Write(&file, binary.LittleEndian, uint16(42))
+	binary.Write(&file, binary.LittleEndian, uint32(8))
+	file.Write(ifd.Bytes())
+	file.Write(extra.Bytes())
+	file.Write(pixels)
+	if err := os.WriteFile(path, file.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeoTIFF(t *testing.T) {
+	// 3×2 的 int16 高程，采样间隔 0.5°，第 0 行第 0 列的采样点位于 (31, 120)，-9999 为空值
+	values := []int16{
+		100, 200, 300,
+		400, -9999, 600,
+	}
+	raw := func(values []int16) []byte {
+		data := make([]byte, len(values)*2)
+		for i, v := range values {
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(v))
+		}
+		return data
+	}
+
+	// 水平差分后 Deflate 压缩
+	diffs := make([]int16, len(values))
+	for i, v := range values {
+		diffs[i] = v
+		if i%3 != 0 {
+			diffs[i] = v - values[i-1]
+		}
+	}
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	zw.Write(raw(diffs))
+	zw.Close()
+
+	cases := []struct {
+		Name   string
+		Tags   map[uint16]tiffTag
+		Pixels []byte
+	}{
+		{
+			"无压缩，像素点",
+			map[uint16]tiffTag{
+				tagCompression:     shorts(compressionNone),
+				tagModelTiepoint:   doubles(0, 0, 0, 120, 31, 0),
+				tagGeoKeyDirectory: shorts(1, 1, 0, 1, geoKeyRasterType, 0, 1, rasterPixelIsPoint),
+			},
+			raw(values),
+		},
+		{
+			"Deflate 压缩与水平差分，像素区域",
+			map[uint16]tiffTag{
+				tagCompression:   shorts(compressionDeflate),
+				tagPredictor:     shorts(2),
+				tagModelTiepoint: doubles(0, 0, 0, 119.75, 31.25, 0),
+			},
+			deflated.Bytes(),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			tags := map[uint16]tiffTag{
+				tagImageWidth:      shorts(3),
+				tagImageLength:     shorts(2),
+				tagBitsPerSample:   shorts(16),
+				tagSamplesPerPixel: shorts(1),
+				tagRowsPerStrip:    shorts(2),
+				tagSampleFormat:    shorts(sampleFormatInt),
+				tagModelPixelScale: doubles(0.5, 0.5, 0),
+				tagGDALNoData:      ascii("-9999"),
+			}
+			for tag, entry := range c.Tags {
+				tags[tag] = entry
+			}
+			dir := t.TempDir()
+			writeTIFF(t, filepath.Join(dir, "dem.tif"), tags, c.Pixels)
+
+			store, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkElevations(t, store, []sampleCase{
+				{31, 120, 100},
+				{30.5, 121, 600},
+				{31, 120.75, 250},
+				{30.75, 120.25, (100 + 200 + 400) / 3.0}, // 空值不参与插值
+				{30.5, 120.5, math.NaN()},
+				{31.2, 120, math.NaN()},
+			})
+		})
+	}
+}
+
+func TestGeoTIFFProjected(t *testing.T) {
+	// 投影坐标系的像素大小以米为单位，不支持
+	path := filepath.Join(t.TempDir(), "utm.tif")
+	writeTIFF(t, path, map[uint16]tiffTag{
+		tagImageWidth:      shorts(2),
+		tagImageLength:     shorts(2),
+		tagBitsPerSample:   shorts(16),
+		tagModelPixelScale: doubles(30, 30, 0),
+		tagModelTiepoint:   doubles(0, 0, 0, 500000, 3300000, 0),
+	}, make([]byte, 8))
+	if _, err := readGeoTIFF(path, false); err == nil {
+		t.Error("readGeoTIFF accepted a projected GeoTIFF")
+	}
+}
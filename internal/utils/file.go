@@ -6,7 +6,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	consts "steplife-universal-importer-gui/internal/const"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -111,3 +113,21 @@ func WriteCSV(filePath string, rows [][]string) error {
 	csvWriter.Flush()
 	return nil
 }
+
+// generatedOutputPattern 输出文件名（不含扩展名）的后缀，拆分输出时追加 _序号
+var generatedOutputPattern = regexp.MustCompile(`_(steplife|merged|gapfill)(_\d+)?$`)
+
+// IsGeneratedOutput
+//
+//	@Description: 	判断文件是否为输出目录中本程序生成的结果文件，扫描源目录时跳过；
+//	                	包括在缺口处拆分输出的 xxx_steplife_1.csv 等带序号的文件
+//	@param filePath
+//	@param outputDir
+//	@return bool
+func IsGeneratedOutput(filePath, outputDir string) bool {
+	if filepath.Dir(filePath) != filepath.Clean(outputDir) {
+		return false
+	}
+	baseName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return generatedOutputPattern.MatchString(baseName)
+}
@@ -0,0 +1,236 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+)
+
+// limit 交通方式对应的物理上限
+type limit struct {
+	MaxSpeed        float64 // 最大速度（米/秒）
+	MaxAcceleration float64 // 最大加速度（米/秒²）
+}
+
+var limits = map[string]limit{
+	consts.TransportWalk:   {MaxSpeed: 4, MaxAcceleration: 3},
+	consts.TransportBike:   {MaxSpeed: 20, MaxAcceleration: 4},
+	consts.TransportCar:    {MaxSpeed: 60, MaxAcceleration: 8},
+	consts.TransportRail:   {MaxSpeed: 100, MaxAcceleration: 3},
+	consts.TransportFlight: {MaxSpeed: 300, MaxAcceleration: 10},
+}
+
+// 连续被剔除的点数达到该值且彼此一致时，视为真实的位置跳变（如信号中断后恢复），重新以这些点为基准
+const maxConsecutiveDrops = 5
+
+// Dropped 被剔除的点及原因
+type Dropped struct {
+	Index  int // 在原始轨迹中的序号
+	Point  model.Point
+	Reason string
+}
+
+// RemoveOutliers
+//
+//	@Description: 		剔除速度或加速度超过交通方式上限的漂移点，只处理带时间的数据；
+//	                	以最前面一对彼此一致的点作为基准，其之前的点（开头的漂移）一并剔除
+//	@param points		按时间排序的轨迹点
+//	@param transport	交通方式：walk、bike、car、rail、flight
+//	@return []model.Point	保留的点
+//	@return []Dropped		被剔除的点
+//	@return error
+func RemoveOutliers(points []model.Point, transport string) ([]model.Point, []Dropped, error) {
+	lim, ok := limits[transport]
+	if !ok {
+		return nil, nil, fmt.Errorf("不支持的交通方式：%s", transport)
+	}
+	if len(points) < 2 || !hasTime(points) {
+		return points, nil, nil
+	}
+
+	start := anchor(points, lim)
+	var dropped []Dropped
+	for i := 0; i < start; i++ {
+		dropped = append(dropped, Dropped{Index: i, Point: points[i], Reason: "与之后的点不一致（起点漂移）"})
+	}
+
+	kept := []model.Point{points[start]}
+	lastSpeed := -1.0 // 负数表示尚无可参考的速度，不检查加速度
+	var pending []int // 连续被剔除的点在 points 中的序号
+
+	for i := start + 1; i < len(points); i++ {
+		last := kept[len(kept)-1]
+		reason, speed := check(last, points[i], lastSpeed, lim)
+		if reason == "" {
+			kept = append(kept, points[i])
+			lastSpeed = speed
+			pending = pending[:0]
+			continue
+		}
+
+		dropped = append(dropped, Dropped{Index: i, Point: points[i], Reason: reason})
+		pending = append(pending, i)
+		if len(pending) >= maxConsecutiveDrops && consistent(points, pending, lim) {
+			// 连续的点彼此一致，说明是真实跳变而不是漂移，恢复这些点
+			dropped = dropped[:len(dropped)-len(pending)]
+			for _, idx := range pending {
+				kept = append(kept, points[idx])
+			}
+			lastSpeed = -1
+			pending = pending[:0]
+		}
+	}
+	return kept, dropped, nil
+}
+
+// anchor 返回作为基准的点：最前面与下一个点之间速度在上限内的点。
+// 只在前 maxConsecutiveDrops 个点中查找，找不到时以第一个点为基准，避免把整段轨迹当作开头的漂移
+func anchor(points []model.Point, lim limit) int {
+	for i := 0; i+1 < len(points) && i < maxConsecutiveDrops; i++ {
+		if reason, _ := check(points[i], points[i+1], -1, lim); reason == "" {
+			return i
+		}
+	}
+	return 0
+}
+
+// check 检查从 last 到 current 的速度和加速度，返回剔除原因（为空表示正常）和速度（无法计算时为负数）
+func check(last, current model.Point, lastSpeed float64, lim limit) (string, float64) {
+	// 部分点没有时间时无法计算速度，保留该点，之后的点不再以它为参考检查加速度
+	if last.DataTime == 0 || current.DataTime == 0 {
+		return "", -1
+	}
+
+	dt := float64(current.DataTime - last.DataTime)
+	dist := pointcalc.Distance(last, current)
+	if dt <= 0 {
+		// 时间相同或回退的点，位置不同即视为异常
+		if dist > 0 {
+			return fmt.Sprintf("时间未递增（%d → %d）但位置移动%.1f米", last.DataTime, current.DataTime, dist), 0
+		}
+		return "", lastSpeed
+	}
+
+	speed := dist / dt
+	if speed > lim.MaxSpeed {
+		return fmt.Sprintf("速度%.1fm/s超过上限%.1fm/s", speed, lim.MaxSpeed), speed
+	}
+	// 急停同样受加速度上限约束
+	if acceleration := (speed - lastSpeed) / dt; lastSpeed >= 0 && math.Abs(acceleration) > lim.MaxAcceleration {
+		return fmt.Sprintf("加速度%.1fm/s²超过上限±%.1fm/s²", acceleration, lim.MaxAcceleration), speed
+	}
+	return "", speed
+}
+
+// consistent 判断一组连续点之间的速度是否都在上限内
+func consistent(points []model.Point, indexes []int, lim limit) bool {
+	for k := 1; k < len(indexes); k++ {
+		a, b := points[indexes[k-1]], points[indexes[k]]
+		dt := float64(b.DataTime - a.DataTime)
+		if dt <= 0 || pointcalc.Distance(a, b)/dt > lim.MaxSpeed {
+			return false
+		}
+	}
+	return true
+}
+
+// Timed 判断轨迹的每个点是否都带有时间
+func Timed(points []model.Point) bool {
+	for _, p := range points {
+		if p.DataTime == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTime 判断轨迹是否带有时间
+func hasTime(points []model.Point) bool {
+	for _, p := range points {
+		if p.DataTime != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MedianSmooth
+//
+//	@Description: 	对经纬度做滑动窗口中值滤波，首尾点保持不变
+//	@param points
+//	@param window	窗口大小，取奇数
+//	@return []model.Point
+func MedianSmooth(points []model.Point, window int) []model.Point {
+	if window < 3 {
+		window = 3
+	}
+	half := window / 2
+	result := make([]model.Point, len(points))
+	copy(result, points)
+
+	lats := make([]float64, 0, window)
+	lngs := make([]float64, 0, window)
+	for i := 1; i < len(points)-1; i++ {
+		lats, lngs = lats[:0], lngs[:0]
+		for j := max(0, i-half); j <= min(len(points)-1, i+half); j++ {
+			lats = append(lats, points[j].Latitude)
+			lngs = append(lngs, points[j].Longitude)
+		}
+		result[i].Latitude = median(lats)
+		result[i].Longitude = median(lngs)
+	}
+	return result
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// KalmanSmooth
+//
+//	@Description: 				对经纬度做卡尔曼滤波，过程噪声随时间间隔增大
+//	@param points
+//	@param accuracy				定位精度（米）
+//	@param processNoise			过程噪声（米/秒），一般取交通方式的典型速度
+//	@return []model.Point
+func KalmanSmooth(points []model.Point, accuracy float64, processNoise float64) []model.Point {
+	if len(points) == 0 {
+		return points
+	}
+	result := make([]model.Point, len(points))
+	copy(result, points)
+
+	// 以米为单位的方差，经纬度共用
+	variance := accuracy * accuracy
+	lat, lng := points[0].Latitude, points[0].Longitude
+	for i := 1; i < len(points); i++ {
+		// 无时间或时间相同的点按 1 秒间隔处理
+		dt := math.Max(1, float64(points[i].DataTime-points[i-1].DataTime))
+		variance += dt * processNoise * processNoise
+
+		gain := variance / (variance + accuracy*accuracy)
+		lat += gain * (points[i].Latitude - lat)
+		lng += gain * (points[i].Longitude - lng)
+		variance = (1 - gain) * variance
+
+		result[i].Latitude = lat
+		result[i].Longitude = lng
+	}
+	return result
+}
+
+// TypicalSpeed 交通方式的典型速度（米/秒），用作卡尔曼滤波的过程噪声
+func TypicalSpeed(transport string) float64 {
+	if lim, ok := limits[transport]; ok {
+		return math.Max(1, lim.MaxSpeed/3)
+	}
+	return 3
+}
@@ -0,0 +1,55 @@
+package filter
+
+import (
+	"slices"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+// northOf 从 (30, 120) 向北 meters 米的点，纬度 1° 约 111195 米
+func northOf(meters float64, dataTime int64) model.Point {
+	return model.Point{Latitude: 30 + meters/111195, Longitude: 120, DataTime: dataTime}
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Points  []model.Point
+		Dropped []int
+	}{
+		{
+			"速度超限",
+			[]model.Point{northOf(0, 100), northOf(3, 101), northOf(6, 102), northOf(500, 103), northOf(9, 104), northOf(12, 105)},
+			[]int{3},
+		},
+		{
+			"急停超过加速度上限",
+			[]model.Point{northOf(0, 100), northOf(3.9, 101), northOf(7.8, 102), northOf(8, 103), northOf(11.8, 104)},
+			[]int{3},
+		},
+		{
+			"部分点没有时间时保留",
+			[]model.Point{northOf(0, 100), northOf(50, 0), northOf(100, 0), northOf(150, 160), northOf(200, 190)},
+			nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			kept, dropped, err := RemoveOutliers(c.Points, consts.TransportWalk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var indexes []int
+			for _, d := range dropped {
+				indexes = append(indexes, d.Index)
+			}
+			if !slices.Equal(indexes, c.Dropped) {
+				t.Errorf("dropped = %v, want %v", dropped, c.Dropped)
+			}
+			if len(kept)+len(dropped) != len(c.Points) {
+				t.Errorf("kept %d + dropped %d != %d", len(kept), len(dropped), len(c.Points))
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package pointcalc
 import (
 	"github.com/kellydunn/golang-geo"
 	"math"
+	consts "steplife-universal-importer-gui/internal/const"
 	"steplife-universal-importer-gui/internal/model"
 )
 
@@ -24,15 +25,21 @@ func Calculate(previousPoint model.Point, currentPoint model.Point, spacing int)
 		return []model.Point{currentPoint}
 	}
 
+	// 任一端没有时间时插出的点也没有时间，由分配时间的阶段处理
+	timed := previousPoint.DataTime > 0 && currentPoint.DataTime > 0
 	var interpolatedPoints []model.Point
 	for i := 0; i < numPoints; i++ {
 		alpha := float64(i+1) / float64(numPoints+1)
 		// 可能时钟回拨，重置
-		if currentPoint.DataTime < previousPoint.DataTime {
+		if timed && currentPoint.DataTime < previousPoint.DataTime {
 			currentPoint.DataTime = previousPoint.DataTime + 1
 		}
+		var dataTime int64
+		if timed {
+			dataTime = previousPoint.DataTime + int64(alpha*(float64(currentPoint.DataTime-previousPoint.DataTime)))
+		}
 		newPoint := model.Point{
-			DataTime:  previousPoint.DataTime + int64(alpha*(float64(currentPoint.DataTime-previousPoint.DataTime))),
+			DataTime:  dataTime,
 			Altitude:  previousPoint.Altitude + alpha*(currentPoint.Altitude-previousPoint.Altitude),
 			Speed:     previousPoint.Speed + alpha*(currentPoint.Speed-previousPoint.Speed),
 			Latitude:  previousPoint.Latitude + alpha*(currentPoint.Latitude-previousPoint.Latitude),
@@ -43,3 +50,74 @@ func Calculate(previousPoint model.Point, currentPoint model.Point, spacing int)
 	interpolatedPoints = append(interpolatedPoints, currentPoint)
 	return interpolatedPoints
 }
+
+// Distance
+//
+//	@Description: 	计算两点间的球面距离
+//	@param p1
+//	@param p2
+//	@return float64	距离（米）
+func Distance(p1 model.Point, p2 model.Point) float64 {
+	return geo.NewPoint(p1.Latitude, p1.Longitude).GreatCircleDistance(geo.NewPoint(p2.Latitude, p2.Longitude)) * 1000
+}
+
+// FlightAltitude
+//
+//	@Description: 			飞行剖面海拔：起飞后爬升至巡航高度，降落前下降，爬升和下降各占航程的 20%（最多 150 公里）
+//	@param groundAltitude	该位置的地面海拔（米）
+//	@param fraction			在航程中的比例，0 为起点，1 为终点
+//	@param distance			航程（米）
+//	@return float64
+func FlightAltitude(groundAltitude, fraction, distance float64) float64 {
+	if distance <= 0 {
+		return groundAltitude
+	}
+	transition := math.Min(0.2, 150000/distance)
+	ratio := math.Max(0, math.Min(1, math.Min(fraction/transition, (1-fraction)/transition)))
+	return groundAltitude + ratio*(consts.FlightCruiseAltitude-groundAltitude)
+}
+
+// Bearing
+//
+//	@Description: 	计算从 p1 指向 p2 的初始方位角（正北为 0，顺时针）
+//	@param p1
+//	@param p2
+//	@return float64	方位角（度），范围 [0, 360)
+func Bearing(p1 model.Point, p2 model.Point) float64 {
+	lat1, lat2 := p1.Latitude*math.Pi/180, p2.Latitude*math.Pi/180
+	dLon := (p2.Longitude - p1.Longitude) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// GreatCircleFraction
+//
+//	@Description: 		沿大圆航线计算两点之间指定比例处的位置
+//	@param p1
+//	@param p2
+//	@param fraction		0 为起点，1 为终点
+//	@return float64		纬度
+//	@return float64		经度
+func GreatCircleFraction(p1 model.Point, p2 model.Point, fraction float64) (float64, float64) {
+	lat1, lon1 := p1.Latitude*math.Pi/180, p1.Longitude*math.Pi/180
+	lat2, lon2 := p2.Latitude*math.Pi/180, p2.Longitude*math.Pi/180
+
+	// 两点间的角距离
+	delta := 2 * math.Asin(math.Sqrt(math.Pow(math.Sin((lat2-lat1)/2), 2)+
+		math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)))
+	if delta == 0 {
+		return p1.Latitude, p1.Longitude
+	}
+
+	a := math.Sin((1-fraction)*delta) / math.Sin(delta)
+	b := math.Sin(fraction*delta) / math.Sin(delta)
+	x := a*math.Cos(lat1)*math.Cos(lon1) + b*math.Cos(lat2)*math.Cos(lon2)
+	y := a*math.Cos(lat1)*math.Sin(lon1) + b*math.Cos(lat2)*math.Sin(lon2)
+	z := a*math.Sin(lat1) + b*math.Sin(lat2)
+
+	lat := math.Atan2(z, math.Sqrt(x*x+y*y))
+	lon := math.Atan2(y, x)
+	return lat * 180 / math.Pi, lon * 180 / math.Pi
+}
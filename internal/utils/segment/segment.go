@@ -0,0 +1,106 @@
+package segment
+
+import (
+	"fmt"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"time"
+)
+
+// Boundary 轨迹段边界，即相邻两点之间的缺口
+type Boundary struct {
+	Index    int     // 新轨迹段第一个点的序号
+	Reason   string  // 判定为缺口的原因
+	Duration int64   // 两点之间的时间间隔（秒），无时间时为 0
+	Distance float64 // 两点之间的距离（米）
+}
+
+func (this Boundary) String() string {
+	return fmt.Sprintf("第%d个点之前：%s（间隔%s，距离%.2f公里）",
+		this.Index+1, this.Reason, time.Duration(this.Duration)*time.Second, this.Distance/1000)
+}
+
+// Detect
+//
+//	@Description: 		检测单个轨迹段内的缺口：时间间隔或距离超过阈值
+//	@param points
+//	@param maxDuration	时间间隔阈值（秒），0 表示不按时间判断，只对带时间的点生效
+//	@param maxDistance	距离阈值（米），0 表示不按距离判断
+//	@return []Boundary
+func Detect(points []model.Point, maxDuration int64, maxDistance float64) []Boundary {
+	var boundaries []Boundary
+	for i := 1; i < len(points); i++ {
+		if boundary, ok := Check(points[i-1], points[i], i, maxDuration, maxDistance); ok {
+			boundaries = append(boundaries, boundary)
+		}
+	}
+	return boundaries
+}
+
+// Check
+//
+//	@Description: 		判断相邻两点之间是否为缺口，供逐点处理时使用
+//	@param prev
+//	@param curr
+//	@param index		curr 的序号
+//	@param maxDuration	时间间隔阈值（秒），0 表示不按时间判断
+//	@param maxDistance	距离阈值（米），0 表示不按距离判断
+//	@return Boundary
+//	@return bool		是否为缺口
+func Check(prev, curr model.Point, index int, maxDuration int64, maxDistance float64) (Boundary, bool) {
+	boundary := Between(prev, curr, index, "")
+	switch {
+	case maxDuration > 0 && boundary.Duration > maxDuration:
+		boundary.Reason = "时间间隔超过阈值"
+	case maxDistance > 0 && boundary.Distance > maxDistance:
+		boundary.Reason = "距离超过阈值"
+	default:
+		return boundary, false
+	}
+	return boundary, true
+}
+
+// Between
+//
+//	@Description: 		两个相邻轨迹段之间的边界
+//	@param prev			前一段的最后一个点
+//	@param curr			后一段的第一个点
+//	@param index		后一段第一个点的序号
+//	@param reason
+//	@return Boundary
+func Between(prev, curr model.Point, index int, reason string) Boundary {
+	boundary := Boundary{Index: index, Reason: reason, Distance: pointcalc.Distance(prev, curr)}
+	if prev.DataTime > 0 && curr.DataTime > 0 {
+		boundary.Duration = curr.DataTime - prev.DataTime
+	}
+	return boundary
+}
+
+// Split
+//
+//	@Description: 		按边界将轨迹拆分为多段，返回的点均不带 SegmentStart 标记
+//	@param points
+//	@param boundaries	Detect 返回的边界
+//	@return [][]model.Point
+func Split(points []model.Point, boundaries []Boundary) [][]model.Point {
+	if len(points) == 0 {
+		return nil
+	}
+
+	parts := make([][]model.Point, 0, len(boundaries)+1)
+	start := 0
+	for _, boundary := range boundaries {
+		parts = append(parts, clearMarks(points[start:boundary.Index]))
+		start = boundary.Index
+	}
+	return append(parts, clearMarks(points[start:]))
+}
+
+func clearMarks(points []model.Point) []model.Point {
+	part := make([]model.Point, len(points))
+	for i, point := range points {
+		point.SegmentStart = false
+		part[i] = point
+	}
+	return part
+}
@@ -0,0 +1,183 @@
+package simplify
+
+import (
+	"container/heap"
+	"math"
+	"steplife-universal-importer-gui/internal/model"
+)
+
+const earthRadius = 6371000.0 // 地球半径（米）
+
+// vec 以首个点为原点的局部平面坐标（米）
+type vec struct {
+	X float64
+	Y float64
+}
+
+// project 使用等距圆柱投影将经纬度转换为以首个点为原点的平面坐标，轨迹范围内误差可忽略
+func project(points []model.Point) []vec {
+	if len(points) == 0 {
+		return nil
+	}
+	lat0 := points[0].Latitude * math.Pi / 180
+	lon0 := points[0].Longitude * math.Pi / 180
+	cosLat0 := math.Cos(lat0)
+
+	vs := make([]vec, len(points))
+	for i, p := range points {
+		vs[i] = vec{
+			X: earthRadius * (p.Longitude*math.Pi/180 - lon0) * cosLat0,
+			Y: earthRadius * (p.Latitude*math.Pi/180 - lat0),
+		}
+	}
+	return vs
+}
+
+// DouglasPeucker
+//
+//	@Description: 		Douglas-Peucker 算法简化轨迹，保留首尾点
+//	@param points
+//	@param tolerance	容差（米），点到简化线段的垂直距离小于该值时被移除
+//	@return []model.Point
+func DouglasPeucker(points []model.Point, tolerance float64) []model.Point {
+	if len(points) < 3 || tolerance <= 0 {
+		return points
+	}
+
+	vs := project(points)
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// 使用栈代替递归，避免超长轨迹导致栈溢出
+	type span struct{ first, last int }
+	stack := []span{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		maxDist, index := 0.0, -1
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(vs[i], vs[s.first], vs[s.last]); d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+		if index >= 0 && maxDist > tolerance {
+			keep[index] = true
+			stack = append(stack, span{s.first, index}, span{index, s.last})
+		}
+	}
+
+	result := make([]model.Point, 0, len(points))
+	for i, p := range points {
+		if keep[i] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Visvalingam
+//
+//	@Description: 		Visvalingam-Whyatt 算法简化轨迹，保留首尾点
+//	@param points
+//	@param tolerance	容差（米），依次移除有效面积最小的点，直到最小面积不小于 tolerance²
+//	@return []model.Point
+func Visvalingam(points []model.Point, tolerance float64) []model.Point {
+	if len(points) < 3 || tolerance <= 0 {
+		return points
+	}
+
+	vs := project(points)
+	n := len(points)
+	prev := make([]int, n)
+	next := make([]int, n)
+	removed := make([]bool, n)
+	for i := range points {
+		prev[i], next[i] = i-1, i+1
+	}
+
+	h := &areaHeap{}
+	entries := make([]*areaEntry, n)
+	for i := 1; i < n-1; i++ {
+		entries[i] = &areaEntry{index: i, area: triangleArea(vs[i-1], vs[i], vs[i+1])}
+		heap.Push(h, entries[i])
+	}
+
+	threshold := tolerance * tolerance
+	maxArea := 0.0
+	for h.Len() > 0 {
+		e := heap.Pop(h).(*areaEntry)
+		// 有效面积取被移除点面积的最大值，保证移除顺序单调
+		area := math.Max(e.area, maxArea)
+		if area >= threshold {
+			break
+		}
+		maxArea = area
+		removed[e.index] = true
+
+		p, q := prev[e.index], next[e.index]
+		next[p], prev[q] = q, p
+		for _, k := range []int{p, q} {
+			if k <= 0 || k >= n-1 {
+				continue
+			}
+			entries[k].area = triangleArea(vs[prev[k]], vs[k], vs[next[k]])
+			heap.Fix(h, entries[k].heapIndex)
+		}
+	}
+
+	result := make([]model.Point, 0, n)
+	for i, p := range points {
+		if !removed[i] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// segmentDistance 点 p 到线段 ab 的距离
+func segmentDistance(p, a, b vec) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
+
+func triangleArea(a, b, c vec) float64 {
+	return math.Abs((b.X-a.X)*(c.Y-a.Y)-(c.X-a.X)*(b.Y-a.Y)) / 2
+}
+
+type areaEntry struct {
+	index     int
+	area      float64
+	heapIndex int
+}
+
+// areaHeap 按有效面积排序的小顶堆
+type areaHeap []*areaEntry
+
+func (h areaHeap) Len() int { return len(h) }
+
+func (h areaHeap) Less(i, j int) bool { return h[i].area < h[j].area }
+
+func (h areaHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *areaHeap) Push(x interface{}) {
+	e := x.(*areaEntry)
+	e.heapIndex = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *areaHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,79 @@
+package staypoint
+
+import (
+	"fmt"
+	"steplife-universal-importer-gui/internal/model"
+	"steplife-universal-importer-gui/internal/utils/pointcalc"
+	"time"
+)
+
+// Collapse
+//
+//	@Description: 		检测停留点，并将每个停留点内抖动的点折叠为质心处的两个点（停留开始和结束），只处理带时间的数据
+//	@param points		按时间排序的轨迹点
+//	@param radius		停留半径（米），与停留起点的距离不超过该值的连续点视为同一停留
+//	@param minDuration	最短停留时间（秒）
+//	@return []model.Point	折叠后的轨迹点
+//	@return []model.Stop	检测到的停留点，名称中的时间按系统本地时区显示
+func Collapse(points []model.Point, radius float64, minDuration int64) ([]model.Point, []model.Stop) {
+	if len(points) < 2 || radius <= 0 || minDuration <= 0 {
+		return points, nil
+	}
+
+	var result []model.Point
+	var stops []model.Stop
+	i := 0
+	for i < len(points) {
+		j := i + 1
+		for j < len(points) && pointcalc.Distance(points[i], points[j]) <= radius {
+			j++
+		}
+
+		// points[i:j] 均在停留半径内
+		start, end := points[i].DataTime, points[j-1].DataTime
+		if j-i < 2 || start == 0 || end-start < minDuration {
+			result = append(result, points[i])
+			i++
+			continue
+		}
+
+		center := centroid(points[i:j])
+		stops = append(stops, model.Stop{
+			Name:      Name(len(stops)+1, start, end, time.Local),
+			Latitude:  center.Latitude,
+			Longitude: center.Longitude,
+			Altitude:  center.Altitude,
+			StartTime: start,
+			EndTime:   end,
+		})
+
+		// 停留期间保持在质心位置不动
+		arrive, leave := center, center
+		arrive.DataTime, leave.DataTime = start, end
+		arrive.Speed, leave.Speed = 0, 0
+		result = append(result, arrive, leave)
+		i = j
+	}
+	return result, stops
+}
+
+// Name 停留点名称，包含序号和按 loc 显示的起止时间
+func Name(n int, start, end int64, loc *time.Location) string {
+	return fmt.Sprintf("停留点%d（%s - %s）", n,
+		time.Unix(start, 0).In(loc).Format("01-02 15:04"), time.Unix(end, 0).In(loc).Format("15:04"))
+}
+
+// centroid 计算一组点的质心
+func centroid(points []model.Point) model.Point {
+	var c model.Point
+	for _, p := range points {
+		c.Latitude += p.Latitude
+		c.Longitude += p.Longitude
+		c.Altitude += p.Altitude
+	}
+	n := float64(len(points))
+	c.Latitude /= n
+	c.Longitude /= n
+	c.Altitude /= n
+	return c
+}
@@ -0,0 +1,260 @@
+package timeUtils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datePattern 年月日在前的日期时间：2024-05-01、2024/5/1 08:30、2024-05-01T08:30:00.123+08:00、20240501T083000Z
+var datePattern = regexp.MustCompile(`^(\d{4})(?:[-/.](\d{1,2})[-/.](\d{1,2})|(\d{2})(\d{2}))` +
+	`(?:[T\s]+(\d{1,2})(?::?(\d{2}))?(?::?(\d{2}))?(?:[.,](\d{1,9}))?)?` +
+	`\s*(Z|UTC|GMT|[+-]\d{2}(?::?\d{2})?)?$`)
+
+// compactPattern 紧凑的纯数字日期时间：20240501、20240501083000
+var compactPattern = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})(?:(\d{2})(\d{2})(\d{2}))?$`)
+
+// epochPattern 纯数字时间戳，可带小数；至少 9 位整数（1973 年以后的秒级时间戳），避免把 2024 这类年份当作时间戳
+var epochPattern = regexp.MustCompile(`^-?\d{9,}(?:\.\d+)?$`)
+
+// chineseReplacer 将中文日期时间转换为数字分隔的格式，如 2024年5月1日 08时30分 → 2024-5-1 08:30:
+var chineseReplacer = strings.NewReplacer(
+	"年", "-", "月", "-", "日", " ", "号", " ",
+	"时", ":", "点", ":", "分", ":", "秒", "",
+	"：", ":", "　", " ",
+)
+
+// dayPeriod 中文时段，决定 12 小时制的小时如何换算
+type dayPeriod int
+
+const (
+	periodNone  dayPeriod = iota // 未指定时段，按 24 小时制
+	periodAM                     // 凌晨、早上、上午：12 点为 0 点
+	periodPM                     // 中午、下午：1~11 点加 12 小时
+	periodNight                  // 晚上：1~11 点加 12 小时，12 点为次日 0 点
+)
+
+// fallbackLayouts 其它带时区名称的常见格式
+var fallbackLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.ANSIC,
+}
+
+// Parse
+//
+//	@Description: 	解析时间字符串，支持：
+//	                	- 秒、毫秒、微秒、纳秒时间戳，秒级时间戳可带小数
+//	                	- 年月日在前的日期时间，分隔符为 - / . 或紧凑的 ISO 8601 格式，可带小数秒
+//	                	- Z、UTC、GMT、±hh、±hhmm、±hh:mm 时区标记
+//	                	- 中文日期，如 2024年5月1日 08时30分、2024年5月1日 下午3点
+//	                	- RFC 1123、RFC 822 等带时区名称的格式
+//	@param timeStr	时间字符串
+//	@param loc		不带时区信息时使用的时区；夏令时重叠的本地时间取较早的时刻，跳过的本地时间按切换前的偏移换算
+//	@return time.Time
+//	@return bool	时间字符串本身是否确定了时刻（带时区标记或为时间戳）
+//	@return error
+func Parse(timeStr string, loc *time.Location) (time.Time, bool, error) {
+	s := strings.TrimSpace(timeStr)
+	if s == "" {
+		return time.Time{}, false, fmt.Errorf("时间字符串为空")
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	// 8 位或 14 位纯数字优先按紧凑日期解析，年份不合理时再按时间戳解析
+	if m := compactPattern.FindStringSubmatch(s); m != nil {
+		if year, _ := strconv.Atoi(m[1]); year >= 1900 && year <= 2100 {
+			if t, hasZone, err := parseDate([]string{s, m[1], "", "", m[2], m[3], m[4], m[5], m[6], "", ""}, periodNone, loc, timeStr); err == nil {
+				return t, hasZone, nil
+			}
+		}
+	}
+	if epochPattern.MatchString(s) {
+		t, err := parseEpoch(s)
+		return t, true, err
+	}
+
+	normalized, period := normalizeChinese(s)
+	if m := datePattern.FindStringSubmatch(normalized); m != nil {
+		return parseDate(m, period, loc, timeStr)
+	}
+
+	for _, layout := range fallbackLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("无法解析时间字符串: %s", timeStr)
+}
+
+// parseEpoch 按数值大小判断时间戳单位
+func parseEpoch(s string) (time.Time, error) {
+	if strings.Contains(s, ".") {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("无法解析时间戳: %s", s)
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))), nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法解析时间戳: %s", s)
+	}
+	abs := v
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1e17:
+		return time.Unix(0, v), nil
+	case abs >= 1e14:
+		return time.UnixMicro(v), nil
+	case abs >= 1e11:
+		return time.UnixMilli(v), nil
+	default:
+		return time.Unix(v, 0), nil
+	}
+}
+
+// normalizeChinese 转换中文日期时间，返回转换后的字符串和上午/下午等时段
+func normalizeChinese(s string) (string, dayPeriod) {
+	period := periodNone
+	for _, p := range []struct {
+		Word   string
+		Period dayPeriod
+	}{
+		{"凌晨", periodAM}, {"早上", periodAM}, {"上午", periodAM},
+		{"中午", periodPM}, {"下午", periodPM}, {"晚上", periodNight},
+	} {
+		if strings.Contains(s, p.Word) {
+			s = strings.Replace(s, p.Word, " ", 1)
+			period = p.Period
+			break
+		}
+	}
+	s = chineseReplacer.Replace(s)
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.TrimRight(s, ":"), period
+}
+
+// parseDate 由 datePattern 的匹配结果构造时间
+func parseDate(m []string, period dayPeriod, loc *time.Location, original string) (time.Time, bool, error) {
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	year := atoi(m[1])
+	month, day := atoi(m[2]), atoi(m[3])
+	if m[2] == "" {
+		month, day = atoi(m[4]), atoi(m[5])
+	}
+	hour, minute, second := atoi(m[6]), atoi(m[7]), atoi(m[8])
+	nanos := 0
+	if m[9] != "" {
+		nanos = atoi((m[9] + "000000000")[:9])
+	}
+
+	// 12 小时制
+	nextDay := false
+	switch {
+	case period == periodAM && hour == 12:
+		hour = 0
+	case period == periodNight && hour == 12:
+		hour, nextDay = 0, true
+	case (period == periodPM || period == periodNight) && hour < 12:
+		hour += 12
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > daysIn(year, month) ||
+		hour > 23 || minute > 59 || second > 60 {
+		return time.Time{}, false, fmt.Errorf("时间超出范围: %s", original)
+	}
+	if nextDay {
+		// time.Date 会把超出月末的日期顺延到下个月
+		day++
+	}
+
+	if zone := m[10]; zone != "" {
+		offset, err := parseOffset(zone)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("无法解析时区偏移: %s", original)
+		}
+		return time.Date(year, time.Month(month), day, hour, minute, second, nanos, time.FixedZone("", offset)), true, nil
+	}
+	return resolveLocal(year, time.Month(month), day, hour, minute, second, nanos, loc), false, nil
+}
+
+// parseOffset 解析 Z、UTC、GMT、±hh、±hhmm、±hh:mm，返回相对 UTC 的秒数
+func parseOffset(zone string) (int, error) {
+	switch strings.ToUpper(zone) {
+	case "Z", "UTC", "GMT":
+		return 0, nil
+	}
+	sign := 1
+	if zone[0] == '-' {
+		sign = -1
+	}
+	digits := strings.ReplaceAll(zone[1:], ":", "")
+	hours, err := strconv.Atoi(digits[:2])
+	if err != nil {
+		return 0, err
+	}
+	minutes := 0
+	if len(digits) == 4 {
+		if minutes, err = strconv.Atoi(digits[2:]); err != nil {
+			return 0, err
+		}
+	}
+	if hours > 14 || minutes > 59 {
+		return 0, fmt.Errorf("时区偏移超出范围: %s", zone)
+	}
+	return sign * (hours*3600 + minutes*60), nil
+}
+
+// resolveLocal 将本地墙上时间换算为时刻：夏令时结束时重复的时间取较早的时刻，夏令时开始时跳过的时间按切换前的偏移换算
+func resolveLocal(year int, month time.Month, day, hour, minute, second, nanos int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, minute, second, nanos, time.UTC)
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	var candidates []time.Time
+	for _, offset := range []int{before, after} {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if sameWall(t, wall) {
+			candidates = append(candidates, t)
+		}
+	}
+	switch {
+	case len(candidates) == 2 && candidates[1].Before(candidates[0]):
+		return candidates[1]
+	case len(candidates) > 0:
+		return candidates[0]
+	default:
+		return wall.Add(-time.Duration(before) * time.Second).In(loc)
+	}
+}
+
+// sameWall 两个时间的墙上时间是否一致
+func sameWall(t, wall time.Time) bool {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := wall.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		t.Hour() == wall.Hour() && t.Minute() == wall.Minute() && t.Second() == wall.Second()
+}
+
+// daysIn 指定年月的天数
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
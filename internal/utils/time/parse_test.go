@@ -0,0 +1,121 @@
+package timeUtils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// parseCase 一个时间字符串及期望解析出的时刻
+type parseCase struct {
+	Name     string
+	Input    string
+	Want     time.Time
+	WantZone bool
+}
+
+func runParseCases(t *testing.T, loc *time.Location, cases []parseCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			got, hasZone, err := Parse(c.Input, loc)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", c.Input, err)
+			}
+			if !got.Equal(c.Want) {
+				t.Errorf("Parse(%q) = %s, want %s", c.Input, got.UTC(), c.Want.UTC())
+			}
+			if hasZone != c.WantZone {
+				t.Errorf("Parse(%q) hasZone = %v, want %v", c.Input, hasZone, c.WantZone)
+			}
+		})
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("加载时区 %s 失败: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseEpoch(t *testing.T) {
+	runParseCases(t, time.UTC, []parseCase{
+		{"秒", "1714550400", time.Unix(1714550400, 0), true},
+		{"毫秒", "1714550400123", time.UnixMilli(1714550400123), true},
+		{"微秒", "1714550400123456", time.UnixMicro(1714550400123456), true},
+		{"纳秒", "1714550400123456789", time.Unix(0, 1714550400123456789), true},
+		{"小数秒", "1714550400.75", time.Unix(1714550400, 750000000), true},
+		{"9 位秒", "999999999", time.Unix(999999999, 0), true},
+	})
+}
+
+func TestParseNotEpoch(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	runParseCases(t, shanghai, []parseCase{
+		{"紧凑日期", "20240501", time.Date(2024, 5, 1, 0, 0, 0, 0, shanghai), false},
+		{"紧凑日期时间", "20240501083000", time.Date(2024, 5, 1, 8, 30, 0, 0, shanghai), false},
+	})
+
+	for _, input := range []string{"2024", "12345678", "20241399"} {
+		if got, _, err := Parse(input, shanghai); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", input, got)
+		}
+	}
+}
+
+func TestParseISO(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	runParseCases(t, shanghai, []parseCase{
+		{"不带时区", "2024-05-01 08:30:00", time.Date(2024, 5, 1, 8, 30, 0, 0, shanghai), false},
+		{"斜杠分隔", "2024/5/1 8:30", time.Date(2024, 5, 1, 8, 30, 0, 0, shanghai), false},
+		{"点分隔", "2024.05.01", time.Date(2024, 5, 1, 0, 0, 0, 0, shanghai), false},
+		{"小数秒", "2024-05-01T08:30:00.123", time.Date(2024, 5, 1, 8, 30, 0, 123000000, shanghai), false},
+		{"纳秒", "2024-05-01T08:30:00.123456789Z", time.Date(2024, 5, 1, 8, 30, 0, 123456789, time.UTC), true},
+		{"小数秒带偏移", "2024-05-01T08:30:00.5+08:00", time.Date(2024, 5, 1, 0, 30, 0, 500000000, time.UTC), true},
+		{"Z", "2024-05-01T08:30:00Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), true},
+		{"±hhmm", "2024-05-01 08:30:00 +0800", time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC), true},
+		{"±hh", "2024-05-01T08:30:00-05", time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC), true},
+		{"半小时偏移", "2024-05-01T08:30:00+05:30", time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC), true},
+		{"紧凑 ISO", "20240501T083000Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), true},
+	})
+}
+
+func TestParseChinese(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	runParseCases(t, shanghai, []parseCase{
+		{"时分", "2024年5月1日 08时30分", time.Date(2024, 5, 1, 8, 30, 0, 0, shanghai), false},
+		{"上午", "2024年5月1日 上午9点", time.Date(2024, 5, 1, 9, 0, 0, 0, shanghai), false},
+		{"上午 12 点", "2024年5月1日 上午12点", time.Date(2024, 5, 1, 0, 0, 0, 0, shanghai), false},
+		{"凌晨", "2024年5月1日 凌晨0点05分", time.Date(2024, 5, 1, 0, 5, 0, 0, shanghai), false},
+		{"中午 12 点", "2024年5月1日 中午12点", time.Date(2024, 5, 1, 12, 0, 0, 0, shanghai), false},
+		{"下午", "2024年5月1日 下午3点", time.Date(2024, 5, 1, 15, 0, 0, 0, shanghai), false},
+		{"下午 12 点", "2024年5月1日 下午12点30分", time.Date(2024, 5, 1, 12, 30, 0, 0, shanghai), false},
+		{"晚上", "2024年5月1日 晚上8点", time.Date(2024, 5, 1, 20, 0, 0, 0, shanghai), false},
+		{"晚上 12 点", "2024年5月1日 晚上12点", time.Date(2024, 5, 2, 0, 0, 0, 0, shanghai), false},
+		{"晚上 12 点跨月", "2024年5月31日 晚上12点", time.Date(2024, 6, 1, 0, 0, 0, 0, shanghai), false},
+	})
+}
+
+func TestParseFallbackLayouts(t *testing.T) {
+	runParseCases(t, time.UTC, []parseCase{
+		{"RFC 1123Z", "Wed, 01 May 2024 08:30:00 +0800", time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC), true},
+		{"RFC 1123", "Wed, 01 May 2024 08:30:00 GMT", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), true},
+		{"RFC 822Z", "01 May 24 08:30 +0800", time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC), true},
+		{"RFC 822", "01 May 24 08:30 UTC", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), true},
+	})
+}
+
+func TestParseDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	runParseCases(t, newYork, []parseCase{
+		// 2024-11-03 01:30 出现两次（EDT、EST），取较早的 EDT
+		{"重叠取较早", "2024-11-03 01:30:00", time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), false},
+		// 2024-03-10 02:30 被跳过，按切换前的 EST 换算，即 EDT 03:30
+		{"跳过按切换前偏移", "2024-03-10 02:30:00", time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), false},
+		{"夏令时", "2024-07-01 12:00:00", time.Date(2024, 7, 1, 16, 0, 0, 0, time.UTC), false},
+		{"标准时", "2024-01-01 12:00:00", time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC), false},
+	})
+}
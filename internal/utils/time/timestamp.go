@@ -2,7 +2,7 @@ package timeUtils
 
 import (
 	"fmt"
-	"strings"
+	consts "steplife-universal-importer-gui/internal/const"
 	"time"
 )
 
@@ -20,48 +20,55 @@ func ToTimestamp(timeStr string) (int64, error) {
 //
 //	@Description:
 //	@param timeStr	时间字符串
-//	@param timezone	时区名称，如 "Asia/Shanghai"，空字符串表示使用系统本地时区
+//	@param timezone	时区名称，如 "Asia/Shanghai"，空字符串或 "auto" 表示使用系统本地时区
 //	@return int64	时间戳
 //	@return error
 func ToTimestampWithTimezone(timeStr string, timezone string) (int64, error) {
-	// 常见时间格式列表，从最精确到最简单
-	layouts := []string{
-		time.RFC3339,          // 2024-01-03T03:53:22Z
-		"2006-01-02 15:04:05", // 2020-10-20 16:49:00
-		"2006-01-02 15:04",    // 2020-10-20 16:49
-		"2006-01-02",          // 2020-10-20
-		"2006/01/02 15:04:05", // 2020/10/20 16:49:00
-		"2006/01/02 15:04",    // 2020/10/20 16:49
-		"2006/01/02",          // 2020/10/20
+	loc, err := Location(timezone)
+	if err != nil {
+		return 0, err
 	}
+	t, _, err := Parse(timeStr, loc)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
 
-	timeStr = strings.TrimSpace(timeStr)
-	var t time.Time
-	var err error
-	var loc *time.Location
-
-	// 确定使用的时区
-	if timezone != "" {
-		loc, err = time.LoadLocation(timezone)
-		if err != nil {
-			return 0, fmt.Errorf("无效的时区: %s", timezone)
-		}
-	} else {
-		loc = time.Local
+// Location
+//
+//	@Description: 		加载时区，空字符串表示系统本地时区；"auto" 时由转换流水线按轨迹坐标推断，这里同样使用系统本地时区
+//	@param timezone		时区名称，如 "Asia/Shanghai"
+//	@return *time.Location
+//	@return error
+func Location(timezone string) (*time.Location, error) {
+	if timezone == "" || timezone == consts.TimezoneAuto {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", timezone)
 	}
+	return loc, nil
+}
 
-	for _, layout := range layouts {
-		// 如果时间中含有Z或T等 UTC 标记，默认使用 time.Parse（UTC）
-		if strings.ContainsAny(timeStr, "TZ") {
-			t, err = time.Parse(layout, timeStr)
-		} else {
-			// 使用指定的时区或本地时区
-			t, err = time.ParseInLocation(layout, timeStr, loc)
-		}
-		if err == nil {
-			return t.Unix(), nil
-		}
+// ParseTimestamp
+//
+//	@Description: 	解析轨迹文件中的时间字符串，不带时区信息的时间先按 UTC 解析为墙上时间，由调用方通过 Localize 按实际时区换算
+//	@param timeStr	时间字符串
+//	@return int64	时间戳
+//	@return bool	时间是否不带时区信息
+//	@return error
+func ParseTimestamp(timeStr string) (int64, bool, error) {
+	t, hasZone, err := Parse(timeStr, time.UTC)
+	if err != nil {
+		return 0, false, err
 	}
+	return t.Unix(), !hasZone, nil
+}
 
-	return 0, fmt.Errorf("无法解析时间字符串: %s", timeStr)
+// Localize 将按 UTC 解析的墙上时间换算为指定时区的时间戳，夏令时的处理与 Parse 一致
+func Localize(timestamp int64, loc *time.Location) int64 {
+	t := time.Unix(timestamp, 0).UTC()
+	return resolveLocal(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc).Unix()
 }
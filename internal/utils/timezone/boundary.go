@@ -0,0 +1,130 @@
+package timezone
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	tzfrellite "github.com/ringsaturn/tzf-rel-lite"
+	tzfpb "github.com/ringsaturn/tzf/gen/go/tzf/v1"
+	"github.com/twpayne/go-polyline"
+	"google.golang.org/protobuf/proto"
+)
+
+// zone 一个时区的边界
+type zone struct {
+	name     string
+	polygons []polygon
+}
+
+// polygon 时区边界中的一个多边形，坐标为 [经度, 纬度]
+type polygon struct {
+	exterior []float32
+	holes    [][]float32
+	// 外环的外接矩形，用于快速排除
+	minLon, minLat, maxLon, maxLat float32
+}
+
+// contains 点是否在多边形内（不在任何洞内）
+func (this *polygon) contains(lon, lat float32) bool {
+	if lon < this.minLon || lon > this.maxLon || lat < this.minLat || lat > this.maxLat {
+		return false
+	}
+	if !ringContains(this.exterior, lon, lat) {
+		return false
+	}
+	for _, hole := range this.holes {
+		if ringContains(hole, lon, lat) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains 射线法判断点是否在环内，ring 为依次排列的经度、纬度
+func ringContains(ring []float32, lon, lat float32) bool {
+	inside := false
+	n := len(ring) / 2
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := ring[2*i], ring[2*i+1]
+		xj, yj := ring[2*j], ring[2*j+1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// loadZones
+//
+//	@Description: 	解码 tzf-rel-lite 中经 Google Polyline 压缩的时区边界，按 tzf 生成的 protobuf 结构解析
+//	@return []zone	按时区名称的字母顺序排列
+//	@return error
+func loadZones() ([]zone, error) {
+	var data tzfpb.CompressedTimezones
+	if err := proto.Unmarshal(tzfrellite.LiteCompressData, &data); err != nil {
+		return nil, fmt.Errorf("时区边界数据损坏：%w", err)
+	}
+	if data.GetMethod() != tzfpb.CompressMethod_COMPRESS_METHOD_POLYLINE {
+		return nil, fmt.Errorf("不支持的压缩方式：%s", data.GetMethod())
+	}
+
+	zones := make([]zone, 0, len(data.GetTimezones()))
+	for _, tz := range data.GetTimezones() {
+		z := zone{name: tz.GetName()}
+		for _, compressed := range tz.GetData() {
+			p, err := readPolygon(compressed)
+			if err != nil {
+				return nil, fmt.Errorf("时区 %s 边界数据损坏：%w", z.name, err)
+			}
+			z.polygons = append(z.polygons, p)
+		}
+		zones = append(zones, z)
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("时区边界数据为空")
+	}
+	slices.SortStableFunc(zones, func(a, b zone) int { return strings.Compare(a.name, b.name) })
+	return zones, nil
+}
+
+// readPolygon 解压多边形的外环和洞
+func readPolygon(compressed *tzfpb.CompressedPolygon) (polygon, error) {
+	var p polygon
+	var err error
+	if p.exterior, err = decodeRing(compressed.GetPoints()); err != nil {
+		return p, err
+	}
+	for _, hole := range compressed.GetHoles() {
+		ring, err := decodeRing(hole.GetPoints())
+		if err != nil {
+			return p, err
+		}
+		p.holes = append(p.holes, ring)
+	}
+
+	p.minLon, p.minLat = math.MaxFloat32, math.MaxFloat32
+	p.maxLon, p.maxLat = -math.MaxFloat32, -math.MaxFloat32
+	for i := 0; i+1 < len(p.exterior); i += 2 {
+		p.minLon, p.maxLon = min(p.minLon, p.exterior[i]), max(p.maxLon, p.exterior[i])
+		p.minLat, p.maxLat = min(p.minLat, p.exterior[i+1]), max(p.maxLat, p.exterior[i+1])
+	}
+	return p, nil
+}
+
+// decodeRing 解码 Google Polyline 编码的环，tzf 按 [经度, 纬度] 的顺序编码
+func decodeRing(data []byte) ([]float32, error) {
+	coords, rest, err := polyline.DecodeCoords(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("多余的 %d 字节", len(rest))
+	}
+	ring := make([]float32, 0, 2*len(coords))
+	for _, coord := range coords {
+		ring = append(ring, float32(coord[0]), float32(coord[1]))
+	}
+	return ring, nil
+}
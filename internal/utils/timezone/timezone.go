@@ -0,0 +1,77 @@
+package timezone
+
+import (
+	"fmt"
+	"math"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/utils/logx"
+	timeUtils "steplife-universal-importer-gui/internal/utils/time"
+	"sync"
+	"time"
+	_ "time/tzdata" // 内嵌时区数据库，保证推断出的时区在没有系统时区数据的平台上也能加载
+)
+
+var (
+	zonesOnce sync.Once
+	zones     []zone
+)
+
+// load 加载内嵌的时区边界数据：timezone-boundary-builder（ODbL）发布的含海洋时区边界，经 tzf-rel-lite 简化压缩
+func load() {
+	var err error
+	if zones, err = loadZones(); err != nil {
+		logx.ErrorF("时区边界数据加载失败，按经度推断时区：%v", err)
+		zones = nil
+	}
+}
+
+// Infer
+//
+//	@Description: 	根据坐标离线推断 IANA 时区：按 timezone-boundary-builder 的时区边界查找，
+//	                	海上返回对应的 Etc/GMT 时区，边界数据不可用时按经度使用理论时区
+//	@param lat
+//	@param lon
+//	@return string	时区名称，如 "Asia/Shanghai"；多个时区重叠时取名称字母顺序的第一个
+func Infer(lat, lon float64) string {
+	zonesOnce.Do(load)
+
+	for i := range zones {
+		for j := range zones[i].polygons {
+			if zones[i].polygons[j].contains(float32(lon), float32(lat)) {
+				return zones[i].name
+			}
+		}
+	}
+	return nauticalZone(lon)
+}
+
+// LocationAt
+//
+//	@Description: 		展示某个位置的时间时使用的时区：配置为自动推断时按该位置的坐标推断，时区无效时使用系统本地时区
+//	@param zone		配置的时区，空字符串表示系统本地时区
+//	@param lat
+//	@param lon
+//	@return *time.Location
+func LocationAt(zone string, lat, lon float64) *time.Location {
+	if zone == consts.TimezoneAuto {
+		zone = Infer(lat, lon)
+	}
+	loc, err := timeUtils.Location(zone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// nauticalZone 按经度每 15° 划分的理论时区，注意 Etc/GMT 的符号与 UTC 偏移相反
+func nauticalZone(lon float64) string {
+	offset := int(math.Round(lon / 15))
+	switch {
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", min(offset, 12))
+	case offset < 0:
+		return fmt.Sprintf("Etc/GMT+%d", min(-offset, 12))
+	default:
+		return "UTC"
+	}
+}
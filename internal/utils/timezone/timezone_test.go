@@ -0,0 +1,78 @@
+package timezone
+
+import (
+	"slices"
+	"testing"
+
+	tzfrellite "github.com/ringsaturn/tzf-rel-lite"
+	tzfpb "github.com/ringsaturn/tzf/gen/go/tzf/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestInfer(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Lat, Lon float64
+		Want     string
+	}{
+		{"上海", 31.23, 121.47, "Asia/Shanghai"},
+		{"加尔各答", 22.57, 88.36, "Asia/Kolkata"},
+		{"东京", 35.68, 139.69, "Asia/Tokyo"},
+		{"纽约", 40.71, -74.01, "America/New_York"},
+		{"伦敦", 51.51, -0.13, "Europe/London"},
+		{"悉尼", -33.87, 151.21, "Australia/Sydney"},
+		{"太平洋", 0, -150, "Etc/GMT+10"},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if got := Infer(c.Lat, c.Lon); got != c.Want {
+				t.Errorf("Infer(%v, %v) = %s, want %s", c.Lat, c.Lon, got, c.Want)
+			}
+		})
+	}
+}
+
+// TestBoundaryData tzf-rel-lite 升级后数据格式变化（新增字段、压缩方式或坐标顺序改变）时失败
+func TestBoundaryData(t *testing.T) {
+	var data tzfpb.CompressedTimezones
+	if err := proto.Unmarshal(tzfrellite.LiteCompressData, &data); err != nil {
+		t.Fatal(err)
+	}
+	if unknown := data.ProtoReflect().GetUnknown(); len(unknown) > 0 {
+		t.Errorf("CompressedTimezones 有 %d 字节未知字段", len(unknown))
+	}
+	for _, tz := range data.GetTimezones() {
+		if len(tz.ProtoReflect().GetUnknown()) > 0 {
+			t.Fatalf("时区 %s 有未知字段", tz.GetName())
+		}
+		for _, p := range tz.GetData() {
+			if len(p.ProtoReflect().GetUnknown()) > 0 {
+				t.Fatalf("时区 %s 的多边形有未知字段", tz.GetName())
+			}
+		}
+	}
+
+	zones, err := loadZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) < 300 {
+		t.Errorf("len(zones) = %d, want at least 300", len(zones))
+	}
+	names := make([]string, 0, len(zones))
+	for _, z := range zones {
+		names = append(names, z.name)
+		if len(z.polygons) == 0 {
+			t.Errorf("时区 %s 没有边界", z.name)
+		}
+		for _, p := range z.polygons {
+			if len(p.exterior) < 6 || p.minLon < -180 || p.maxLon > 180 || p.minLat < -90 || p.maxLat > 90 {
+				t.Fatalf("时区 %s 的边界无效：%d 个坐标，经度 [%v, %v]，纬度 [%v, %v]",
+					z.name, len(p.exterior)/2, p.minLon, p.maxLon, p.minLat, p.maxLat)
+			}
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Error("时区没有按名称排序，多个时区重叠时的结果不确定")
+	}
+}
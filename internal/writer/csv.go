@@ -0,0 +1,49 @@
+package writer
+
+import (
+	"encoding/csv"
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+)
+
+// CSVWriter 逐行写出一生足迹 CSV，不在内存中保留已写出的轨迹行
+type CSVWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+	count         int
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w)}
+}
+
+// WriteRow 写出一行，第一次写出前先写表头
+func (this *CSVWriter) WriteRow(row model.Row) error {
+	if err := this.writeHeader(); err != nil {
+		return err
+	}
+	this.count++
+	return this.writer.Write(model.FormatCSVRow(row))
+}
+
+// Count 已写出的行数
+func (this *CSVWriter) Count() int {
+	return this.count
+}
+
+// Flush 写出缓冲区，没有任何行时也会写出表头
+func (this *CSVWriter) Flush() error {
+	if err := this.writeHeader(); err != nil {
+		return err
+	}
+	this.writer.Flush()
+	return this.writer.Error()
+}
+
+func (this *CSVWriter) writeHeader() error {
+	if this.headerWritten {
+		return nil
+	}
+	this.headerWritten = true
+	return this.writer.WriteAll(model.NewStepLife().CSVHeader)
+}
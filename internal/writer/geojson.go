@@ -0,0 +1,113 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string      `json:"type"`
+	Geometry   interface{} `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string       `json:"type"`
+	Coordinates [][3]float64 `json:"coordinates"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [3]float64 `json:"coordinates"`
+}
+
+// geoJSONStopProperties 停留点属性，kind 固定为 "stop" 以便与轨迹区分
+type geoJSONStopProperties struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Duration  int64  `json:"duration"`
+}
+
+// geoJSONProperties 时间与速度按坐标顺序逐点存放，coordTimes 与 togeojson 等工具的约定一致。
+// 含无时间点的轨迹段不输出这三个属性
+type geoJSONProperties struct {
+	Name       string    `json:"name"`
+	CoordTimes []string  `json:"coordTimes,omitempty"`
+	Timestamps []int64   `json:"timestamps,omitempty"`
+	Speeds     []float64 `json:"speeds,omitempty"`
+}
+
+type GeoJSONWriter struct{}
+
+func NewGeoJSONWriter() *GeoJSONWriter {
+	return &GeoJSONWriter{}
+}
+
+func (this *GeoJSONWriter) Ext() string {
+	return ".geojson"
+}
+
+// Write 每个轨迹段输出为一个 LineString Feature，坐标为 [经度, 纬度, 海拔]，停留点输出为 Point Feature。
+// LineString 至少需要两个坐标（RFC 7946），只有一个点的轨迹段输出为 Point Feature。
+// 与 KML 的 LineString 一致，含无时间点的轨迹段只输出坐标，不输出逐点的时间和速度
+func (this *GeoJSONWriter) Write(w io.Writer, name string, segments [][]model.Row, stops []model.Stop) error {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	for i, segment := range segments {
+		if len(segment) == 0 {
+			continue
+		}
+		line := geoJSONGeometry{Type: "LineString"}
+		properties := geoJSONProperties{Name: name}
+		if len(segments) > 1 {
+			properties.Name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		withTime := timed(segment)
+		for _, row := range segment {
+			line.Coordinates = append(line.Coordinates,
+				[3]float64{row.Longitude, row.Latitude, row.Altitude})
+			if !withTime {
+				continue
+			}
+			properties.CoordTimes = append(properties.CoordTimes, formatTime(row.DataTime))
+			properties.Timestamps = append(properties.Timestamps, row.DataTime)
+			properties.Speeds = append(properties.Speeds, row.Speed)
+		}
+		var geometry interface{} = line
+		if len(line.Coordinates) == 1 {
+			geometry = geoJSONPoint{Type: "Point", Coordinates: line.Coordinates[0]}
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geometry,
+			Properties: properties,
+		})
+	}
+	for _, stop := range stops {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONPoint{Type: "Point", Coordinates: [3]float64{stop.Longitude, stop.Latitude, stop.Altitude}},
+			Properties: geoJSONStopProperties{
+				Name:      stop.Name,
+				Kind:      "stop",
+				StartTime: formatTime(stop.StartTime),
+				EndTime:   formatTime(stop.EndTime),
+				Duration:  stop.EndTime - stop.StartTime,
+			},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
@@ -0,0 +1,45 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"steplife-universal-importer-gui/internal/model"
+	"testing"
+)
+
+func TestGeoJSONUntimedSegment(t *testing.T) {
+	segments := [][]model.Row{
+		{
+			{Point: model.Point{Latitude: 30, Longitude: 120, DataTime: 1714521600, Speed: 1}},
+			{Point: model.Point{Latitude: 30.001, Longitude: 120, DataTime: 1714521660, Speed: 2}},
+		},
+		{
+			{Point: model.Point{Latitude: 31, Longitude: 121, DataTime: 1714525200}},
+			{Point: model.Point{Latitude: 31.001, Longitude: 121}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := NewGeoJSONWriter().Write(&buf, "trip", segments, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var collection struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 2 {
+		t.Fatalf("len(Features) = %d, want 2", len(collection.Features))
+	}
+	for i, want := range []bool{true, false} {
+		properties := collection.Features[i].Properties
+		for _, key := range []string{"coordTimes", "timestamps", "speeds"} {
+			if _, ok := properties[key]; ok != want {
+				t.Errorf("feature %d has %s = %v, want %v", i, key, ok, want)
+			}
+		}
+	}
+}
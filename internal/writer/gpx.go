@@ -0,0 +1,142 @@
+package writer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+	"time"
+)
+
+const (
+	gpxNamespace = "http://www.topografix.com/GPX/1/1"
+	// Garmin TrackPointExtension v2，GPX 1.1 中的速度通过该扩展写出
+	gpxTpxNamespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v2"
+)
+
+type gpxFile struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	XmlnsTpx  string        `xml:"xmlns:gpxtpx,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+	Tracks    []gpxTrack    `xml:"trk"`
+}
+
+// gpxWaypoint 停留点输出为航点，时间为到达时间，停留时长写入描述
+type gpxWaypoint struct {
+	Lat  string `xml:"lat,attr"`
+	Lon  string `xml:"lon,attr"`
+	Ele  string `xml:"ele"`
+	Time string `xml:"time,omitempty"`
+	Name string `xml:"name"`
+	Desc string `xml:"desc"`
+	Type string `xml:"type"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat        string         `xml:"lat,attr"`
+	Lon        string         `xml:"lon,attr"`
+	Ele        string         `xml:"ele"`
+	Time       string         `xml:"time,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxExtensions struct {
+	TrackPointExtension gpxTpx `xml:"gpxtpx:TrackPointExtension"`
+}
+
+type gpxTpx struct {
+	Speed string `xml:"gpxtpx:speed"`
+}
+
+type GpxWriter struct{}
+
+func NewGpxWriter() *GpxWriter {
+	return &GpxWriter{}
+}
+
+func (this *GpxWriter) Ext() string {
+	return ".gpx"
+}
+
+// Write 输出 GPX 1.1，所有轨迹段写入同一个 trk，每段对应一个 trkseg，停留点写为 wpt
+func (this *GpxWriter) Write(w io.Writer, name string, segments [][]model.Row, stops []model.Stop) error {
+	track := gpxTrack{Name: name}
+	for _, segment := range segments {
+		if len(segment) == 0 {
+			continue
+		}
+		var gpxSeg gpxSegment
+		for _, row := range segment {
+			pt := gpxPoint{
+				Lat:  fmt.Sprintf("%.8f", row.Latitude),
+				Lon:  fmt.Sprintf("%.8f", row.Longitude),
+				Ele:  fmt.Sprintf("%.2f", row.Altitude),
+				Time: formatTime(row.DataTime),
+			}
+			if row.Speed > 0 {
+				pt.Extensions = &gpxExtensions{
+					TrackPointExtension: gpxTpx{Speed: fmt.Sprintf("%.2f", row.Speed)},
+				}
+			}
+			gpxSeg.Points = append(gpxSeg.Points, pt)
+		}
+		track.Segments = append(track.Segments, gpxSeg)
+	}
+
+	file := gpxFile{
+		Version:  "1.1",
+		Creator:  consts.AppName,
+		Xmlns:    gpxNamespace,
+		XmlnsTpx: gpxTpxNamespace,
+		Tracks:   []gpxTrack{track},
+	}
+	for _, stop := range stops {
+		file.Waypoints = append(file.Waypoints, gpxWaypoint{
+			Lat:  fmt.Sprintf("%.8f", stop.Latitude),
+			Lon:  fmt.Sprintf("%.8f", stop.Longitude),
+			Ele:  fmt.Sprintf("%.2f", stop.Altitude),
+			Time: formatTime(stop.StartTime),
+			Name: stop.Name,
+			Desc: stopDescription(stop),
+			Type: "stop",
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// stopDescription 停留点描述：起止时间与停留时长
+func stopDescription(stop model.Stop) string {
+	return fmt.Sprintf("%s - %s，停留%d分钟", formatTime(stop.StartTime), formatTime(stop.EndTime),
+		(stop.EndTime-stop.StartTime)/60)
+}
+
+// formatTime 将时间戳格式化为 UTC 的 RFC3339 字符串，0 表示无时间
+func formatTime(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,67 @@
+package writer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	consts "steplife-universal-importer-gui/internal/const"
+	"steplife-universal-importer-gui/internal/model"
+)
+
+type FileWriter interface {
+	//
+	// Write
+	//  @Description: 		将处理后的轨迹行写出为目标格式
+	//  @param w
+	//  @param name			轨迹名称
+	//  @param segments		轨迹段，每段为一组按顺序排列的轨迹行
+	//  @param stops		命名停留点，为空时不输出
+	//  @return error
+	//
+	Write(w io.Writer, name string, segments [][]model.Row, stops []model.Stop) error
+
+	//
+	// Ext
+	//  @Description: 		输出文件扩展名（含点）
+	//  @return string
+	//
+	Ext() string
+}
+
+func CreateWriter(format string) FileWriter {
+	switch format {
+	case consts.OutputFormatOvjsn:
+		return NewOvjsnWriter()
+	case consts.OutputFormatGPX:
+		return NewGpxWriter()
+	case consts.OutputFormatKML:
+		return NewKmlWriter()
+	case consts.OutputFormatGeoJSON:
+		return NewGeoJSONWriter()
+	default:
+		return nil
+	}
+}
+
+// WriteFile
+//
+//	@Description: 		使用指定的写出器写入文件（覆盖模式）
+//	@param filePath
+//	@param fw
+//	@param name			轨迹名称
+//	@param segments
+//	@param stops		命名停留点
+//	@return error
+func WriteFile(filePath string, fw FileWriter, name string, segments [][]model.Row, stops []model.Stop) error {
+	file, err := os.OpenFile(filePath, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	bw := bufio.NewWriter(file)
+	if err = fw.Write(bw, name, segments, stops); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
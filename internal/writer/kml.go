@@ -0,0 +1,188 @@
+package writer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+	"strings"
+)
+
+const (
+	kmlNamespace   = "http://www.opengis.net/kml/2.2"
+	kmlGxNamespace = "http://www.google.com/kml/ext/2.2"
+)
+
+type kmlFile struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	XmlnsGx  string      `xml:"xmlns:gx,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Schema     kmlSchema      `xml:"Schema"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlSchema struct {
+	ID    string                `xml:"id,attr"`
+	Field []kmlSimpleArrayField `xml:"gx:SimpleArrayField"`
+}
+
+type kmlSimpleArrayField struct {
+	Name        string `xml:"name,attr"`
+	Type        string `xml:"type,attr"`
+	DisplayName string `xml:"displayName"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	TimeSpan    *kmlTimeSpan   `xml:"TimeSpan,omitempty"`
+	Point       *kmlPoint      `xml:"Point,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+	Track       *kmlTrack      `xml:"gx:Track,omitempty"`
+}
+
+// kmlTimeSpan 停留点的起止时间
+type kmlTimeSpan struct {
+	Begin string `xml:"begin"`
+	End   string `xml:"end"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlLineString 没有时间的轨迹段，坐标以空白分隔
+type kmlLineString struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// kmlTrack 使用 gx:Track 保留每个点的时间，速度写入 ExtendedData
+type kmlTrack struct {
+	AltitudeMode string          `xml:"altitudeMode"`
+	When         []string        `xml:"when"`
+	Coord        []string        `xml:"gx:coord"`
+	ExtendedData kmlExtendedData `xml:"ExtendedData"`
+}
+
+type kmlExtendedData struct {
+	SchemaData kmlSchemaData `xml:"SchemaData"`
+}
+
+type kmlSchemaData struct {
+	SchemaURL string               `xml:"schemaUrl,attr"`
+	ArrayData []kmlSimpleArrayData `xml:"gx:SimpleArrayData"`
+}
+
+type kmlSimpleArrayData struct {
+	Name   string   `xml:"name,attr"`
+	Values []string `xml:"gx:value"`
+}
+
+type KmlWriter struct{}
+
+func NewKmlWriter() *KmlWriter {
+	return &KmlWriter{}
+}
+
+func (this *KmlWriter) Ext() string {
+	return ".kml"
+}
+
+// Write 每个轨迹段输出为一个带 gx:Track 的 Placemark，停留点输出为带 TimeSpan 的 Point Placemark。
+// gx:Track 的每个点都需要时间，含无时间点的轨迹段输出为 LineString（只有一个点时为 Point）
+func (this *KmlWriter) Write(w io.Writer, name string, segments [][]model.Row, stops []model.Stop) error {
+	doc := kmlDocument{
+		Name: name,
+		Schema: kmlSchema{
+			ID: "trackSchema",
+			Field: []kmlSimpleArrayField{
+				{Name: "speed", Type: "float", DisplayName: "速度(m/s)"},
+			},
+		},
+	}
+
+	for i, segment := range segments {
+		if len(segment) == 0 {
+			continue
+		}
+		placemarkName := name
+		if len(segments) > 1 {
+			placemarkName = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		if len(segment) == 1 && segment[0].DataTime == 0 {
+			row := segment[0]
+			doc.Placemarks = append(doc.Placemarks, kmlPlacemark{Name: placemarkName,
+				Point: &kmlPoint{Coordinates: fmt.Sprintf("%.8f,%.8f,%.2f", row.Longitude, row.Latitude, row.Altitude)}})
+			continue
+		}
+		if !timed(segment) {
+			doc.Placemarks = append(doc.Placemarks, kmlPlacemark{Name: placemarkName, LineString: lineString(segment)})
+			continue
+		}
+
+		track := kmlTrack{
+			AltitudeMode: "absolute",
+			ExtendedData: kmlExtendedData{
+				SchemaData: kmlSchemaData{SchemaURL: "#trackSchema"},
+			},
+		}
+		speeds := kmlSimpleArrayData{Name: "speed"}
+		for _, row := range segment {
+			track.When = append(track.When, formatTime(row.DataTime))
+			track.Coord = append(track.Coord, fmt.Sprintf("%.8f %.8f %.2f", row.Longitude, row.Latitude, row.Altitude))
+			speeds.Values = append(speeds.Values, fmt.Sprintf("%.2f", row.Speed))
+		}
+		track.ExtendedData.SchemaData.ArrayData = []kmlSimpleArrayData{speeds}
+		doc.Placemarks = append(doc.Placemarks, kmlPlacemark{Name: placemarkName, Track: &track})
+	}
+	for _, stop := range stops {
+		doc.Placemarks = append(doc.Placemarks, kmlPlacemark{
+			Name:        stop.Name,
+			Description: stopDescription(stop),
+			TimeSpan:    &kmlTimeSpan{Begin: formatTime(stop.StartTime), End: formatTime(stop.EndTime)},
+			Point:       &kmlPoint{Coordinates: fmt.Sprintf("%.8f,%.8f,%.2f", stop.Longitude, stop.Latitude, stop.Altitude)},
+		})
+	}
+
+	file := kmlFile{
+		Xmlns:    kmlNamespace,
+		XmlnsGx:  kmlGxNamespace,
+		Document: doc,
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// timed 轨迹段的每个点是否都有时间
+func timed(segment []model.Row) bool {
+	for _, row := range segment {
+		if row.DataTime == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// lineString 将轨迹段转换为 LineString，坐标格式为 经度,纬度,海拔
+func lineString(segment []model.Row) *kmlLineString {
+	coordinates := make([]string, len(segment))
+	for i, row := range segment {
+		coordinates[i] = fmt.Sprintf("%.8f,%.8f,%.2f", row.Longitude, row.Latitude, row.Altitude)
+	}
+	return &kmlLineString{AltitudeMode: "absolute", Coordinates: strings.Join(coordinates, " ")}
+}
@@ -0,0 +1,101 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"steplife-universal-importer-gui/internal/model"
+	"time"
+)
+
+const (
+	ovjsnVersion = "V10.4.0"
+	// 奥维对象类型：轨迹
+	ovjsnObjTypeTrack = 8
+	// 根节点 ID，顶层对象的 ParentID
+	ovjsnRootID = 1
+)
+
+// ovjsnFile 与 parser.Ovjsn 读取的 ObjItems/Object/ObjectDetail/Latlng 结构保持一致
+type ovjsnFile struct {
+	Version  string         `json:"Version"`
+	Type     int            `json:"Type"`
+	ObjItems []ovjsnObjItem `json:"ObjItems"`
+}
+
+type ovjsnObjItem struct {
+	Type     int         `json:"Type"`
+	ObjID    int64       `json:"ObjID"`
+	ParentID int64       `json:"ParentID"`
+	TmModify string      `json:"tmModify"`
+	Object   ovjsnObject `json:"Object"`
+}
+
+type ovjsnObject struct {
+	Name         string            `json:"Name"`
+	Type         int               `json:"Type"`
+	Comment      string            `json:"Comment"`
+	ObjectDetail ovjsnObjectDetail `json:"ObjectDetail"`
+}
+
+type ovjsnObjectDetail struct {
+	// 0 表示 WGS84 坐标
+	Gcj02     int       `json:"Gcj02"`
+	TrackType int       `json:"TrackType"`
+	Latlng    []float64 `json:"Latlng"`
+}
+
+type OvjsnWriter struct{}
+
+func NewOvjsnWriter() *OvjsnWriter {
+	return &OvjsnWriter{}
+}
+
+func (this *OvjsnWriter) Ext() string {
+	return ".ovjsn"
+}
+
+// Write 每个轨迹段输出为一个奥维轨迹对象，Latlng 按 [纬度, 经度, ...] 交替排列。停留点暂不输出
+func (this *OvjsnWriter) Write(w io.Writer, name string, segments [][]model.Row, stops []model.Stop) error {
+	now := time.Now()
+	file := ovjsnFile{
+		Version:  ovjsnVersion,
+		Type:     1,
+		ObjItems: []ovjsnObjItem{},
+	}
+
+	for i, segment := range segments {
+		if len(segment) == 0 {
+			continue
+		}
+		latLng := make([]float64, 0, len(segment)*2)
+		for _, row := range segment {
+			latLng = append(latLng, row.Latitude, row.Longitude)
+		}
+
+		objName := name
+		if len(segments) > 1 {
+			objName = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		file.ObjItems = append(file.ObjItems, ovjsnObjItem{
+			Type:     ovjsnObjTypeTrack,
+			ObjID:    now.Unix() + int64(i),
+			ParentID: ovjsnRootID,
+			TmModify: now.Format("2006/01/02 15:04:05"),
+			Object: ovjsnObject{
+				Name:    objName,
+				Type:    ovjsnObjTypeTrack,
+				Comment: "",
+				ObjectDetail: ovjsnObjectDetail{
+					Latlng: latLng,
+				},
+			},
+		})
+	}
+
+	// 奥维导出的文件带有 UTF-8 BOM，保持一致
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(file)
+}